@@ -3,6 +3,7 @@ package commands
 import (
 	"github.com/spf13/cobra"
 
+	"sagikazarmark.dev/mga/internal/cmd/commands/config"
 	"sagikazarmark.dev/mga/internal/cmd/commands/generate"
 	"sagikazarmark.dev/mga/internal/cmd/commands/scaffold"
 )
@@ -11,7 +12,11 @@ import (
 func AddCommands(cmd *cobra.Command) {
 	cmd.AddCommand(
 		NewNewCommand(),
+		config.NewConfigCommand(),
 		generate.NewGenerateCommand(),
+		generate.NewCleanCommand(),
+		NewLintCommand(),
+		NewMarkersCommand(),
 		scaffold.NewScaffoldCommand(),
 	)
 }
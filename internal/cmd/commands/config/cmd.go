@@ -0,0 +1,20 @@
+package config
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// NewConfigCommand returns a cobra command for `config` subcommands.
+func NewConfigCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "config",
+		Aliases: []string{"cfg"},
+		Short:   "Manage the project configuration (mga.yaml)",
+	}
+
+	cmd.AddCommand(
+		NewPrintCommand(),
+	)
+
+	return cmd
+}
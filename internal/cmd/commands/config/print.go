@@ -0,0 +1,57 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"sagikazarmark.dev/mga/internal/config"
+)
+
+// NewPrintCommand returns a cobra command for printing the effective configuration.
+func NewPrintCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "print",
+		Short: "Print the effective configuration",
+		Long: `This command prints the effective configuration used by generators.
+
+The configuration is loaded from the mga.yaml file at the root of the current module
+and completed with defaults (eg. the default output rule of each generator).
+Paths are resolved relative to the module root.
+`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceErrors = true
+			cmd.SilenceUsage = true
+
+			return runPrint(os.Stdout)
+		},
+	}
+
+	return cmd
+}
+
+func runPrint(w io.Writer) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	if path := cfg.Path(); path != "" {
+		fmt.Fprintf(w, "# Configuration loaded from %s\n", path)
+	} else {
+		fmt.Fprintf(w, "# No %s found, showing defaults\n", config.FileName)
+	}
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+
+	if err := encoder.Encode(cfg.Effective()); err != nil {
+		return err
+	}
+
+	return encoder.Close()
+}
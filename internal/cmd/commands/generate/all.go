@@ -0,0 +1,307 @@
+package generate
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/controller-tools/pkg/genall"
+	"sigs.k8s.io/controller-tools/pkg/loader"
+
+	"sagikazarmark.dev/mga/internal/config"
+	"sagikazarmark.dev/mga/internal/generate/check"
+	"sagikazarmark.dev/mga/internal/generate/diagnostics"
+	"sagikazarmark.dev/mga/internal/generate/generators"
+	"sagikazarmark.dev/mga/internal/generate/runner"
+	"sagikazarmark.dev/mga/internal/generate/template/templategen"
+	"sagikazarmark.dev/mga/internal/generate/watch"
+	"sagikazarmark.dev/mga/pkg/genutils"
+)
+
+type allOptions struct {
+	headerFile  string
+	year        string
+	templateDir string
+	build       runner.BuildOptions
+
+	generators  []string
+	outputs     []string
+	parallelism int
+	check       bool
+	prune       bool
+	watch       bool
+	plugins     bool
+
+	diagnosticsFormat string
+
+	paths []string
+
+	config config.Config
+}
+
+// NewAllCommand returns a cobra command for running every generator at once.
+func NewAllCommand() *cobra.Command {
+	var options allOptions
+
+	factories := generators.All()
+
+	names := make([]string, 0, len(factories))
+	for _, factory := range factories {
+		names = append(names, factory.Name)
+	}
+
+	cmd := &cobra.Command{
+		Use:     "all [flags] [paths]",
+		Aliases: []string{"a"},
+		Short:   "Run every generator in a single pass",
+		Long: fmt.Sprintf(`This command runs every generator (or a subset of them) in a single pass.
+
+Packages are loaded and type-checked only once (instead of once per generator)
+and processed in parallel, which makes it considerably faster on large trees.
+
+Available generators (and their default output rules):
+
+%s
+Output rules can be overridden per generator (in mga.yaml or on the command line):
+
+	mga generate all --output kit:endpoint=subpkg:suffix=transport ./...
+
+Generator plugins (mga-gen-<name> executables found in PATH) run with every other generator
+as plugin:<name> generators (with the pkg output rule by default).
+`, generatorList()),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceErrors = true
+			cmd.SilenceUsage = true
+
+			cfg, err := config.Load()
+			if err != nil {
+				return err
+			}
+
+			if err := cfg.ApplyFlags("", cmd.Flags()); err != nil {
+				return err
+			}
+
+			// every generator (including plugins) runs unless selected explicitly
+			if !cmd.Flags().Changed("generators") {
+				options.generators = nil
+			}
+
+			options.paths = args
+			options.config = cfg
+
+			return runAll(options)
+		},
+	}
+
+	flags := cmd.Flags()
+
+	flags.StringSliceVar(&options.generators, "generators", names, "generators to run")
+	flags.StringArrayVar(&options.outputs, "output", nil, "output rule override for a generator (eg. kit:endpoint=subpkg:suffix=driver)")
+	flags.BoolVar(&options.check, "check", false, "check that generated files are up-to-date without writing anything")
+	flags.BoolVar(&options.prune, "prune", false, "remove generated files that are not generated anymore")
+	flags.BoolVar(&options.watch, "watch", false, "regenerate code when source files change")
+	flags.BoolVar(&options.plugins, "plugins", true, "run generator plugins (mga-gen-<name> executables) found in PATH")
+	flags.StringVar(&options.diagnosticsFormat, "diagnostics-format", "text", "format of reported errors (text, json or sarif)")
+	flags.IntVar(&options.parallelism, "parallelism", 0, "number of packages processed at the same time (defaults to the number of CPUs)")
+	flags.StringVar(&options.headerFile, "header-file", "", "header text (e.g. license) to prepend to generated files")
+	flags.StringVar(&options.year, "year", "", "copyright year")
+	flags.StringVar(&options.templateDir, "template-dir", templategen.DefaultTemplateDir, "directory user-supplied templates are read from")
+	flags.StringSliceVar(&options.build.Tags, "tags", nil, "additional build tags used when loading packages")
+	flags.StringVar(&options.build.GOOS, "goos", "", "target operating system used when loading packages")
+	flags.StringVar(&options.build.GOARCH, "goarch", "", "target architecture used when loading packages")
+
+	return cmd
+}
+
+func generatorList() string {
+	var list strings.Builder
+
+	for _, factory := range generators.All() {
+		fmt.Fprintf(&list, "\t%-18s %s\n", factory.Name, factory.Output)
+	}
+
+	return list.String()
+}
+
+func runAll(options allOptions) error {
+	var newOutput outputFactory
+
+	switch {
+	case options.check:
+		newOutput = check.NewOutput
+
+	case options.prune:
+		newOutput = check.NewWritingOutput
+	}
+
+	if options.watch && (options.check || options.prune) {
+		return errors.New("--watch cannot be used with --check or --prune")
+	}
+
+	runtime, checkOutputs, err := newRuntime(options, newOutput)
+	if err != nil {
+		return err
+	}
+
+	hadErrs := runtime.Run()
+
+	if options.watch {
+		return watch.Watch(runtime.Roots, options.build.Load, func(roots []*loader.Package) bool {
+			runtime.Roots = options.config.Filter(roots)
+
+			return runtime.Run()
+		}, runtime.OutputFiles())
+	}
+
+	if hadErrs {
+		os.Exit(1)
+	}
+
+	if options.check {
+		stale, err := check.Run(os.Stdout, runtime.Roots, checkOutputs...)
+		if err != nil {
+			return err
+		}
+
+		if stale {
+			os.Exit(1)
+		}
+	}
+
+	if options.prune && !options.check {
+		if err := check.Prune(os.Stdout, runtime.Roots, false, checkOutputs...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// outputFactory wraps the output rule of a generator.
+type outputFactory func(rule genall.OutputRule, generator genall.Generator) *check.Output
+
+// newRuntime returns a runtime running the selected generators.
+//
+// When newOutput is not nil, output rules are wrapped (eg. to keep track of generated files).
+func newRuntime(options allOptions, newOutput outputFactory) (*runner.Runtime, []*check.Output, error) {
+	diagnosticsFormat, err := diagnostics.ParseFormat(options.diagnosticsFormat)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	factories := generators.All()
+
+	if options.plugins {
+		factories = generators.WithPlugins(os.Stderr)
+	}
+
+	outputs := map[string]string{}
+
+	for _, factory := range factories {
+		outputs[factory.Name] = options.config.Output(factory.Name)
+	}
+
+	for _, output := range options.outputs {
+		name, rule, ok := strings.Cut(output, "=")
+		if !ok {
+			return nil, nil, fmt.Errorf("invalid output rule override %q (expected <generator>=<rule>)", output)
+		}
+
+		if _, ok := outputs[name]; !ok {
+			return nil, nil, fmt.Errorf("unknown generator %q", name)
+		}
+
+		outputs[name] = rule
+	}
+
+	selected := map[string]bool{}
+
+	for _, factory := range factories {
+		selected[factory.Name] = options.generators == nil
+	}
+
+	for _, name := range options.generators {
+		if _, ok := outputs[name]; !ok {
+			return nil, nil, fmt.Errorf("unknown generator %q (available generators: %s)", name, strings.Join(sortedKeys(outputs), ", "))
+		}
+
+		selected[name] = true
+	}
+
+	var runners []runner.Generator
+	var checkOutputs []*check.Output
+
+	for _, factory := range factories {
+		if !selected[factory.Name] {
+			continue
+		}
+
+		outputRule, err := genutils.LookupOutput(outputs[factory.Name])
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", factory.Name, err)
+		}
+
+		generatorOptions := generators.Options{
+			HeaderFile:  options.headerFile,
+			Year:        options.year,
+			TemplateDir: options.templateDir,
+			FileName:    options.config.Generators[factory.Name].FileName,
+			PerType:     options.config.Generators[factory.Name].PerType,
+		}
+
+		if err := generatorOptions.Validate(); err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", factory.Name, err)
+		}
+
+		generator := factory.New(generatorOptions)
+
+		if newOutput != nil {
+			checkOutput := newOutput(outputRule, generator)
+			checkOutputs = append(checkOutputs, checkOutput)
+
+			outputRule = checkOutput
+		}
+
+		runners = append(runners, runner.Generator{
+			Name:       factory.Name,
+			Generator:  generator,
+			OutputRule: outputRule,
+		})
+	}
+
+	if len(options.paths) == 0 {
+		options.paths = options.config.Include()
+	}
+
+	roots, err := options.build.Load(options.paths...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	runtime, err := runner.New(runners, roots)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	runtime.Roots = options.config.Filter(runtime.Roots)
+	runtime.Parallelism = options.parallelism
+	runtime.DiagnosticsFormat = diagnosticsFormat
+
+	return runtime, checkOutputs, nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+
+	for key := range m {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,85 @@
+package generate
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"sagikazarmark.dev/mga/internal/config"
+	"sagikazarmark.dev/mga/internal/generate/check"
+	"sagikazarmark.dev/mga/internal/generate/template/templategen"
+)
+
+type cleanOptions struct {
+	allOptions
+
+	dryRun bool
+}
+
+// NewCleanCommand returns a cobra command for removing orphaned generated files.
+func NewCleanCommand() *cobra.Command {
+	var options cleanOptions
+
+	cmd := &cobra.Command{
+		Use:   "clean [flags] [paths]",
+		Short: "Remove generated files that are not generated anymore",
+		Long: `This command removes files generated by mga (containing the
+"Code generated by mga tool. DO NOT EDIT." header) that no generator would produce anymore
+for the selected packages (eg. because the marker was removed from the source).
+
+Generators run in memory (nothing else is written to disk) using the same output rules as
+"mga generate all" (including overrides in mga.yaml and on the command line).
+
+Use --dry-run to list the files that would be removed.
+`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceErrors = true
+			cmd.SilenceUsage = true
+
+			cfg, err := config.Load()
+			if err != nil {
+				return err
+			}
+
+			if err := cfg.ApplyFlags("", cmd.Flags()); err != nil {
+				return err
+			}
+
+			if !cmd.Flags().Changed("generators") {
+				options.generators = nil
+			}
+
+			options.paths = args
+			options.config = cfg
+
+			return runClean(options)
+		},
+	}
+
+	flags := cmd.Flags()
+
+	flags.BoolVar(&options.dryRun, "dry-run", false, "list files that would be removed without removing them")
+	flags.StringSliceVar(&options.generators, "generators", config.GeneratorNames(), "generators to run")
+	flags.StringArrayVar(&options.outputs, "output", nil, "output rule override for a generator (eg. kit:endpoint=subpkg:suffix=driver)")
+	flags.BoolVar(&options.plugins, "plugins", true, "run generator plugins (mga-gen-<name> executables) found in PATH")
+	flags.IntVar(&options.parallelism, "parallelism", 0, "number of packages processed at the same time (defaults to the number of CPUs)")
+	flags.StringVar(&options.templateDir, "template-dir", templategen.DefaultTemplateDir, "directory user-supplied templates are read from")
+	flags.StringSliceVar(&options.build.Tags, "tags", nil, "additional build tags used when loading packages")
+	flags.StringVar(&options.build.GOOS, "goos", "", "target operating system used when loading packages")
+	flags.StringVar(&options.build.GOARCH, "goarch", "", "target architecture used when loading packages")
+
+	return cmd
+}
+
+func runClean(options cleanOptions) error {
+	runtime, checkOutputs, err := newRuntime(options.allOptions, check.NewOutput)
+	if err != nil {
+		return err
+	}
+
+	if hadErrs := runtime.Run(); hadErrs {
+		os.Exit(1)
+	}
+
+	return check.Prune(os.Stdout, runtime.Roots, options.dryRun, checkOutputs...)
+}
@@ -3,6 +3,7 @@ package generate
 import (
 	"github.com/spf13/cobra"
 
+	"sagikazarmark.dev/mga/internal/cmd/commands/generate/command"
 	"sagikazarmark.dev/mga/internal/cmd/commands/generate/event"
 	"sagikazarmark.dev/mga/internal/cmd/commands/generate/kit"
 	"sagikazarmark.dev/mga/internal/cmd/commands/generate/testify"
@@ -17,9 +18,12 @@ func NewGenerateCommand() *cobra.Command {
 	}
 
 	cmd.AddCommand(
+		NewAllCommand(),
+		command.NewCommandsCommand(),
 		event.NewEventsCommand(),
 		kit.NewKitCommand(),
 		testify.NewTestifyCommand(),
+		NewTemplateCommand(),
 		NewMockeryCommand(),
 	)
 
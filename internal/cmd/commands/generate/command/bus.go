@@ -0,0 +1,44 @@
+package command
+
+import (
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/controller-tools/pkg/genall"
+
+	"sagikazarmark.dev/mga/internal/generate/command/bus/busgen"
+	"sagikazarmark.dev/mga/pkg/generator"
+)
+
+// NewBusCommand returns a cobra command for generating a command sender.
+func NewBusCommand() *cobra.Command {
+	return generator.NewCommand(generator.Command{
+		Name:    "command:bus",
+		Use:     "bus [flags] [paths]",
+		Aliases: []string{"b"},
+		Short:   "Generate implementations for command bus interfaces",
+		Long: `This command generates type safe command sender implementations with an underlying generic command bus.
+The command bus itself is an interface generated alongside the sender:
+
+	type CommandBus interface {
+		Send(ctx context.Context, command interface{}) error
+	}
+
+You can either implement this interface yourself or use an implementation that's already compatible with it
+(for example Watermill: https://github.com/ThreeDotsLabs/watermill).
+
+Base interfaces follow the same rules as event dispatchers:
+
+	type Commands interface {
+		CreateTodo(ctx context.Context, command CreateTodo) error
+
+		// ... other commands
+	}
+
+where CreateTodo is a simple data structure containing the command payload.
+The context parameter and the error return value are both optional,
+but interface methods cannot accept or return more or different parameters.
+`,
+		New: func(base generator.Base) genall.Generator {
+			return busgen.Generator{Base: base}
+		},
+	})
+}
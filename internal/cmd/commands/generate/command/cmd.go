@@ -0,0 +1,21 @@
+package command
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// NewCommandsCommand returns a cobra command for `command` subcommands.
+func NewCommandsCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "command",
+		Aliases: []string{"c", "cmd", "commands"},
+		Short:   "Generate command related code",
+	}
+
+	cmd.AddCommand(
+		NewBusCommand(),
+		NewHandlerCommand(),
+	)
+
+	return cmd
+}
@@ -0,0 +1,31 @@
+package command
+
+import (
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/controller-tools/pkg/genall"
+
+	"sagikazarmark.dev/mga/internal/generate/command/handler/handlergen"
+	"sagikazarmark.dev/mga/pkg/generator"
+)
+
+// NewHandlerCommand returns a cobra command for generating a command handler.
+func NewHandlerCommand() *cobra.Command {
+	return generator.NewCommand(generator.Command{
+		Name:    "command:handler",
+		Use:     "handler [flags] [paths]",
+		Aliases: []string{"h"},
+		Short:   "Generate command handlers for commands",
+		Long: `This command generates type safe command handler implementations for commands.
+A command can be any plain, exported struct:
+
+	type CreateTodo struct {
+		Title string
+	}
+
+The generated handler is compatible with Watermill (https://github.com/ThreeDotsLabs/watermill).
+`,
+		New: func(base generator.Base) genall.Generator {
+			return handlergen.Generator{Base: base}
+		},
+	})
+}
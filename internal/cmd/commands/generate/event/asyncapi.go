@@ -0,0 +1,46 @@
+package event
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"sigs.k8s.io/controller-tools/pkg/genall"
+
+	"sagikazarmark.dev/mga/internal/generate/event/asyncapi/asyncapigen"
+	"sagikazarmark.dev/mga/pkg/generator"
+)
+
+// NewAsyncAPICommand returns a cobra command for generating an AsyncAPI document.
+func NewAsyncAPICommand() *cobra.Command {
+	var title, version, topicStrategy string
+
+	return generator.NewCommand(generator.Command{
+		Name:    "event:asyncapi",
+		Use:     "asyncapi [flags] [paths]",
+		Aliases: []string{"a"},
+		Short:   "Generate AsyncAPI documents from event dispatchers and event handlers",
+		Long: `This command generates an AsyncAPI 3 document for each package containing
+event dispatcher interfaces (+mga:event:dispatcher) or event structs (+mga:event:handler).
+
+Each dispatcher method becomes a send operation and each handled event becomes a receive operation.
+Channels are named after events according to the topic strategy:
+
+	struct:    the name of the event struct (eg. TodoCreated)
+	qualified: the name of the event struct qualified by its package name (eg. todo.TodoCreated)
+
+Message payload schemas are derived from the Go types the same way encoding/json marshals them.
+`,
+		NoHeader: true,
+		Flags: func(flags *pflag.FlagSet) {
+			flags.StringVar(&title, "title", "", "application title (defaults to the package name)")
+			flags.StringVar(&version, "api-version", "", "application API version (defaults to 1.0.0)")
+			flags.StringVar(&topicStrategy, "topic-strategy", "struct", "channel naming strategy (struct or qualified)")
+		},
+		New: func(_ generator.Base) genall.Generator {
+			return asyncapigen.Generator{
+				Title:         title,
+				Version:       version,
+				TopicStrategy: topicStrategy,
+			}
+		},
+	})
+}
@@ -13,8 +13,10 @@ func NewEventsCommand() *cobra.Command {
 	}
 
 	cmd.AddCommand(
+		NewAsyncAPICommand(),
 		NewDispatcherCommand(),
 		NewHandlerCommand(),
+		NewRegistryCommand(),
 	)
 
 	return cmd
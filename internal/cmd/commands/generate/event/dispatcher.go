@@ -1,28 +1,17 @@
 package event
 
 import (
-	"os"
-
 	"github.com/spf13/cobra"
 	"sigs.k8s.io/controller-tools/pkg/genall"
 
 	"sagikazarmark.dev/mga/internal/generate/event/dispatcher/dispatchergen"
-	"sagikazarmark.dev/mga/pkg/genutils"
+	"sagikazarmark.dev/mga/pkg/generator"
 )
 
-type dispatcherOptions struct {
-	headerFile string
-	year       string
-
-	paths  []string
-	output string
-}
-
 // NewDispatcherCommand returns a cobra command for generating an event dispatcher.
 func NewDispatcherCommand() *cobra.Command {
-	var options dispatcherOptions
-
-	cmd := &cobra.Command{
+	return generator.NewCommand(generator.Command{
+		Name:    "event:dispatcher",
 		Use:     "dispatcher [flags] [paths]",
 		Aliases: []string{"d", "disp"},
 		Short:   "Generate implementations for event dispatcher interfaces",
@@ -47,53 +36,28 @@ Base interfaces look like the following:
 where Event is a simple data structure containing the event payload.
 The context parameter and the error return value are both optional,
 but interface methods cannot accept or return more or different parameters.
-`,
-		RunE: func(cmd *cobra.Command, args []string) error {
-			cmd.SilenceErrors = true
-			cmd.SilenceUsage = true
 
-			options.paths = args
+Methods accepting a slice of events (eg. EventsImported(ctx context.Context, events []Event) error)
+publish every event at once when the event bus implements the generated BatchEventBus interface:
 
-			return runDispatcher(options)
-		},
+	type BatchEventBus interface {
+		PublishBatch(ctx context.Context, events []interface{}) error
 	}
 
-	flags := cmd.Flags()
-
-	flags.StringVar(&options.output, "output", "pkg", "output rule")
-	flags.StringVar(&options.headerFile, "header-file", "", "header text (e.g. license) to prepend to generated files")
-	flags.StringVar(&options.year, "year", "", "copyright year replacing YEAR in the header text")
-
-	return cmd
-}
-
-func runDispatcher(options dispatcherOptions) error {
-	var generator genall.Generator = dispatchergen.Generator{
-		HeaderFile: options.headerFile,
-		Year:       options.year,
-	}
+Otherwise events are published one by one and errors are aggregated.
 
-	generators := genall.Generators{&generator}
+Adding the recorder option to the marker (+mga:event:dispatcher:recorder=true) generates
+an in-memory RecordingEventBus in a test file. It records published events in order,
+provides typed accessors for each event (eg. PublishedEvent() []Event)
+and can return injected errors (FailWith) for testing failure scenarios.
 
-	if len(options.paths) == 0 {
-		options.paths = []string{"."}
-	}
-
-	runtime, err := generators.ForRoots(options.paths...)
-	if err != nil {
-		return err
-	}
-
-	outputRule, err := genutils.LookupOutput(options.output)
-	if err != nil {
-		return err
-	}
-
-	runtime.OutputRules.Default = outputRule
-
-	if hadErrs := runtime.Run(); hadErrs {
-		os.Exit(1)
-	}
-
-	return nil
+Adding the instrumented option to the marker (+mga:event:dispatcher:instrumented=true) generates
+an InstrumentedEventBus decorating any EventBus implementation. It records the number of published events,
+publishing errors and latency per event name and starts a producer span (using OpenTelemetry) for each event.
+When the underlying event bus implements MetadataEventBus, trace context is injected into the event metadata.
+`,
+		New: func(base generator.Base) genall.Generator {
+			return dispatchergen.Generator{Base: base}
+		},
+	})
 }
@@ -1,28 +1,17 @@
 package event
 
 import (
-	"os"
-
 	"github.com/spf13/cobra"
 	"sigs.k8s.io/controller-tools/pkg/genall"
 
 	"sagikazarmark.dev/mga/internal/generate/event/handler/handlergen"
-	"sagikazarmark.dev/mga/pkg/genutils"
+	"sagikazarmark.dev/mga/pkg/generator"
 )
 
-type handlerOptions struct {
-	headerFile string
-	year       string
-
-	paths  []string
-	output string
-}
-
 // NewHandlerCommand returns a cobra command for generating an event handler.
 func NewHandlerCommand() *cobra.Command {
-	var options handlerOptions
-
-	cmd := &cobra.Command{
+	return generator.NewCommand(generator.Command{
+		Name:    "event:handler",
 		Use:     "handler [flags] [paths]",
 		Aliases: []string{"h"},
 		Short:   "Generate event handlers for events",
@@ -34,53 +23,39 @@ An event can be any plain, exported struct:
 	}
 
 The generated handler is compatible with Watermill (https://github.com/ThreeDotsLabs/watermill).
-`,
-		RunE: func(cmd *cobra.Command, args []string) error {
-			cmd.SilenceErrors = true
-			cmd.SilenceUsage = true
-
-			options.paths = args
 
-			return runHandler(options)
+Events marked with the same group (+mga:event:handler:group=Todo) are handled by a single handler:
+a TodoHandler interface with one method per event, a NoopTodoHandler to embed in implementations
+and a TodoEventHandler that dispatches events to the right method based on their type.
+
+Older versions of an event can be marked with a version (+mga:event:version=1).
+Versions belong to the same event when their names only differ in a V<version> suffix
+(eg. TodoCreatedV1 and TodoCreatedV2 are versions of TodoCreated).
+The handled event is the latest version (unless marked with a version explicitly).
+The generated handler accepts every version and upcasts older ones step by step using a generated
+TodoCreatedUpcaster interface before calling the typed handler.
+Every version from 1 to the latest one must exist, missing upcaster steps fail the generation.
+
+Adding the instrumented option to the marker (+mga:event:handler:instrumented=true) generates
+an InstrumentedTodoCreatedEventHandler decorating the generated handler. It records the number of handled events,
+handling errors and latency per event name and starts a consumer span (using OpenTelemetry) for each event.
+HandleWithMetadata extracts trace context from event metadata (eg. message headers) before handling the event.
+
+Adding the idempotent option to the marker (+mga:event:handler:idempotent=true) generates
+an IdempotentTodoCreatedEventHandler decorating the generated handler. It handles each event at most once:
+the event key is looked up in a DedupStore before calling the handler and recorded after successful handling.
+The key is the field marked with +mga:event:key or the message ID stored in the context (using ContextWithMessageID).
+NewInMemoryDedupStore returns an in-memory store (with TTL) for tests and single instance deployments.
+
+Retry options on the marker (+mga:event:handler:maxAttempts=3,backoff=100ms,deadLetter="todo.dead_letter")
+generate a RetryingTodoCreatedEventHandler decorating the generated handler. It handles each event at most maxAttempts times,
+waiting backoff before the first retry (doubled after each attempt). Events failing every attempt are sent
+to the dead-letter destination using a generated DeadLetterSink interface (the error carries the handler name
+and the number of attempts as details). Without a dead-letter destination the last error is returned.
+Events in a group must share the same retry options.
+`,
+		New: func(base generator.Base) genall.Generator {
+			return handlergen.Generator{Base: base}
 		},
-	}
-
-	flags := cmd.Flags()
-
-	flags.StringVar(&options.output, "output", "pkg", "output rule")
-	flags.StringVar(&options.headerFile, "header-file", "", "header text (e.g. license) to prepend to generated files")
-	flags.StringVar(&options.year, "year", "", "copyright year")
-
-	return cmd
-}
-
-func runHandler(options handlerOptions) error {
-	var generator genall.Generator = handlergen.Generator{
-		HeaderFile: options.headerFile,
-		Year:       options.year,
-	}
-
-	generators := genall.Generators{&generator}
-
-	if len(options.paths) == 0 {
-		options.paths = []string{"."}
-	}
-
-	runtime, err := generators.ForRoots(options.paths...)
-	if err != nil {
-		return err
-	}
-
-	outputRule, err := genutils.LookupOutput(options.output)
-	if err != nil {
-		return err
-	}
-
-	runtime.OutputRules.Default = outputRule
-
-	if hadErrs := runtime.Run(); hadErrs {
-		os.Exit(1)
-	}
-
-	return nil
+	})
 }
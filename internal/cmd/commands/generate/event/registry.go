@@ -0,0 +1,35 @@
+package event
+
+import (
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/controller-tools/pkg/genall"
+
+	"sagikazarmark.dev/mga/internal/generate/event/registry/registrygen"
+	"sagikazarmark.dev/mga/pkg/generator"
+)
+
+// NewRegistryCommand returns a cobra command for generating an event registry.
+func NewRegistryCommand() *cobra.Command {
+	return generator.NewCommand(generator.Command{
+		Name:    "event:registry",
+		Use:     "registry [flags] [paths]",
+		Aliases: []string{"r", "reg"},
+		Short:   "Generate event registries mapping event names to event types",
+		Long: `This command generates an EventRegistry for each package containing
+event dispatcher interfaces (+mga:event:dispatcher) or event structs (+mga:event:handler, +mga:event:version).
+
+The registry maps stable event names to event types, so that events read from a generic stream
+can be unmarshaled into the right Go type:
+
+	event, err := registry.New(name) // returns a pointer to an empty event
+	name := registry.Name(event)     // returns the name of an event
+
+Events are named after their struct by default (the same way Watermill names them).
+The name can be overridden on the event struct (+mga:event:name=todo.created).
+Registering two different events under the same name is an error.
+`,
+		New: func(base generator.Base) genall.Generator {
+			return registrygen.Generator{Base: base}
+		},
+	})
+}
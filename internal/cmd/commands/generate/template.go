@@ -0,0 +1,34 @@
+package generate
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"sigs.k8s.io/controller-tools/pkg/genall"
+
+	"sagikazarmark.dev/mga/internal/generate/template/templategen"
+	"sagikazarmark.dev/mga/pkg/generator"
+)
+
+// NewTemplateCommand returns a cobra command for rendering user-supplied templates.
+func NewTemplateCommand() *cobra.Command {
+	var templateDir string
+
+	return generator.NewCommand(generator.Command{
+		Name:    "template",
+		Use:     "template [flags] [paths]",
+		Aliases: []string{"t"},
+		Short:   "Render user-supplied templates for types",
+		Long: `This command renders text/template files for types marked with +mga:template:name=<name>.
+
+The template <name>.tmpl is read from the template directory and rendered into zz_generated.<name>.go
+with the marked types of the package (methods, fields, tags, doc comments) as its input.
+`,
+		PerType: true,
+		Flags: func(flags *pflag.FlagSet) {
+			flags.StringVar(&templateDir, "template-dir", templategen.DefaultTemplateDir, "directory templates are read from")
+		},
+		New: func(base generator.Base) genall.Generator {
+			return templategen.Generator{Base: base, TemplateDir: templateDir}
+		},
+	})
+}
@@ -0,0 +1,124 @@
+package commands
+
+import (
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/controller-tools/pkg/loader"
+
+	"sagikazarmark.dev/mga/internal/config"
+	"sagikazarmark.dev/mga/internal/generate/diagnostics"
+	"sagikazarmark.dev/mga/internal/generate/generators"
+	"sagikazarmark.dev/mga/internal/generate/lint"
+	"sagikazarmark.dev/mga/internal/generate/runner"
+)
+
+type lintOptions struct {
+	plugins bool
+	build   runner.BuildOptions
+
+	diagnosticsFormat string
+}
+
+// NewLintCommand returns a cobra command for finding mistakes in markers.
+func NewLintCommand() *cobra.Command {
+	var options lintOptions
+
+	cmd := &cobra.Command{
+		Use:   "lint [paths]",
+		Short: "Report invalid markers",
+		Long: `This command reports markers generators would silently ignore:
+
+	- unknown markers (eg. typos in marker names)
+	- unknown arguments and argument values of the wrong type
+	- markers placed on unsupported declarations (eg. +kit:endpoint on a struct)
+
+Problems are reported with their position (file:line:col).
+Markers in function bodies and unattached markers are reported as warnings.
+Markers of generator plugins (mga-gen-<name> executables found in PATH) are checked as well.
+
+The command exits with a non-zero status if any errors are found.
+`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceErrors = true
+			cmd.SilenceUsage = true
+
+			cfg, err := config.Load()
+			if err != nil {
+				return err
+			}
+
+			if err := cfg.ApplyFlags("", cmd.Flags()); err != nil {
+				return err
+			}
+
+			diagnosticsFormat, err := diagnostics.ParseFormat(options.diagnosticsFormat)
+			if err != nil {
+				return err
+			}
+
+			if len(args) == 0 {
+				args = cfg.Include()
+			}
+
+			roots, err := options.build.Load(args...)
+			if err != nil {
+				return err
+			}
+
+			factories := generators.All()
+
+			if options.plugins {
+				factories = generators.WithPlugins(os.Stderr)
+			}
+
+			failed, err := runLint(os.Stdout, diagnosticsFormat, factories, cfg.Filter(roots))
+			if err != nil {
+				return err
+			}
+
+			if failed {
+				os.Exit(1)
+			}
+
+			return nil
+		},
+	}
+
+	flags := cmd.Flags()
+
+	flags.BoolVar(&options.plugins, "plugins", true, "check markers of generator plugins (mga-gen-<name> executables) found in PATH")
+	flags.StringVar(&options.diagnosticsFormat, "diagnostics-format", "text", "format of reported problems (text, json or sarif)")
+	flags.StringSliceVar(&options.build.Tags, "tags", nil, "additional build tags used when loading packages")
+	flags.StringVar(&options.build.GOOS, "goos", "", "target operating system used when loading packages")
+	flags.StringVar(&options.build.GOARCH, "goarch", "", "target architecture used when loading packages")
+
+	return cmd
+}
+
+func runLint(w io.Writer, format diagnostics.Format, factories []generators.Factory, roots []*loader.Package) (bool, error) {
+	registry, _, err := generators.Registry(factories)
+	if err != nil {
+		return false, err
+	}
+
+	var diags []diagnostics.Diagnostic
+
+	// packages that cannot be loaded are reported along with marker problems
+	for _, root := range roots {
+		for _, err := range root.Errors {
+			diags = append(diags, diagnostics.FromPackageError("", root.PkgPath, err))
+		}
+	}
+
+	diags = append(diags, lint.NewLinter(registry, generators.MarkerKinds(factories)).Lint(roots)...)
+
+	diagnostics.Sort(diags)
+
+	if err := diagnostics.Write(w, format, diags); err != nil {
+		return false, err
+	}
+
+	return diagnostics.HasErrors(diags), nil
+}
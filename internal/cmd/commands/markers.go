@@ -0,0 +1,75 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"sagikazarmark.dev/mga/internal/generate/generators"
+	"sagikazarmark.dev/mga/internal/generate/markerdoc"
+)
+
+type markersOptions struct {
+	format  string
+	plugins bool
+}
+
+// NewMarkersCommand returns a cobra command for documenting every supported marker.
+func NewMarkersCommand() *cobra.Command {
+	var options markersOptions
+
+	cmd := &cobra.Command{
+		Use:   "markers",
+		Short: "Document every marker supported by generators",
+		Long: `This command lists every marker recognized by generators in source code
+(with their targets, arguments, argument types and descriptions)
+and every output rule accepted by the --output flag.
+Markers of generator plugins (mga-gen-<name> executables found in PATH) are listed as well.
+
+Supported formats: text, markdown, json.
+`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceErrors = true
+			cmd.SilenceUsage = true
+
+			return runMarkers(os.Stdout, options)
+		},
+	}
+
+	flags := cmd.Flags()
+
+	flags.StringVar(&options.format, "format", "text", "output format (text, markdown or json)")
+	flags.BoolVar(&options.plugins, "plugins", true, "list markers of generator plugins (mga-gen-<name> executables) found in PATH")
+
+	return cmd
+}
+
+func runMarkers(w io.Writer, options markersOptions) error {
+	factories := generators.All()
+
+	if options.plugins {
+		factories = generators.WithPlugins(os.Stderr)
+	}
+
+	doc, err := markerdoc.Describe(factories)
+	if err != nil {
+		return err
+	}
+
+	switch options.format {
+	case "text":
+		return markerdoc.WriteText(w, doc)
+
+	case "markdown", "md":
+		return markerdoc.WriteMarkdown(w, doc)
+
+	case "json":
+		return markerdoc.WriteJSON(w, doc)
+
+	default:
+		return fmt.Errorf("unknown format %q (supported formats: text, markdown, json)", options.format)
+	}
+}
@@ -0,0 +1,437 @@
+// Package config loads the project configuration (mga.yaml) used by generators.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+	"sigs.k8s.io/controller-tools/pkg/loader"
+)
+
+// FileName is the name of the configuration file at the module root.
+const FileName = "mga.yaml"
+
+// Config is the project configuration.
+type Config struct {
+	// HeaderFile is the header text (e.g. license) prepended to generated files.
+	HeaderFile string `yaml:"headerFile,omitempty"`
+
+	// Year substitutes " YEAR" in the header text.
+	Year string `yaml:"year,omitempty"`
+
+	// TemplateDir is the directory user-supplied templates are read from.
+	TemplateDir string `yaml:"templateDir,omitempty"`
+
+	// Tags are additional build tags used when loading packages (eg. integration).
+	Tags []string `yaml:"tags,omitempty"`
+
+	// GOOS is the target operating system used when loading packages.
+	GOOS string `yaml:"goos,omitempty"`
+
+	// GOARCH is the target architecture used when loading packages.
+	GOARCH string `yaml:"goarch,omitempty"`
+
+	// Packages selects packages to generate code for.
+	Packages Packages `yaml:"packages,omitempty"`
+
+	// Generators configures individual generators (by name, eg. kit:endpoint).
+	Generators map[string]Generator `yaml:"generators,omitempty"`
+
+	// root is the directory relative paths in the configuration are resolved from.
+	root string
+
+	// path is the file the configuration was loaded from (if any).
+	path string
+
+	// outputs maps the names of generators not registered with RegisterGenerator to their default output rules.
+	outputs map[string]string
+}
+
+// Packages selects packages to generate code for.
+type Packages struct {
+	// Include lists package patterns generated when no packages are given on the command line.
+	Include []string `yaml:"include,omitempty"`
+
+	// Exclude lists package patterns that are never generated.
+	Exclude []string `yaml:"exclude,omitempty"`
+}
+
+// Generator configures a generator.
+type Generator struct {
+	// Output is the output rule of the generator (eg. subpkg:suffix=gen).
+	Output string `yaml:"output,omitempty"`
+
+	// FileName is the template of generated file names (eg. "{{ .Name }}.gen").
+	FileName string `yaml:"fileName,omitempty"`
+
+	// PerType writes a separate file for every marked type (if the generator supports it).
+	PerType bool `yaml:"perType,omitempty"`
+}
+
+// defaultOutputs maps the names of known generators to their default output rules.
+//
+// Generators are registered with RegisterGenerator (builtin ones by the generators package).
+// nolint: gochecknoglobals
+var defaultOutputs = map[string]string{}
+
+// pluginPrefix prefixes the names of generator plugins (eg. plugin:authz).
+const pluginPrefix = "plugin:"
+
+// PluginOutput is the default output rule of generator plugins.
+const PluginOutput = "pkg"
+
+// RegisterGenerator makes a generator known to the configuration with a default output rule.
+//
+// Registering an already known generator has no effect.
+func RegisterGenerator(name string, output string) {
+	if _, ok := defaultOutputs[name]; ok {
+		return
+	}
+
+	defaultOutputs[name] = output
+}
+
+// GeneratorNames returns the names of every known generator.
+func GeneratorNames() []string {
+	names := make([]string, 0, len(defaultOutputs))
+
+	for name := range defaultOutputs {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// Load loads the configuration from the root of the module containing the current directory.
+//
+// An empty configuration is returned when there is no configuration file.
+func Load() (Config, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return Config{}, err
+	}
+
+	return LoadFrom(moduleRoot(wd))
+}
+
+// LoadGenerator loads the configuration (see Load) making a generator not registered with RegisterGenerator
+// (eg. the generator of a custom command) known to it with a default output rule.
+//
+// Unlike RegisterGenerator, it does not make the generator known to other configurations.
+func LoadGenerator(name string, output string) (Config, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return Config{}, err
+	}
+
+	return loadFrom(moduleRoot(wd), map[string]string{name: output})
+}
+
+// LoadFrom loads the configuration from a directory.
+//
+// An empty configuration is returned when there is no configuration file.
+func LoadFrom(dir string) (Config, error) {
+	return loadFrom(dir, nil)
+}
+
+// loadFrom loads the configuration from a directory
+// with the default output rules of generators not registered with RegisterGenerator.
+func loadFrom(dir string, outputs map[string]string) (Config, error) {
+	config := Config{root: dir, outputs: outputs}
+
+	path := filepath.Join(dir, FileName)
+
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return config, nil
+	}
+	if err != nil {
+		return config, err
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+
+	if err := decoder.Decode(&config); err != nil && !errors.Is(err, io.EOF) {
+		return config, fmt.Errorf("invalid configuration file %s: %w", path, err)
+	}
+
+	for name := range config.Generators {
+		if _, ok := config.defaultOutput(name); !ok && !isPlugin(name) {
+			return config, fmt.Errorf(
+				"unknown generator %q in %s (available generators: %s)",
+				name,
+				path,
+				strings.Join(config.generatorNames(), ", "),
+			)
+		}
+	}
+
+	config.path = path
+
+	return config, nil
+}
+
+// moduleRoot returns the closest directory containing a go.mod file (or the directory itself).
+func moduleRoot(dir string) string {
+	for current := dir; ; {
+		if _, err := os.Stat(filepath.Join(current, "go.mod")); err == nil {
+			return current
+		}
+
+		parent := filepath.Dir(current)
+		if parent == current {
+			return dir
+		}
+
+		current = parent
+	}
+}
+
+// Path returns the file the configuration was loaded from (if any).
+func (c Config) Path() string {
+	return c.path
+}
+
+// Effective returns the configuration with defaults filled in and paths resolved.
+func (c Config) Effective() Config {
+	effective := c
+
+	effective.HeaderFile = c.headerFile()
+	effective.TemplateDir = c.templateDir()
+	effective.Packages.Include = c.Include()
+	effective.Packages.Exclude = make([]string, 0, len(c.Packages.Exclude))
+
+	for _, pattern := range c.Packages.Exclude {
+		effective.Packages.Exclude = append(effective.Packages.Exclude, c.resolve(pattern))
+	}
+	effective.Generators = make(map[string]Generator, len(defaultOutputs)+len(c.outputs))
+
+	for _, name := range c.generatorNames() {
+		effective.Generators[name] = c.generator(name)
+	}
+
+	for name := range c.Generators {
+		if isPlugin(name) {
+			effective.Generators[name] = c.generator(name)
+		}
+	}
+
+	return effective
+}
+
+// generator returns the configuration of a generator with defaults filled in.
+func (c Config) generator(name string) Generator {
+	generator := c.Generators[name]
+	generator.Output = c.Output(name)
+
+	return generator
+}
+
+// Output returns the output rule of a generator.
+func (c Config) Output(generator string) string {
+	if output := c.Generators[generator].Output; output != "" {
+		return output
+	}
+
+	if isPlugin(generator) {
+		return PluginOutput
+	}
+
+	output, _ := c.defaultOutput(generator)
+
+	return output
+}
+
+// defaultOutput returns the default output rule of a known generator.
+func (c Config) defaultOutput(generator string) (string, bool) {
+	if output, ok := c.outputs[generator]; ok {
+		return output, true
+	}
+
+	output, ok := defaultOutputs[generator]
+
+	return output, ok
+}
+
+// generatorNames returns the names of every generator known to the configuration.
+func (c Config) generatorNames() []string {
+	names := GeneratorNames()
+
+	for name := range c.outputs {
+		if _, ok := defaultOutputs[name]; !ok {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// isPlugin reports whether a generator name refers to a generator plugin.
+func isPlugin(generator string) bool {
+	name, ok := strings.CutPrefix(generator, pluginPrefix)
+
+	return ok && name != ""
+}
+
+// Include returns package patterns to generate code for when no packages are given on the command line.
+func (c Config) Include() []string {
+	if len(c.Packages.Include) == 0 {
+		return []string{"."}
+	}
+
+	include := make([]string, 0, len(c.Packages.Include))
+
+	for _, pattern := range c.Packages.Include {
+		include = append(include, c.resolve(pattern))
+	}
+
+	return include
+}
+
+// headerFile returns the header file path resolved from the configuration root.
+func (c Config) headerFile() string {
+	if c.HeaderFile == "" || filepath.IsAbs(c.HeaderFile) {
+		return c.HeaderFile
+	}
+
+	return filepath.Join(c.root, c.HeaderFile)
+}
+
+// templateDir returns the template directory resolved from the configuration root.
+func (c Config) templateDir() string {
+	if c.TemplateDir == "" || filepath.IsAbs(c.TemplateDir) {
+		return c.TemplateDir
+	}
+
+	return filepath.Join(c.root, c.TemplateDir)
+}
+
+// resolve resolves a relative package pattern from the configuration root.
+func (c Config) resolve(pattern string) string {
+	if !isRelative(pattern) || c.root == "" {
+		return pattern
+	}
+
+	resolved := filepath.Join(c.root, pattern)
+
+	// filepath.Join cleans the path, so it needs to be restored
+	if strings.HasSuffix(pattern, "/...") && !strings.HasSuffix(resolved, "/...") {
+		resolved += "/..."
+	}
+
+	return resolved
+}
+
+// ApplyFlags sets flags (header-file, year, template-dir, tags, goos, goarch, output, file-name, per-type)
+// not set explicitly on the command line to the values in the configuration.
+func (c Config) ApplyFlags(generator string, flags *pflag.FlagSet) error {
+	values := map[string]string{
+		"header-file":  c.headerFile(),
+		"year":         c.Year,
+		"template-dir": c.templateDir(),
+		"tags":         strings.Join(c.Tags, ","),
+		"goos":         c.GOOS,
+		"goarch":       c.GOARCH,
+		"output":       c.Generators[generator].Output,
+		"file-name":    c.Generators[generator].FileName,
+	}
+
+	if c.Generators[generator].PerType {
+		values["per-type"] = "true"
+	}
+
+	for name, value := range values {
+		flag := flags.Lookup(name)
+		if flag == nil || flag.Changed || value == "" {
+			continue
+		}
+
+		if err := flag.Value.Set(value); err != nil {
+			return fmt.Errorf("invalid %s in %s: %w", name, c.path, err)
+		}
+	}
+
+	return nil
+}
+
+// Filter removes excluded packages.
+func (c Config) Filter(roots []*loader.Package) []*loader.Package {
+	if len(c.Packages.Exclude) == 0 {
+		return roots
+	}
+
+	filtered := roots[:0:0]
+
+	for _, root := range roots {
+		if !c.excluded(root) {
+			filtered = append(filtered, root)
+		}
+	}
+
+	return filtered
+}
+
+func (c Config) excluded(pkg *loader.Package) bool {
+	dir := packageDir(pkg)
+
+	for _, pattern := range c.Packages.Exclude {
+		if isRelative(pattern) {
+			if dir != "" && matchPattern(filepath.ToSlash(c.resolve(pattern)), filepath.ToSlash(dir)) {
+				return true
+			}
+
+			continue
+		}
+
+		if matchPattern(pattern, pkg.PkgPath) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func packageDir(pkg *loader.Package) string {
+	if len(pkg.CompiledGoFiles) > 0 {
+		return filepath.Dir(pkg.CompiledGoFiles[0])
+	}
+
+	if len(pkg.GoFiles) > 0 {
+		return filepath.Dir(pkg.GoFiles[0])
+	}
+
+	return ""
+}
+
+func isRelative(pattern string) bool {
+	return pattern == "." || pattern == ".." || strings.HasPrefix(pattern, "./") || strings.HasPrefix(pattern, "../")
+}
+
+// matchPattern reports whether a name matches a package pattern (the same way the go command does).
+//
+// "..." matches any string (including the empty string and slashes),
+// a trailing "/..." also matches the name without the suffix.
+func matchPattern(pattern string, name string) bool {
+	re := regexp.QuoteMeta(pattern)
+	re = strings.ReplaceAll(re, `\.\.\.`, `.*`)
+
+	if strings.HasSuffix(re, `/.*`) {
+		re = strings.TrimSuffix(re, `/.*`) + `(/.*)?`
+	}
+
+	return regexp.MustCompile(`^` + re + `$`).MatchString(name)
+}
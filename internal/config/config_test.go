@@ -0,0 +1,157 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/tools/go/packages"
+	"sigs.k8s.io/controller-tools/pkg/loader"
+)
+
+// nolint: gochecknoinits
+func init() {
+	RegisterGenerator("event:handler", "pkg")
+	RegisterGenerator("kit:endpoint", "subpkg:suffix=driver")
+}
+
+func loadProject(t *testing.T) (Config, string) {
+	t.Helper()
+
+	root, err := filepath.Abs("testdata/project")
+	require.NoError(t, err)
+
+	config, err := LoadFrom(root)
+	require.NoError(t, err)
+
+	return config, root
+}
+
+func TestLoadFrom(t *testing.T) {
+	config, root := loadProject(t)
+
+	assert.Equal(t, filepath.Join(root, FileName), config.Path())
+	assert.Equal(t, "hack/header.txt", config.HeaderFile)
+	assert.Equal(t, "2024", config.Year)
+	assert.Equal(t, "hack/templates", config.TemplateDir)
+	assert.Equal(t, "subpkg:suffix=transport", config.Output("kit:endpoint"))
+	assert.Equal(t, "pkg", config.Output("event:handler"))
+	assert.Equal(t, "subpkg:suffix=authz", config.Output("plugin:authz"))
+	assert.Equal(t, "pkg", config.Output("plugin:flags"))
+	assert.Equal(t, []string{filepath.Join(root) + "/..."}, config.Include())
+}
+
+func TestLoadFrom_NoConfig(t *testing.T) {
+	config, err := LoadFrom(t.TempDir())
+	require.NoError(t, err)
+
+	assert.Equal(t, "", config.Path())
+	assert.Equal(t, []string{"."}, config.Include())
+	assert.Equal(t, "subpkg:suffix=driver", config.Output("kit:endpoint"))
+}
+
+func TestRegisterGenerator(t *testing.T) {
+	RegisterGenerator("test:generator", "subpkg:suffix=test")
+	RegisterGenerator("test:generator", "pkg")
+	RegisterGenerator("kit:endpoint", "pkg")
+
+	assert.Contains(t, GeneratorNames(), "test:generator")
+	assert.Equal(t, "subpkg:suffix=test", Config{}.Output("test:generator"))
+	assert.Equal(t, "subpkg:suffix=driver", Config{}.Output("kit:endpoint"))
+}
+
+func TestLoadFrom_CustomGenerator(t *testing.T) {
+	_, err := LoadFrom("testdata/custom")
+	require.Error(t, err)
+
+	config, err := loadFrom("testdata/custom", map[string]string{"acme:authz": "subpkg:suffix=authz"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "subpkg:suffix=authz", config.Output("acme:authz"))
+	assert.Equal(t, "subpkg:suffix=authz", config.Effective().Generators["acme:authz"].Output)
+	assert.NotContains(t, GeneratorNames(), "acme:authz")
+}
+
+func TestLoadFrom_UnknownGenerator(t *testing.T) {
+	_, err := LoadFrom("testdata/invalid")
+	require.Error(t, err)
+
+	assert.Contains(t, err.Error(), `unknown generator "kit:endpoints"`)
+}
+
+func TestConfig_ApplyFlags(t *testing.T) {
+	config, root := loadProject(t)
+
+	var headerFile, year, templateDir, output, fileName, goos, goarch string
+	var tags []string
+	var perType bool
+
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.StringVar(&output, "output", "subpkg:suffix=driver", "")
+	flags.StringVar(&fileName, "file-name", "", "")
+	flags.BoolVar(&perType, "per-type", false, "")
+	flags.StringVar(&headerFile, "header-file", "", "")
+	flags.StringVar(&year, "year", "", "")
+	flags.StringVar(&templateDir, "template-dir", "templates", "")
+	flags.StringSliceVar(&tags, "tags", nil, "")
+	flags.StringVar(&goos, "goos", "", "")
+	flags.StringVar(&goarch, "goarch", "", "")
+
+	require.NoError(t, flags.Parse([]string{"--year", "2025", "--goarch", "arm64"}))
+
+	require.NoError(t, config.ApplyFlags("kit:endpoint", flags))
+
+	assert.Equal(t, "subpkg:suffix=transport", output)
+	assert.Equal(t, filepath.Join(root, "hack/header.txt"), headerFile)
+	assert.Equal(t, "2025", year, "flags set on the command line should override the configuration")
+	assert.Equal(t, filepath.Join(root, "hack/templates"), templateDir)
+	assert.Equal(t, "{{ .Name }}.gen", fileName)
+	assert.True(t, perType)
+	assert.Equal(t, []string{"integration", "e2e"}, tags)
+	assert.Equal(t, "linux", goos)
+	assert.Equal(t, "arm64", goarch)
+}
+
+func TestConfig_Filter(t *testing.T) {
+	config, root := loadProject(t)
+
+	newPackage := func(path string, dir string) *loader.Package {
+		return &loader.Package{
+			Package: &packages.Package{
+				PkgPath:         path,
+				CompiledGoFiles: []string{filepath.Join(root, dir, "file.go")},
+			},
+		}
+	}
+
+	app := newPackage("example.com/project/internal/app", "internal/app")
+	legacy := newPackage("example.com/project/internal/legacy", "internal/legacy")
+	legacySub := newPackage("example.com/project/internal/legacy/sub", "internal/legacy/sub")
+	legacyApp := newPackage("example.com/project/internal/legacyapp", "internal/legacyapp")
+	tools := newPackage("example.com/project/tools", "tools")
+
+	roots := config.Filter([]*loader.Package{app, legacy, legacySub, legacyApp, tools})
+
+	assert.Equal(t, []*loader.Package{app, legacyApp}, roots)
+}
+
+func TestMatchPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		match   bool
+	}{
+		{"example.com/x", "example.com/x", true},
+		{"example.com/x", "example.com/x/y", false},
+		{"example.com/x/...", "example.com/x", true},
+		{"example.com/x/...", "example.com/x/y/z", true},
+		{"example.com/x/...", "example.com/xy", false},
+		{"example.com/.../internal", "example.com/x/internal", true},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.match, matchPattern(test.pattern, test.name), "%s ~ %s", test.pattern, test.name)
+	}
+}
@@ -0,0 +1,382 @@
+// Package check compares generated code with the files on disk without writing anything.
+package check
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"sigs.k8s.io/controller-tools/pkg/genall"
+	"sigs.k8s.io/controller-tools/pkg/loader"
+
+	"sagikazarmark.dev/mga/pkg/genutils"
+)
+
+// generatedHeader matches the header line of files generated by mga
+// (a Go comment or a YAML comment for non-Go files, eg. AsyncAPI documents).
+//
+// The line has to match exactly (see https://golang.org/s/generatedcode),
+// so sources containing the header text (eg. in a string literal) are not considered generated.
+// nolint: gochecknoglobals
+var generatedHeader = regexp.MustCompile(`(?m)^(//|#) Code generated by mga tool\. DO NOT EDIT\.\r?$`)
+
+// IsGenerated reports whether a file content was generated by mga.
+func IsGenerated(content []byte) bool {
+	return generatedHeader.Match(content)
+}
+
+// Output is an output rule keeping generated files in memory.
+//
+// Files are written to the same paths as the wrapped output rule would write them.
+type Output struct {
+	rule      genall.OutputRule
+	fileNames []string
+
+	// write tells the output to write files using the wrapped output rule as well
+	write bool
+
+	mu    sync.Mutex
+	files map[string][]byte
+}
+
+// NewOutput returns a new Output wrapping an output rule.
+//
+// If the generator knows the names of the files it writes (see genutils.OutputFiler),
+// files that are not generated anymore are reported as well.
+func NewOutput(rule genall.OutputRule, generator genall.Generator) *Output {
+	var fileNames []string
+	if filer, ok := generator.(genutils.OutputFiler); ok {
+		fileNames = filer.OutputFiles()
+	}
+
+	return &Output{
+		rule:      rule,
+		fileNames: fileNames,
+		files:     make(map[string][]byte),
+	}
+}
+
+// NewWritingOutput returns a new Output wrapping an output rule.
+//
+// Unlike NewOutput, files are written using the wrapped output rule (and kept track of).
+func NewWritingOutput(rule genall.OutputRule, generator genall.Generator) *Output {
+	output := NewOutput(rule, generator)
+	output.write = true
+
+	return output
+}
+
+// Open returns a writer keeping the written artifact in memory.
+func (o *Output) Open(pkg *loader.Package, itemPath string) (io.WriteCloser, error) {
+	path, err := genutils.OutputPath(o.rule, pkg, itemPath)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &file{output: o, path: path}
+
+	if o.write {
+		w, err := o.rule.Open(pkg, itemPath)
+		if err != nil {
+			return nil, err
+		}
+
+		f.writer = w
+	}
+
+	return f, nil
+}
+
+// PackageRef returns package reference (name and path) based on the wrapped output rule.
+func (o *Output) PackageRef(pkg *loader.Package) (string, string) {
+	if pkgrefer, ok := o.rule.(genutils.PackageRefer); ok {
+		return pkgrefer.PackageRef(pkg)
+	}
+
+	return pkg.Name, pkg.PkgPath
+}
+
+type file struct {
+	bytes.Buffer
+
+	output *Output
+	path   string
+
+	// writer receives the contents on close (if any)
+	writer io.WriteCloser
+}
+
+func (f *file) Close() error {
+	f.output.mu.Lock()
+	f.output.files[f.path] = f.Bytes()
+	f.output.mu.Unlock()
+
+	if f.writer == nil {
+		return nil
+	}
+
+	n, err := f.writer.Write(f.Bytes())
+	if err != nil {
+		_ = f.writer.Close()
+
+		return err
+	}
+
+	if n < f.Len() {
+		_ = f.writer.Close()
+
+		return io.ErrShortWrite
+	}
+
+	return f.writer.Close()
+}
+
+// Mismatch is a generated file that differs from the file on disk.
+type Mismatch struct {
+	// Path of the file.
+	Path string
+
+	// Diff is a unified diff between the file on disk and the generated one.
+	Diff string
+}
+
+// Compare compares generated files with the files on disk.
+//
+// Files generated by mga that would be written for the given packages but are not generated anymore are reported too.
+func (o *Output) Compare(roots []*loader.Package) ([]Mismatch, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	var mismatches []Mismatch
+
+	for path, generated := range o.files {
+		current, err := readFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		if bytes.Equal(current, generated) {
+			continue
+		}
+
+		mismatch, err := newMismatch(path, current, generated)
+		if err != nil {
+			return nil, err
+		}
+
+		mismatches = append(mismatches, mismatch)
+	}
+
+	orphans, err := o.orphans(roots)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, path := range orphans {
+		current, err := readFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		mismatch, err := newMismatch(path, current, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		mismatches = append(mismatches, mismatch)
+	}
+
+	sort.Slice(mismatches, func(i, j int) bool {
+		return mismatches[i].Path < mismatches[j].Path
+	})
+
+	return mismatches, nil
+}
+
+// Orphans returns files generated by mga that would be written for the given packages
+// but are not generated anymore.
+func (o *Output) Orphans(roots []*loader.Package) ([]string, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return o.orphans(roots)
+}
+
+func (o *Output) orphans(roots []*loader.Package) ([]string, error) {
+	var orphans []string
+
+	// patterns may match the same file
+	seen := make(map[string]bool)
+
+	for _, root := range roots {
+		for _, fileName := range o.fileNames {
+			pattern, err := genutils.OutputPath(o.rule, root, fileName)
+			if err != nil {
+				return nil, err
+			}
+
+			// file names may be glob patterns (eg. for files generated per type)
+			paths, err := filepath.Glob(pattern)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, path := range paths {
+				if _, ok := o.files[path]; ok || seen[path] {
+					continue
+				}
+
+				seen[path] = true
+
+				current, err := readFile(path)
+				if err != nil {
+					return nil, err
+				}
+
+				if current == nil || !IsGenerated(current) {
+					continue
+				}
+
+				orphans = append(orphans, path)
+			}
+		}
+	}
+
+	sort.Strings(orphans)
+
+	return orphans, nil
+}
+
+// readFile reads a file from disk (returns nil if the file does not exist).
+func readFile(path string) ([]byte, error) {
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	return content, err
+}
+
+// relativePath returns a path relative to the current directory (if possible).
+func relativePath(path string) string {
+	if wd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(wd, path); err == nil {
+			return filepath.ToSlash(rel)
+		}
+	}
+
+	return filepath.ToSlash(path)
+}
+
+func newMismatch(path string, current []byte, generated []byte) (Mismatch, error) {
+	name := relativePath(path)
+
+	fromFile, toFile := "a/"+name, "b/"+name
+	if current == nil {
+		fromFile = "/dev/null"
+	}
+	if generated == nil {
+		toFile = "/dev/null"
+	}
+
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        splitLines(current),
+		B:        splitLines(generated),
+		FromFile: fromFile,
+		ToFile:   toFile,
+		Context:  3,
+	})
+	if err != nil {
+		return Mismatch{}, err
+	}
+
+	return Mismatch{Path: path, Diff: diff}, nil
+}
+
+// splitLines splits content into lines keeping line endings.
+func splitLines(content []byte) []string {
+	if len(content) == 0 {
+		return nil
+	}
+
+	lines := strings.SplitAfter(string(content), "\n")
+
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	return lines
+}
+
+// Report prints mismatches as unified diffs and returns whether there were any.
+func Report(w io.Writer, mismatches []Mismatch) bool {
+	for _, mismatch := range mismatches {
+		fmt.Fprint(w, mismatch.Diff)
+	}
+
+	return len(mismatches) > 0
+}
+
+// Prune removes files generated by mga that are not generated anymore and lists them.
+//
+// When dryRun is true, files are only listed.
+func Prune(w io.Writer, roots []*loader.Package, dryRun bool, outputs ...*Output) error {
+	var orphans []string
+
+	for _, output := range outputs {
+		o, err := output.Orphans(roots)
+		if err != nil {
+			return err
+		}
+
+		orphans = append(orphans, o...)
+	}
+
+	sort.Strings(orphans)
+
+	for i, path := range orphans {
+		// the same file may be written by more than one generator (eg. using different output rules)
+		if i > 0 && orphans[i-1] == path {
+			continue
+		}
+
+		if dryRun {
+			fmt.Fprintf(w, "would remove %s\n", relativePath(path))
+
+			continue
+		}
+
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(w, "removed %s\n", relativePath(path))
+	}
+
+	return nil
+}
+
+// Run compares the outputs of generators with the files on disk and reports mismatches.
+//
+// It returns true if any generated file is out of date.
+func Run(w io.Writer, roots []*loader.Package, outputs ...*Output) (bool, error) {
+	var mismatches []Mismatch
+
+	for _, output := range outputs {
+		m, err := output.Compare(roots)
+		if err != nil {
+			return false, err
+		}
+
+		mismatches = append(mismatches, m...)
+	}
+
+	return Report(w, mismatches), nil
+}
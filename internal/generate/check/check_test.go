@@ -0,0 +1,219 @@
+package check
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/tools/go/packages"
+	"sigs.k8s.io/controller-tools/pkg/genall"
+	"sigs.k8s.io/controller-tools/pkg/loader"
+	"sigs.k8s.io/controller-tools/pkg/markers"
+)
+
+type generatorStub struct{}
+
+func (generatorStub) RegisterMarkers(_ *markers.Registry) error {
+	return nil
+}
+
+func (generatorStub) Generate(_ *genall.GenerationContext) error {
+	return nil
+}
+
+func (generatorStub) OutputFiles() []string {
+	return []string{"up_to_date.go", "changed.go", "new.go", "stale.go", "handwritten.go", "generator.go"}
+}
+
+func TestOutput_Compare(t *testing.T) {
+	dir := t.TempDir()
+
+	files := map[string]string{
+		"up_to_date.go":  "// Code generated by mga tool. DO NOT EDIT.\n\npackage pkg\n",
+		"changed.go":     "// Code generated by mga tool. DO NOT EDIT.\n\npackage pkg\n\ntype A struct{}\n",
+		"stale.go":       "// Code generated by mga tool. DO NOT EDIT.\n\npackage pkg\n",
+		"handwritten.go": "package pkg\n",
+	}
+
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+	}
+
+	output := NewOutput(genall.OutputToDirectory(dir), generatorStub{})
+
+	pkg := &loader.Package{Package: &packages.Package{Name: "pkg", PkgPath: "app.dev/pkg"}}
+
+	generated := map[string]string{
+		"up_to_date.go": "// Code generated by mga tool. DO NOT EDIT.\n\npackage pkg\n",
+		"changed.go":    "// Code generated by mga tool. DO NOT EDIT.\n\npackage pkg\n\ntype B struct{}\n",
+		"new.go":        "// Code generated by mga tool. DO NOT EDIT.\n\npackage pkg\n",
+	}
+
+	for name, content := range generated {
+		w, err := output.Open(pkg, name)
+		require.NoError(t, err)
+
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+
+		require.NoError(t, w.Close())
+	}
+
+	mismatches, err := output.Compare([]*loader.Package{pkg})
+	require.NoError(t, err)
+
+	require.Len(t, mismatches, 3)
+
+	assert.Equal(t, filepath.Join(dir, "changed.go"), mismatches[0].Path)
+	assert.Contains(t, mismatches[0].Diff, "-type A struct{}\n+type B struct{}\n")
+
+	assert.Equal(t, filepath.Join(dir, "new.go"), mismatches[1].Path)
+	assert.Contains(t, mismatches[1].Diff, "--- /dev/null\n")
+
+	assert.Equal(t, filepath.Join(dir, "stale.go"), mismatches[2].Path)
+	assert.Contains(t, mismatches[2].Diff, "+++ /dev/null\n")
+
+	for name, content := range files {
+		current, err := os.ReadFile(filepath.Join(dir, name))
+		require.NoError(t, err)
+
+		assert.Equal(t, content, string(current), "files on disk should not be changed")
+	}
+
+	_, err = os.Stat(filepath.Join(dir, "new.go"))
+	assert.True(t, os.IsNotExist(err), "new files should not be written")
+}
+
+func TestOutput_PackageRef(t *testing.T) {
+	output := NewOutput(genall.OutputToDirectory(t.TempDir()), generatorStub{})
+
+	pkg := &loader.Package{Package: &packages.Package{Name: "pkg", PkgPath: "app.dev/pkg"}}
+
+	name, path := output.PackageRef(pkg)
+
+	assert.Equal(t, "pkg", name)
+	assert.Equal(t, "app.dev/pkg", path)
+}
+
+func TestPrune(t *testing.T) {
+	dir := t.TempDir()
+
+	files := map[string]string{
+		"up_to_date.go":  "// Code generated by mga tool. DO NOT EDIT.\n\npackage pkg\n",
+		"stale.go":       "// Code generated by mga tool. DO NOT EDIT.\n\npackage pkg\n",
+		"handwritten.go": "package pkg\n",
+		"generator.go":   "package pkg\n\nconst header = \"Code generated by mga tool. DO NOT EDIT.\"\n",
+	}
+
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+	}
+
+	output := NewWritingOutput(genall.OutputToDirectory(dir), generatorStub{})
+
+	pkg := &loader.Package{Package: &packages.Package{Name: "pkg", PkgPath: "app.dev/pkg"}}
+
+	w, err := output.Open(pkg, "new.go")
+	require.NoError(t, err)
+
+	_, err = w.Write([]byte("// Code generated by mga tool. DO NOT EDIT.\n\npackage pkg\n"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	w, err = output.Open(pkg, "up_to_date.go")
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	var buf bytes.Buffer
+
+	err = Prune(&buf, []*loader.Package{pkg}, true, output)
+	require.NoError(t, err)
+
+	assert.Contains(t, buf.String(), "would remove ")
+	assert.FileExists(t, filepath.Join(dir, "stale.go"))
+
+	buf.Reset()
+
+	err = Prune(&buf, []*loader.Package{pkg}, false, output)
+	require.NoError(t, err)
+
+	assert.Contains(t, buf.String(), "removed ")
+	assert.NoFileExists(t, filepath.Join(dir, "stale.go"))
+	assert.FileExists(t, filepath.Join(dir, "new.go"), "written files should be kept")
+	assert.FileExists(t, filepath.Join(dir, "up_to_date.go"))
+	assert.FileExists(t, filepath.Join(dir, "handwritten.go"), "files not generated by mga should be kept")
+	assert.FileExists(t, filepath.Join(dir, "generator.go"), "files containing the header text should be kept")
+}
+
+type patternGeneratorStub struct {
+	generatorStub
+}
+
+func (patternGeneratorStub) OutputFiles() []string {
+	return []string{"zz_generated.mock.*.go", "zz_generated.mock.*_test.go"}
+}
+
+func TestOutput_Orphans_Patterns(t *testing.T) {
+	dir := t.TempDir()
+
+	files := map[string]string{
+		"zz_generated.mock.service.go":       "// Code generated by mga tool. DO NOT EDIT.\n\npackage pkg\n",
+		"zz_generated.mock.repository.go":    "// Code generated by mga tool. DO NOT EDIT.\n\npackage pkg\n",
+		"zz_generated.mock.clock_test.go":    "// Code generated by mga tool. DO NOT EDIT.\n\npackage pkg\n",
+		"zz_generated.mock.handwritten.go":   "package pkg\n",
+		"zz_generated.endpoint.service.go":   "// Code generated by mga tool. DO NOT EDIT.\n\npackage pkg\n",
+		"zz_generated.mock_external_test.go": "// Code generated by mga tool. DO NOT EDIT.\n\npackage pkg_test\n",
+	}
+
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+	}
+
+	output := NewOutput(genall.OutputToDirectory(dir), patternGeneratorStub{})
+
+	pkg := &loader.Package{Package: &packages.Package{Name: "pkg", PkgPath: "app.dev/pkg"}}
+
+	w, err := output.Open(pkg, "zz_generated.mock.service.go")
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	orphans, err := output.Orphans([]*loader.Package{pkg})
+	require.NoError(t, err)
+
+	assert.Equal(
+		t,
+		[]string{
+			filepath.Join(dir, "zz_generated.mock.clock_test.go"),
+			filepath.Join(dir, "zz_generated.mock.repository.go"),
+		},
+		orphans,
+	)
+}
+
+func TestIsGenerated(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		generated bool
+	}{
+		{"go", "// Code generated by mga tool. DO NOT EDIT.\n\npackage pkg\n", true},
+		{"go_header", "// Copyright\n\n// Code generated by mga tool. DO NOT EDIT.\n\npackage pkg\n", true},
+		{"yaml", "# Code generated by mga tool. DO NOT EDIT.\n\nasyncapi: 2.6.0\n", true},
+		{"crlf", "// Code generated by mga tool. DO NOT EDIT.\r\n\r\npackage pkg\r\n", true},
+		{"handwritten", "package pkg\n", false},
+		{"string_literal", "package pkg\n\nconst header = \"Code generated by mga tool. DO NOT EDIT.\"\n", false},
+		{"call", "package pkg\n\nfunc f() {\n\tcode.HeaderComment(\"Code generated by mga tool. DO NOT EDIT.\")\n}\n", false},
+		{"other_tool", "// Code generated by other tool. DO NOT EDIT.\n\npackage pkg\n", false},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.generated, IsGenerated([]byte(test.content)))
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package busgen
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+
+	"sigs.k8s.io/controller-tools/pkg/genall"
+	"sigs.k8s.io/controller-tools/pkg/loader"
+	"sigs.k8s.io/controller-tools/pkg/markers"
+
+	"sagikazarmark.dev/mga/internal/generate/command/bus"
+	"sagikazarmark.dev/mga/pkg/generator"
+	"sagikazarmark.dev/mga/pkg/gentypes"
+	"sagikazarmark.dev/mga/pkg/genutils"
+)
+
+// nolint: gochecknoglobals
+var (
+	// BusMarker enables command sender generation for an interface.
+	BusMarker = markers.Must(markers.MakeDefinition("mga:command:bus", markers.DescribesType, struct{}{}))
+)
+
+// Generator generates command senders for command bus interfaces.
+type Generator struct {
+	generator.Base
+}
+
+func (g Generator) RegisterMarkers(into *markers.Registry) error {
+	if err := into.Register(BusMarker); err != nil {
+		return err
+	}
+
+	into.AddHelp(
+		BusMarker,
+		markers.SimpleHelp("Command", "enables command sender generation for a command bus interface"),
+	)
+
+	return nil
+}
+
+// MarkerKinds restricts markers to the kinds of declarations the generator handles.
+func (Generator) MarkerKinds() map[string]string {
+	return map[string]string{
+		BusMarker.Name: genutils.InterfaceKind,
+	}
+}
+
+func (Generator) CheckFilter() loader.NodeFilter {
+	return func(node ast.Node) bool {
+		return true
+	}
+}
+
+// OutputFiles returns the names of the files written by the generator.
+func (g Generator) OutputFiles() []string {
+	return g.OutputFilePatterns("command_bus")
+}
+
+func (g Generator) Generate(ctx *genall.GenerationContext) error {
+	headerText, err := g.HeaderText(ctx)
+	if err != nil {
+		return err
+	}
+
+	fileName, err := g.OutputFileName("command_bus", "")
+	if err != nil {
+		return err
+	}
+
+	for _, root := range ctx.Roots {
+		outContents := g.generatePackage(ctx, headerText, root)
+		if outContents == nil {
+			continue
+		}
+
+		generator.WriteFile(ctx, root, fileName, outContents)
+	}
+
+	return nil
+}
+
+func (g Generator) generatePackage(ctx *genall.GenerationContext, headerText string, root *loader.Package) []byte {
+	ctx.Checker.Check(root)
+
+	root.NeedTypesInfo()
+
+	var commandSenders []bus.CommandSender
+
+	err := markers.EachType(ctx.Collector, root, func(info *markers.TypeInfo) {
+		if marker := info.Markers.Get(BusMarker.Name); marker == nil {
+			return
+		}
+
+		typeInfo := root.TypesInfo.TypeOf(info.RawSpec.Name)
+		if typeInfo == types.Typ[types.Invalid] {
+			root.AddError(loader.ErrFromNode(fmt.Errorf("unknown type %s", info.Name), info.RawSpec))
+
+			return
+		}
+
+		commands, err := bus.ParseCommands(root.TypesInfo.ObjectOf(info.RawSpec.Name))
+		if err != nil {
+			root.AddError(err)
+
+			return
+		}
+
+		commandSenders = append(commandSenders, bus.CommandSenderFromCommands(commands))
+	})
+	if err != nil {
+		root.AddError(err)
+
+		return nil
+	}
+
+	if len(commandSenders) == 0 {
+		return nil
+	}
+
+	outputPackage := generator.OutputPackage(ctx, root)
+
+	file := bus.File{
+		File: gentypes.File{
+			Package:    outputPackage,
+			HeaderText: headerText,
+		},
+		CommandSenders: commandSenders,
+	}
+
+	outContents, err := bus.Generate(file)
+	if err != nil {
+		root.AddError(err)
+
+		return nil
+	}
+
+	return outContents
+}
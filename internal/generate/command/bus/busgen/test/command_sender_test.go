@@ -0,0 +1,144 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/components/cqrs"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/ThreeDotsLabs/watermill/message/subscriber"
+	"github.com/ThreeDotsLabs/watermill/pubsub/gochannel"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setUpPublisher(t *testing.T) (*cqrs.CommandBus, <-chan *message.Message) {
+	publisher := gochannel.NewGoChannel(gochannel.Config{}, watermill.NopLogger{})
+	const topic = "command"
+	commandBus, err := cqrs.NewCommandBusWithConfig(publisher, cqrs.CommandBusConfig{
+		GeneratePublishTopic: func(_ cqrs.CommandBusGeneratePublishTopicParams) (string, error) {
+			return topic, nil
+		},
+		Marshaler: cqrs.JSONMarshaler{},
+	})
+	require.NoError(t, err)
+
+	messages, err := publisher.Subscribe(context.Background(), topic)
+	require.NoError(t, err)
+
+	return commandBus, messages
+}
+
+func receiveCommand(t *testing.T, messages <-chan *message.Message) Command {
+	received, all := subscriber.BulkRead(messages, 1, time.Second)
+	if !all {
+		t.Fatal("no message received")
+	}
+
+	var receivedCommand Command
+
+	err := json.Unmarshal(received[0].Payload, &receivedCommand)
+	require.NoError(t, err)
+
+	return receivedCommand
+}
+
+func TestCommandSender_Command(t *testing.T) {
+	commandBus, messages := setUpPublisher(t)
+
+	commands := NewCommandSender(commandBus)
+
+	command := Command{
+		ID: "id",
+	}
+
+	commands.Command(command)
+
+	assert.Equal(t, command, receiveCommand(t, messages))
+}
+
+func TestCommandSender_CommandWithContext(t *testing.T) {
+	commandBus, messages := setUpPublisher(t)
+
+	commands := NewCommandSender(commandBus)
+
+	command := Command{
+		ID: "id",
+	}
+
+	commands.CommandWithContext(context.Background(), command)
+
+	assert.Equal(t, command, receiveCommand(t, messages))
+}
+
+func TestCommandSender_CommandWithContextAndError(t *testing.T) {
+	commandBus, messages := setUpPublisher(t)
+
+	commands := NewCommandSender(commandBus)
+
+	command := Command{
+		ID: "id",
+	}
+
+	err := commands.CommandWithContextAndError(context.Background(), command)
+	require.NoError(t, err)
+
+	assert.Equal(t, command, receiveCommand(t, messages))
+}
+
+func TestCommandSender_CommandWithError(t *testing.T) {
+	commandBus, messages := setUpPublisher(t)
+
+	commands := NewCommandSender(commandBus)
+
+	command := Command{
+		ID: "id",
+	}
+
+	err := commands.CommandWithError(command)
+	require.NoError(t, err)
+
+	assert.Equal(t, command, receiveCommand(t, messages))
+}
+
+type failingCommandBus struct {
+	err error
+}
+
+func (f failingCommandBus) Send(_ context.Context, _ interface{}) error {
+	return f.err
+}
+
+func TestCommandSender_CommandWithContextAndError_Error(t *testing.T) {
+	failure := errors.NewPlain("error")
+	commandBus := failingCommandBus{err: failure}
+
+	commands := NewCommandSender(commandBus)
+
+	command := Command{
+		ID: "id",
+	}
+
+	err := commands.CommandWithContextAndError(context.Background(), command)
+
+	assert.Equal(t, failure, errors.Cause(err))
+}
+
+func TestCommandSender_CommandWithError_Error(t *testing.T) {
+	failure := errors.NewPlain("error")
+	commandBus := failingCommandBus{err: failure}
+
+	commands := NewCommandSender(commandBus)
+
+	command := Command{
+		ID: "id",
+	}
+
+	err := commands.CommandWithError(command)
+
+	assert.Equal(t, failure, errors.Cause(err))
+}
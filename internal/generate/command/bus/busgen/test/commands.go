@@ -0,0 +1,26 @@
+package test
+
+import (
+	"context"
+)
+
+type Command struct {
+	ID string
+}
+
+//go:generate go run sagikazarmark.dev/mga generate command bus
+
+// +mga:command:bus
+type Commands interface {
+	// Command sends a Command command.
+	Command(command Command)
+
+	// CommandWithContext accepts a context too.
+	CommandWithContext(ctx context.Context, command Command)
+
+	// CommandWithContextAndError combines the features of CommandWithContext and CommandWithError.
+	CommandWithContextAndError(ctx context.Context, command Command) error
+
+	// CommandWithError returns an error when something goes wrong during sending the command.
+	CommandWithError(command Command) error
+}
@@ -0,0 +1,7 @@
+package testgen
+
+import (
+	"sagikazarmark.dev/mga/internal/generate/command/bus/busgen/test"
+)
+
+type Command = test.Command
@@ -0,0 +1,151 @@
+package bus
+
+import (
+	"bytes"
+	"go/format"
+
+	"github.com/dave/jennifer/jen"
+
+	"sagikazarmark.dev/mga/pkg/gentypes"
+)
+
+// File provides information for generating command senders.
+type File struct {
+	gentypes.File
+
+	// CommandSenders represents command senders to be generated for matching interfaces.
+	CommandSenders []CommandSender
+}
+
+// CommandSender describes the command bus interface.
+type CommandSender struct {
+	Name          string
+	SenderMethods []CommandMethod
+}
+
+// Generate generates a command sender.
+func Generate(file File) ([]byte, error) {
+	code := jen.NewFilePathName(file.Package.Path, file.Package.Name)
+
+	code.HeaderComment("//go:build !ignore_autogenerated\n// +build !ignore_autogenerated\n")
+
+	if file.HeaderText != "" {
+		code.HeaderComment(file.HeaderText)
+	}
+
+	code.HeaderComment("Code generated by mga tool. DO NOT EDIT.")
+
+	code.ImportName("emperror.dev/errors", "errors")
+
+	const commandBusTypeName = "CommandBus"
+	generateCommandBus(code, commandBusTypeName)
+
+	for _, commandSender := range file.CommandSenders {
+		generateCommandSender(code, commandSender)
+	}
+
+	var buf bytes.Buffer
+
+	err := code.Render(&buf)
+	if err != nil {
+		return nil, err
+	}
+
+	return format.Source(buf.Bytes())
+}
+
+func generateCommandBus(code *jen.File, commandBusTypeName string) {
+	code.Commentf("%s is a generic command bus.", commandBusTypeName)
+	code.Type().Id(commandBusTypeName).Interface(
+		jen.Comment("Send sends a command to the underlying message bus."),
+		jen.Id("Send").Params(
+			jen.Id("ctx").Qual("context", "Context"),
+			jen.Id("command").Interface(),
+		).Error(),
+	).Line()
+}
+
+func generateCommandSender(code *jen.File, commandSender CommandSender) {
+	commandSenderTypeName := commandSender.Name + "CommandSender"
+
+	const (
+		commandBusVarName  = "bus"
+		commandBusTypeName = "CommandBus"
+	)
+
+	code.Commentf("%s sends commands through the underlying generic command bus.", commandSenderTypeName)
+	code.Type().Id(commandSenderTypeName).Struct(
+		jen.Id(commandBusVarName).Id(commandBusTypeName),
+	).Line()
+
+	code.Commentf("New%s returns a new %s instance.", commandSenderTypeName, commandSenderTypeName)
+	code.Func().
+		Id("New" + commandSenderTypeName).
+		Params(jen.Id(commandBusVarName).Id(commandBusTypeName)).
+		Id(commandSenderTypeName).
+		Block(
+			jen.Return(
+				jen.Id(commandSenderTypeName).Values(jen.Dict{
+					jen.Id(commandBusVarName): jen.Id(commandBusVarName),
+				}),
+			),
+		).
+		Line()
+
+	for _, method := range commandSender.SenderMethods {
+		code.ImportName(method.Event.Package.Path, method.Event.Package.Name)
+
+		var params []jen.Code
+
+		if method.ReceivesContext {
+			params = append(params, jen.Id("ctx").Qual("context", "Context"))
+		}
+
+		params = append(params, jen.Id("command").Qual(method.Event.Package.Path, method.Event.Name))
+
+		code.Commentf("%s sends a(n) %s command.", method.Name, method.Event.Name)
+		fn := code.Func().Params(
+			jen.Id("s").Id(commandSenderTypeName),
+		).Id(method.Name).Params(params...)
+
+		if method.ReturnsError {
+			fn = fn.Error()
+		}
+
+		var block []jen.Code
+
+		if !method.ReceivesContext {
+			block = append(block, jen.Id("ctx").Op(":=").Qual("context", "Background").Call())
+		}
+
+		if method.ReturnsError {
+			block = append(
+				block,
+				jen.Err().Op(":=").Id("s").Dot(commandBusVarName).Dot("Send").Call(
+					jen.Id("ctx"),
+					jen.Id("command"),
+				),
+				jen.If(
+					jen.Err().Op("!=").Nil(),
+				).Block(
+					jen.Return(jen.Qual("emperror.dev/errors", "WithDetails").Call(
+						jen.Qual("emperror.dev/errors", "WithMessage").Call(
+							jen.Err(),
+							jen.Lit("failed to send command"),
+						),
+						jen.Lit("command"), jen.Lit(method.Event.Name),
+					)),
+				),
+				jen.Line(),
+				jen.Return(jen.Nil()),
+			)
+		} else {
+			block = append(block, jen.Id("_").Op("=").Id("s").Dot(commandBusVarName).Dot("Send").Call(
+				jen.Id("ctx"),
+				jen.Id("command"),
+			))
+		}
+
+		fn.Block(block...).Line()
+	}
+}
@@ -0,0 +1,108 @@
+package bus
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"sagikazarmark.dev/mga/pkg/gentypes"
+)
+
+func TestGenerate(t *testing.T) {
+	command := gentypes.TypeRef{
+		Name: "CreateTodo",
+		Package: gentypes.PackageRef{
+			Name: "pkg",
+			Path: "app.dev/pkg",
+		},
+	}
+
+	file := File{
+		File: gentypes.File{
+			Package: gentypes.PackageRef{
+				Name: "pkggen",
+				Path: "app.dev/pkg/pkggen",
+			},
+			HeaderText: `// Copyright 2020 Acme Inc.
+// All rights reserved.
+//
+// Licensed under "Only for testing purposes" license.
+`,
+		},
+		CommandSenders: []CommandSender{
+			{
+				Name: "Todo",
+				SenderMethods: []CommandMethod{
+					{
+						Name:            "CreateTodo",
+						Event:           command,
+						ReceivesContext: true,
+						ReturnsError:    true,
+					},
+					{
+						Name:  "CreateTodoAsync",
+						Event: command,
+					},
+				},
+			},
+		},
+	}
+
+	expected := `//go:build !ignore_autogenerated
+// +build !ignore_autogenerated
+
+// Copyright 2020 Acme Inc.
+// All rights reserved.
+//
+// Licensed under "Only for testing purposes" license.
+
+// Code generated by mga tool. DO NOT EDIT.
+
+package pkggen
+
+import (
+	"app.dev/pkg"
+	"context"
+	"emperror.dev/errors"
+)
+
+// CommandBus is a generic command bus.
+type CommandBus interface {
+	// Send sends a command to the underlying message bus.
+	Send(ctx context.Context, command interface{}) error
+}
+
+// TodoCommandSender sends commands through the underlying generic command bus.
+type TodoCommandSender struct {
+	bus CommandBus
+}
+
+// NewTodoCommandSender returns a new TodoCommandSender instance.
+func NewTodoCommandSender(bus CommandBus) TodoCommandSender {
+	return TodoCommandSender{bus: bus}
+}
+
+// CreateTodo sends a(n) CreateTodo command.
+func (s TodoCommandSender) CreateTodo(ctx context.Context, command pkg.CreateTodo) error {
+	err := s.bus.Send(ctx, command)
+	if err != nil {
+		return errors.WithDetails(errors.WithMessage(err, "failed to send command"), "command", "CreateTodo")
+	}
+
+	return nil
+}
+
+// CreateTodoAsync sends a(n) CreateTodo command.
+func (s TodoCommandSender) CreateTodoAsync(command pkg.CreateTodo) {
+	ctx := context.Background()
+	_ = s.bus.Send(ctx, command)
+}
+`
+
+	actual, err := Generate(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, expected, string(actual), "the generated code does not match the expected one")
+}
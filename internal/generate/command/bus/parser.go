@@ -0,0 +1,33 @@
+package bus
+
+import (
+	"fmt"
+	"go/types"
+
+	"sagikazarmark.dev/mga/internal/generate/event/dispatcher"
+)
+
+// Commands describes the command bus interface.
+type Commands = dispatcher.Events
+
+// CommandMethod describes a sender method in a command bus interface.
+type CommandMethod = dispatcher.EventMethod
+
+// ParseCommands parses an object as a command bus interface.
+//
+// Command bus interfaces follow the same rules as event dispatcher interfaces (see dispatcher.ParseEvents),
+// except that commands are always sent one by one.
+func ParseCommands(obj types.Object) (Commands, error) {
+	commands, err := dispatcher.ParseEvents(obj)
+	if err != nil {
+		return commands, err
+	}
+
+	for _, method := range commands.Methods {
+		if method.Batch {
+			return commands, fmt.Errorf("command bus method %q cannot send multiple commands", method.Name)
+		}
+	}
+
+	return commands, nil
+}
@@ -0,0 +1,22 @@
+package bus
+
+import (
+	"strings"
+)
+
+// CommandSenderFromCommands creates a CommandSender from Commands.
+// nolint: golint
+func CommandSenderFromCommands(commands Commands) CommandSender {
+	return CommandSender{
+		Name:          cleanCommandSenderName(commands.Name),
+		SenderMethods: commands.Methods,
+	}
+}
+
+func cleanCommandSenderName(name string) string {
+	name = strings.TrimSuffix(name, "Commands")
+	name = strings.TrimSuffix(name, "CommandBus")
+	name = strings.TrimSuffix(name, "CommandSender")
+
+	return name
+}
@@ -0,0 +1,31 @@
+package bus
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"sagikazarmark.dev/mga/pkg/gentypes"
+)
+
+func TestCommandSenderFromCommands(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"TodoCommands", "Todo"},
+		{"TodoCommandBus", "Todo"},
+		{"TodoCommandSender", "Todo"},
+		{"Todo", "Todo"},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			sender := CommandSenderFromCommands(Commands{TypeRef: gentypes.TypeRef{Name: test.name}})
+
+			assert.Equal(t, test.expected, sender.Name)
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"bytes"
+	"go/format"
+
+	"github.com/dave/jennifer/jen"
+
+	"sagikazarmark.dev/mga/pkg/gentypes"
+)
+
+// File provides information for generating command handlers.
+type File struct {
+	gentypes.File
+
+	// CommandHandlers represents command handlers to be generated for matching commands.
+	CommandHandlers []CommandHandler
+}
+
+// CommandHandler describes a command handler.
+type CommandHandler struct {
+	Name    string
+	Command gentypes.TypeRef
+}
+
+// Generate generates a command handler.
+func Generate(file File) ([]byte, error) {
+	code := jen.NewFilePathName(file.Package.Path, file.Package.Name)
+
+	code.HeaderComment("//go:build !ignore_autogenerated\n// +build !ignore_autogenerated\n")
+
+	if file.HeaderText != "" {
+		code.HeaderComment(file.HeaderText)
+	}
+
+	code.HeaderComment("Code generated by mga tool. DO NOT EDIT.")
+
+	code.ImportName("emperror.dev/errors", "errors")
+
+	for _, commandHandler := range file.CommandHandlers {
+		generateCommandHandler(code, commandHandler)
+	}
+
+	var buf bytes.Buffer
+
+	err := code.Render(&buf)
+	if err != nil {
+		return nil, err
+	}
+
+	return format.Source(buf.Bytes())
+}
+
+func generateCommandHandler(code *jen.File, commandHandler CommandHandler) {
+	handlerTypeName := commandHandler.Name + "Handler"
+	commandHandlerTypeName := commandHandler.Name + "CommandHandler"
+
+	const (
+		handlerVarName     = "handler"
+		handlerNameVarName = "name"
+	)
+
+	code.ImportName(commandHandler.Command.Package.Path, commandHandler.Command.Package.Name)
+
+	code.Commentf("%s handles %s commands.", handlerTypeName, commandHandler.Name)
+	code.Type().Id(handlerTypeName).Interface(
+		jen.Commentf("%s handles a(n) %s command.", commandHandler.Name, commandHandler.Name),
+		jen.Id(commandHandler.Name).Params(
+			jen.Id("ctx").Qual("context", "Context"),
+			jen.Id("command").Qual(commandHandler.Command.Package.Path, commandHandler.Command.Name),
+		).Error(),
+	).Line()
+
+	code.Commentf("%s handles %s commands.", commandHandlerTypeName, commandHandler.Name)
+	code.Type().Id(commandHandlerTypeName).Struct(
+		jen.Id(handlerVarName).Id(handlerTypeName),
+		jen.Id(handlerNameVarName).String(),
+	).Line()
+
+	code.Commentf("New%s returns a new %s instance.", commandHandlerTypeName, commandHandlerTypeName)
+	code.Func().
+		Id("New"+commandHandlerTypeName).
+		Params(
+			jen.Id(handlerVarName).Id(handlerTypeName),
+			jen.Id(handlerNameVarName).String(),
+		).
+		Id(commandHandlerTypeName).
+		Block(
+			jen.Return(
+				jen.Id(commandHandlerTypeName).Values(jen.Dict{
+					jen.Id(handlerVarName):     jen.Id(handlerVarName),
+					jen.Id(handlerNameVarName): jen.Id(handlerNameVarName),
+				}),
+			),
+		).
+		Line()
+
+	code.Comment("HandlerName returns the name of the command handler.")
+	code.Func().
+		Params(
+			jen.Id("h").Id(commandHandlerTypeName),
+		).
+		Id("HandlerName").
+		Params().
+		Params(jen.String()).
+		Block(jen.Return(jen.Id("h").Dot(handlerNameVarName)))
+
+	code.Comment("NewCommand returns a new empty command used for serialization.")
+	code.Func().
+		Params(
+			jen.Id("h").Id(commandHandlerTypeName),
+		).
+		Id("NewCommand").
+		Params().
+		Params(jen.Interface()).
+		Block(jen.Return(jen.Op("&").Qual(commandHandler.Command.Package.Path, commandHandler.Command.Name).Values()))
+
+	code.Comment("Handle handles a command.")
+	code.Func().
+		Params(
+			jen.Id("h").Id(commandHandlerTypeName),
+		).
+		Id("Handle").
+		Params(
+			jen.Id("ctx").Qual("context", "Context"),
+			jen.Id("command").Interface(),
+		).
+		Params(jen.Error()).
+		Block(
+			jen.List(jen.Id("c"), jen.Id("ok")).
+				Op(":=").
+				Id("command").
+				Assert(jen.Op("*").Qual(commandHandler.Command.Package.Path, commandHandler.Command.Name)),
+			jen.If(jen.Op("!").Id("ok")).Block(
+				jen.Return(
+					jen.Qual("emperror.dev/errors", "NewWithDetails").Call(
+						jen.Lit("unexpected command type"),
+						jen.Lit("type"),
+						jen.Qual("fmt", "Sprintf").Call(
+							jen.Lit("%T"),
+							jen.Id("command"),
+						),
+					),
+				),
+			),
+			jen.Line(),
+			jen.Return(
+				jen.Id("h").Dot(handlerVarName).Dot(commandHandler.Name).Call(
+					jen.Id("ctx"),
+					jen.Op("*").Id("c"),
+				),
+			),
+		)
+}
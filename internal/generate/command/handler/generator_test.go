@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"sagikazarmark.dev/mga/pkg/gentypes"
+)
+
+func TestGenerate(t *testing.T) {
+	file := File{
+		File: gentypes.File{
+			Package: gentypes.PackageRef{
+				Name: "pkggen",
+				Path: "app.dev/pkg/pkggen",
+			},
+			HeaderText: `// Copyright 2020 Acme Inc.
+// All rights reserved.
+//
+// Licensed under "Only for testing purposes" license.
+`,
+		},
+		CommandHandlers: []CommandHandler{
+			{
+				Name: "CreateTodo",
+				Command: Command{
+					Name: "CreateTodo",
+					Package: gentypes.PackageRef{
+						Name: "pkg",
+						Path: "app.dev/pkg",
+					},
+				},
+			},
+		},
+	}
+
+	expected := `//go:build !ignore_autogenerated
+// +build !ignore_autogenerated
+
+// Copyright 2020 Acme Inc.
+// All rights reserved.
+//
+// Licensed under "Only for testing purposes" license.
+
+// Code generated by mga tool. DO NOT EDIT.
+
+package pkggen
+
+import (
+	"app.dev/pkg"
+	"context"
+	"emperror.dev/errors"
+	"fmt"
+)
+
+// CreateTodoHandler handles CreateTodo commands.
+type CreateTodoHandler interface {
+	// CreateTodo handles a(n) CreateTodo command.
+	CreateTodo(ctx context.Context, command pkg.CreateTodo) error
+}
+
+// CreateTodoCommandHandler handles CreateTodo commands.
+type CreateTodoCommandHandler struct {
+	handler CreateTodoHandler
+	name    string
+}
+
+// NewCreateTodoCommandHandler returns a new CreateTodoCommandHandler instance.
+func NewCreateTodoCommandHandler(handler CreateTodoHandler, name string) CreateTodoCommandHandler {
+	return CreateTodoCommandHandler{
+		handler: handler,
+		name:    name,
+	}
+}
+
+// HandlerName returns the name of the command handler.
+func (h CreateTodoCommandHandler) HandlerName() string {
+	return h.name
+}
+
+// NewCommand returns a new empty command used for serialization.
+func (h CreateTodoCommandHandler) NewCommand() interface{} {
+	return &pkg.CreateTodo{}
+}
+
+// Handle handles a command.
+func (h CreateTodoCommandHandler) Handle(ctx context.Context, command interface{}) error {
+	c, ok := command.(*pkg.CreateTodo)
+	if !ok {
+		return errors.NewWithDetails("unexpected command type", "type", fmt.Sprintf("%T", command))
+	}
+
+	return h.handler.CreateTodo(ctx, *c)
+}
+`
+
+	actual, err := Generate(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, expected, string(actual), "the generated code does not match the expected one")
+}
@@ -0,0 +1,142 @@
+package handlergen
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+
+	"sigs.k8s.io/controller-tools/pkg/genall"
+	"sigs.k8s.io/controller-tools/pkg/loader"
+	"sigs.k8s.io/controller-tools/pkg/markers"
+
+	"sagikazarmark.dev/mga/internal/generate/command/handler"
+	"sagikazarmark.dev/mga/pkg/generator"
+	"sagikazarmark.dev/mga/pkg/gentypes"
+	"sagikazarmark.dev/mga/pkg/genutils"
+)
+
+// nolint: gochecknoglobals
+var (
+	// HandlerMarker enables command handler generation for a command struct.
+	HandlerMarker = markers.Must(markers.MakeDefinition("mga:command:handler", markers.DescribesType, struct{}{}))
+)
+
+// Generator generates command handlers for commands.
+type Generator struct {
+	generator.Base
+}
+
+func (g Generator) RegisterMarkers(into *markers.Registry) error {
+	if err := into.Register(HandlerMarker); err != nil {
+		return err
+	}
+
+	into.AddHelp(
+		HandlerMarker,
+		markers.SimpleHelp("Command", "enables command handler generation for a command struct"),
+	)
+
+	return nil
+}
+
+// MarkerKinds restricts markers to the kinds of declarations the generator handles.
+func (Generator) MarkerKinds() map[string]string {
+	return map[string]string{
+		HandlerMarker.Name: genutils.StructKind,
+	}
+}
+
+func (Generator) CheckFilter() loader.NodeFilter {
+	return func(node ast.Node) bool {
+		// ignore non-structs
+		_, isStruct := node.(*ast.StructType)
+
+		return isStruct
+	}
+}
+
+// OutputFiles returns the names of the files written by the generator.
+func (g Generator) OutputFiles() []string {
+	return g.OutputFilePatterns("command_handler")
+}
+
+func (g Generator) Generate(ctx *genall.GenerationContext) error {
+	headerText, err := g.HeaderText(ctx)
+	if err != nil {
+		return err
+	}
+
+	fileName, err := g.OutputFileName("command_handler", "")
+	if err != nil {
+		return err
+	}
+
+	for _, root := range ctx.Roots {
+		outContents := g.generatePackage(ctx, headerText, root)
+		if outContents == nil {
+			continue
+		}
+
+		generator.WriteFile(ctx, root, fileName, outContents)
+	}
+
+	return nil
+}
+
+func (g Generator) generatePackage(ctx *genall.GenerationContext, headerText string, root *loader.Package) []byte {
+	ctx.Checker.Check(root)
+
+	root.NeedTypesInfo()
+
+	var commandHandlers []handler.CommandHandler
+
+	err := markers.EachType(ctx.Collector, root, func(info *markers.TypeInfo) {
+		if marker := info.Markers.Get(HandlerMarker.Name); marker == nil {
+			return
+		}
+
+		typeInfo := root.TypesInfo.TypeOf(info.RawSpec.Name)
+		if typeInfo == types.Typ[types.Invalid] {
+			root.AddError(loader.ErrFromNode(fmt.Errorf("unknown type %s", info.Name), info.RawSpec))
+
+			return
+		}
+
+		command, err := handler.ParseCommand(root.TypesInfo.ObjectOf(info.RawSpec.Name))
+		if err != nil {
+			root.AddError(err)
+
+			return
+		}
+
+		commandHandlers = append(commandHandlers, handler.CommandHandlerFromCommand(command))
+	})
+	if err != nil {
+		root.AddError(err)
+
+		return nil
+	}
+
+	if len(commandHandlers) == 0 {
+		return nil
+	}
+
+	outputPackage := generator.OutputPackage(ctx, root)
+
+	file := handler.File{
+		File: gentypes.File{
+			Package:    outputPackage,
+			HeaderText: headerText,
+		},
+		CommandHandlers: commandHandlers,
+	}
+
+	outContents, err := handler.Generate(file)
+	if err != nil {
+		root.AddError(err)
+
+		return nil
+	}
+
+	return outContents
+}
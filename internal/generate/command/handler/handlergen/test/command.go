@@ -0,0 +1,8 @@
+package test
+
+// Run: go:generate go run sagikazarmark.dev/mga generate command
+
+// +mga:command:handler
+type Command struct {
+	ID string
+}
@@ -0,0 +1,7 @@
+package testgen
+
+import (
+	"sagikazarmark.dev/mga/internal/generate/command/handler/handlergen/test"
+)
+
+type Command = test.Command
@@ -0,0 +1,68 @@
+package testgen
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ThreeDotsLabs/watermill/components/cqrs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type commandHandlerStub struct {
+	ctx     context.Context
+	command Command
+}
+
+func (s *commandHandlerStub) Command(ctx context.Context, command Command) error {
+	s.ctx = ctx
+	s.command = command
+
+	return nil
+}
+
+func TestCommandCommandHandler(t *testing.T) {
+	handler := NewCommandCommandHandler(&commandHandlerStub{}, "command_handler")
+
+	assert.Implements(t, (*cqrs.CommandHandler)(nil), handler)
+}
+
+func TestCommandCommandHandler_HandlerName(t *testing.T) {
+	handler := NewCommandCommandHandler(&commandHandlerStub{}, "command_handler")
+
+	name := handler.HandlerName()
+
+	assert.Equal(t, "command_handler", name)
+}
+
+func TestCommandCommandHandler_NewCommand(t *testing.T) {
+	handler := NewCommandCommandHandler(&commandHandlerStub{}, "command_handler")
+
+	command := handler.NewCommand()
+
+	assert.IsType(t, &Command{}, command)
+}
+
+func TestCommandCommandHandler_Handle(t *testing.T) {
+	h := &commandHandlerStub{}
+	handler := NewCommandCommandHandler(h, "command_handler")
+
+	ctx := context.Background()
+	command := Command{
+		ID: "1234",
+	}
+
+	err := handler.Handle(ctx, &command)
+	require.NoError(t, err)
+
+	assert.Equal(t, h.ctx, ctx)
+	assert.Equal(t, h.command, command)
+}
+
+func TestCommandCommandHandler_Handle_UnexpectedCommand(t *testing.T) {
+	handler := NewCommandCommandHandler(&commandHandlerStub{}, "command_handler")
+
+	err := handler.Handle(context.Background(), "command")
+
+	assert.EqualError(t, err, "unexpected command type")
+}
@@ -0,0 +1,17 @@
+package handler
+
+import (
+	"go/types"
+
+	eventhandler "sagikazarmark.dev/mga/internal/generate/event/handler"
+)
+
+// Command describes a command struct.
+type Command = eventhandler.Event
+
+// ParseCommand parses an object as a command.
+//
+// Commands follow the same rules as events (see handler.ParseEvent).
+func ParseCommand(obj types.Object) (Command, error) {
+	return eventhandler.ParseEvent(obj)
+}
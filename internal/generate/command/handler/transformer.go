@@ -0,0 +1,10 @@
+package handler
+
+// CommandHandlerFromCommand creates a CommandHandler from a Command.
+// nolint: golint
+func CommandHandlerFromCommand(command Command) CommandHandler {
+	return CommandHandler{
+		Name:    command.Name,
+		Command: command,
+	}
+}
@@ -0,0 +1,99 @@
+// Package diagnostics describes problems found in source code (eg. by generators or the marker linter).
+package diagnostics
+
+import (
+	"fmt"
+	"go/token"
+	"io"
+	"sort"
+)
+
+// Severity of a diagnostic.
+type Severity string
+
+// Supported severities.
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Diagnostic is a problem found in source code.
+type Diagnostic struct {
+	// Severity of the problem.
+	Severity Severity
+
+	// Source reporting the problem (eg. a generator name or lint).
+	Source string
+
+	// Package is the import path of the package the problem was found in.
+	Package string
+
+	// Position of the problem in the source code (might be invalid if unknown).
+	Position token.Position
+
+	// Message describes the problem.
+	Message string
+}
+
+// String formats the diagnostic as file:line:col: message.
+//
+// Diagnostics without a position are prefixed with their source (or package) instead.
+func (d Diagnostic) String() string {
+	message := d.Message
+	if d.Severity == SeverityWarning {
+		message = "warning: " + message
+	}
+
+	if !d.Position.IsValid() {
+		switch {
+		case d.Source != "":
+			return fmt.Sprintf("%s: %s", d.Source, message)
+
+		case d.Package != "":
+			return fmt.Sprintf("%s: %s", d.Package, message)
+		}
+
+		return message
+	}
+
+	return fmt.Sprintf("%s: %s", d.Position, message)
+}
+
+// Sort sorts diagnostics by position.
+func Sort(diags []Diagnostic) {
+	sort.SliceStable(diags, func(i, j int) bool {
+		a, b := diags[i].Position, diags[j].Position
+
+		if a.Filename != b.Filename {
+			return a.Filename < b.Filename
+		}
+
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+
+		return a.Column < b.Column
+	})
+}
+
+// HasErrors reports whether any of the diagnostics is an error.
+func HasErrors(diags []Diagnostic) bool {
+	for _, diag := range diags {
+		if diag.Severity == SeverityError {
+			return true
+		}
+	}
+
+	return false
+}
+
+// WriteText writes diagnostics as plain text (one per line).
+func WriteText(w io.Writer, diags []Diagnostic) error {
+	for _, diag := range diags {
+		if _, err := fmt.Fprintln(w, diag); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
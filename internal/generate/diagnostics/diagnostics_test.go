@@ -0,0 +1,207 @@
+package diagnostics
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"go/token"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/tools/go/packages"
+	"sigs.k8s.io/controller-tools/pkg/loader"
+)
+
+// nolint: gochecknoglobals
+var testDiags = []Diagnostic{
+	{
+		Severity: SeverityError,
+		Source:   "kit:endpoint",
+		Package:  "example.com/todo",
+		Position: token.Position{Filename: "/src/todo/service.go", Line: 12, Column: 1},
+		Message:  "unknown argument \"foo\"",
+	},
+	{
+		Severity: SeverityWarning,
+		Source:   "testify:mock",
+		Package:  "example.com/todo",
+		Message:  "no interfaces found",
+	},
+}
+
+func TestDiagnostic_String(t *testing.T) {
+	assert.Equal(t, `/src/todo/service.go:12:1: unknown argument "foo"`, testDiags[0].String())
+	assert.Equal(t, "testify:mock: warning: no interfaces found", testDiags[1].String())
+	assert.Equal(t, "example.com/todo: error", Diagnostic{Package: "example.com/todo", Message: "error"}.String())
+}
+
+func TestSort(t *testing.T) {
+	diags := []Diagnostic{
+		{Position: token.Position{Filename: "b.go", Line: 1, Column: 1}},
+		{Position: token.Position{Filename: "a.go", Line: 2, Column: 1}},
+		{Position: token.Position{Filename: "a.go", Line: 1, Column: 5}},
+		{Position: token.Position{Filename: "a.go", Line: 1, Column: 2}},
+	}
+
+	Sort(diags)
+
+	var positions []string
+	for _, diag := range diags {
+		positions = append(positions, diag.Position.String())
+	}
+
+	assert.Equal(t, []string{"a.go:1:2", "a.go:1:5", "a.go:2:1", "b.go:1:1"}, positions)
+}
+
+func TestParseFormat(t *testing.T) {
+	format, err := ParseFormat("sarif")
+	require.NoError(t, err)
+	assert.Equal(t, FormatSARIF, format)
+
+	format, err = ParseFormat("")
+	require.NoError(t, err)
+	assert.Equal(t, FormatText, format)
+
+	_, err = ParseFormat("xml")
+	require.EqualError(t, err, `unknown diagnostics format "xml" (supported formats: text, json, sarif)`)
+}
+
+func TestFromPackageError(t *testing.T) {
+	tests := []struct {
+		pos      string
+		expected token.Position
+	}{
+		{"/src/todo/service.go:12:3", token.Position{Filename: "/src/todo/service.go", Line: 12, Column: 3}},
+		{"/src/todo/service.go:12", token.Position{Filename: "/src/todo/service.go", Line: 12}},
+		{"example.com/todo:-", token.Position{}},
+		{"", token.Position{}},
+	}
+
+	for _, test := range tests {
+		diag := FromPackageError("gen", "example.com/todo", packages.Error{Pos: test.pos, Msg: "error"})
+
+		assert.Equal(t, test.expected, diag.Position, test.pos)
+		assert.Equal(t, SeverityError, diag.Severity)
+		assert.Equal(t, "gen", diag.Source)
+		assert.Equal(t, "example.com/todo", diag.Package)
+		assert.Equal(t, "error", diag.Message)
+	}
+}
+
+func TestFromError(t *testing.T) {
+	diags := FromError("gen", "example.com/todo", loader.ErrList{errors.New("first"), errors.New("second")})
+
+	assert.Equal(
+		t,
+		[]Diagnostic{
+			{Severity: SeverityError, Source: "gen", Package: "example.com/todo", Message: "first"},
+			{Severity: SeverityError, Source: "gen", Package: "example.com/todo", Message: "second"},
+		},
+		diags,
+	)
+}
+
+func TestWriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+
+	err := WriteJSON(&buf, testDiags)
+	require.NoError(t, err)
+
+	expected := `[
+  {
+    "severity": "error",
+    "generator": "kit:endpoint",
+    "package": "example.com/todo",
+    "file": "/src/todo/service.go",
+    "line": 12,
+    "column": 1,
+    "message": "unknown argument \"foo\""
+  },
+  {
+    "severity": "warning",
+    "generator": "testify:mock",
+    "package": "example.com/todo",
+    "message": "no interfaces found"
+  }
+]
+`
+
+	assert.Equal(t, expected, buf.String())
+
+	buf.Reset()
+
+	err = WriteJSON(&buf, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, "[]\n", buf.String())
+}
+
+func TestWriteSARIF(t *testing.T) {
+	var buf bytes.Buffer
+
+	diags := []Diagnostic{
+		testDiags[0],
+		testDiags[1],
+		{
+			Severity: SeverityError,
+			Position: token.Position{Filename: "/other/file.go", Line: 1},
+			Message:  "outside of the source root",
+		},
+	}
+
+	err := writeSARIF(&buf, diags, "/src")
+	require.NoError(t, err)
+
+	var log sarifLog
+
+	err = json.Unmarshal(buf.Bytes(), &log)
+	require.NoError(t, err)
+
+	assert.Equal(t, "2.1.0", log.Version)
+	require.Len(t, log.Runs, 1)
+
+	run := log.Runs[0]
+
+	assert.Equal(t, "mga", run.Tool.Driver.Name)
+	assert.Equal(t, []sarifRule{{ID: "kit:endpoint"}, {ID: "testify:mock"}}, run.Tool.Driver.Rules)
+
+	assert.Equal(
+		t,
+		[]sarifResult{
+			{
+				RuleID:  "kit:endpoint",
+				Level:   "error",
+				Message: sarifMessage{Text: "unknown argument \"foo\""},
+				Locations: []sarifLocation{
+					{
+						PhysicalLocation: sarifPhysicalLocation{
+							ArtifactLocation: sarifArtifactLocation{URI: "todo/service.go", URIBaseID: "%SRCROOT%"},
+							Region:           &sarifRegion{StartLine: 12, StartColumn: 1},
+						},
+					},
+				},
+				Properties: map[string]string{"package": "example.com/todo"},
+			},
+			{
+				RuleID:     "testify:mock",
+				Level:      "warning",
+				Message:    sarifMessage{Text: "no interfaces found"},
+				Properties: map[string]string{"package": "example.com/todo"},
+			},
+			{
+				Level:   "error",
+				Message: sarifMessage{Text: "outside of the source root"},
+				Locations: []sarifLocation{
+					{
+						PhysicalLocation: sarifPhysicalLocation{
+							ArtifactLocation: sarifArtifactLocation{URI: "file:///other/file.go"},
+							Region:           &sarifRegion{StartLine: 1},
+						},
+					},
+				},
+			},
+		},
+		run.Results,
+	)
+}
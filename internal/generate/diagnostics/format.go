@@ -0,0 +1,47 @@
+package diagnostics
+
+import (
+	"fmt"
+	"io"
+)
+
+// Format is an output format of diagnostics.
+type Format string
+
+// Supported formats.
+const (
+	FormatText  Format = "text"
+	FormatJSON  Format = "json"
+	FormatSARIF Format = "sarif"
+)
+
+// ParseFormat parses a diagnostics output format.
+func ParseFormat(format string) (Format, error) {
+	switch f := Format(format); f {
+	case FormatText, FormatJSON, FormatSARIF:
+		return f, nil
+
+	case "":
+		return FormatText, nil
+
+	default:
+		return "", fmt.Errorf("unknown diagnostics format %q (supported formats: text, json, sarif)", format)
+	}
+}
+
+// Write writes diagnostics in the given format (defaults to text).
+func Write(w io.Writer, format Format, diags []Diagnostic) error {
+	switch format {
+	case FormatText, "":
+		return WriteText(w, diags)
+
+	case FormatJSON:
+		return WriteJSON(w, diags)
+
+	case FormatSARIF:
+		return WriteSARIF(w, diags)
+
+	default:
+		return fmt.Errorf("unknown diagnostics format %q", format)
+	}
+}
@@ -0,0 +1,39 @@
+package diagnostics
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// record is the JSON representation of a diagnostic.
+type record struct {
+	Severity  Severity `json:"severity"`
+	Generator string   `json:"generator,omitempty"`
+	Package   string   `json:"package,omitempty"`
+	File      string   `json:"file,omitempty"`
+	Line      int      `json:"line,omitempty"`
+	Column    int      `json:"column,omitempty"`
+	Message   string   `json:"message"`
+}
+
+// WriteJSON writes diagnostics as a JSON array (an empty array if there are no diagnostics).
+func WriteJSON(w io.Writer, diags []Diagnostic) error {
+	records := make([]record, 0, len(diags))
+
+	for _, diag := range diags {
+		records = append(records, record{
+			Severity:  diag.Severity,
+			Generator: diag.Source,
+			Package:   diag.Package,
+			File:      diag.Position.Filename,
+			Line:      diag.Position.Line,
+			Column:    diag.Position.Column,
+			Message:   diag.Message,
+		})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(records)
+}
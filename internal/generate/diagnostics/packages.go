@@ -0,0 +1,86 @@
+package diagnostics
+
+import (
+	"errors"
+	"go/token"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+	"sigs.k8s.io/controller-tools/pkg/loader"
+)
+
+// FromPackageError converts a package error (eg. one added with loader.Package.AddError) to a diagnostic.
+func FromPackageError(source string, pkgPath string, err packages.Error) Diagnostic {
+	return Diagnostic{
+		Severity: SeverityError,
+		Source:   source,
+		Package:  pkgPath,
+		Position: parsePosition(err.Pos),
+		Message:  err.Msg,
+	}
+}
+
+// FromError converts an error returned by a source (eg. a generator) to diagnostics.
+func FromError(source string, pkgPath string, err error) []Diagnostic {
+	errs := []error{err}
+
+	var errList loader.ErrList
+	if errors.As(err, &errList) {
+		errs = errList
+	}
+
+	diags := make([]Diagnostic, 0, len(errs))
+
+	for _, err := range errs {
+		diags = append(diags, Diagnostic{
+			Severity: SeverityError,
+			Source:   source,
+			Package:  pkgPath,
+			Message:  err.Error(),
+		})
+	}
+
+	return diags
+}
+
+// parsePosition parses positions formatted as file:line:col or file:line.
+//
+// Unknown positions (eg. package:-) result in an invalid position.
+func parsePosition(pos string) token.Position {
+	var position token.Position
+
+	rest, last, ok := cutLast(pos)
+	if !ok {
+		return position
+	}
+
+	lastNum, err := strconv.Atoi(last)
+	if err != nil {
+		return position
+	}
+
+	if filename, line, ok := cutLast(rest); ok {
+		if lineNum, err := strconv.Atoi(line); err == nil {
+			position.Filename = filename
+			position.Line = lineNum
+			position.Column = lastNum
+
+			return position
+		}
+	}
+
+	position.Filename = rest
+	position.Line = lastNum
+
+	return position
+}
+
+func cutLast(s string) (string, string, bool) {
+	i := strings.LastIndex(s, ":")
+	if i < 0 {
+		return "", "", false
+	}
+
+	return s[:i], s[i+1:], true
+}
@@ -0,0 +1,160 @@
+package diagnostics
+
+import (
+	"encoding/json"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// SARIF 2.1.0 log format (only the parts required for reporting results).
+// See https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html
+type (
+	sarifLog struct {
+		Schema  string     `json:"$schema"`
+		Version string     `json:"version"`
+		Runs    []sarifRun `json:"runs"`
+	}
+
+	sarifRun struct {
+		Tool    sarifTool     `json:"tool"`
+		Results []sarifResult `json:"results"`
+	}
+
+	sarifTool struct {
+		Driver sarifDriver `json:"driver"`
+	}
+
+	sarifDriver struct {
+		Name           string      `json:"name"`
+		InformationURI string      `json:"informationUri"`
+		Rules          []sarifRule `json:"rules,omitempty"`
+	}
+
+	sarifRule struct {
+		ID string `json:"id"`
+	}
+
+	sarifResult struct {
+		RuleID     string            `json:"ruleId,omitempty"`
+		Level      string            `json:"level"`
+		Message    sarifMessage      `json:"message"`
+		Locations  []sarifLocation   `json:"locations,omitempty"`
+		Properties map[string]string `json:"properties,omitempty"`
+	}
+
+	sarifMessage struct {
+		Text string `json:"text"`
+	}
+
+	sarifLocation struct {
+		PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+	}
+
+	sarifPhysicalLocation struct {
+		ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+		Region           *sarifRegion          `json:"region,omitempty"`
+	}
+
+	sarifArtifactLocation struct {
+		URI       string `json:"uri"`
+		URIBaseID string `json:"uriBaseId,omitempty"`
+	}
+
+	sarifRegion struct {
+		StartLine   int `json:"startLine"`
+		StartColumn int `json:"startColumn,omitempty"`
+	}
+)
+
+// WriteSARIF writes diagnostics as a SARIF log (eg. for code scanning tools).
+//
+// Rules are named after the source of diagnostics (eg. generator names).
+// Files in the working directory are referenced relative to the %SRCROOT% base.
+func WriteSARIF(w io.Writer, diags []Diagnostic) error {
+	baseDir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	return writeSARIF(w, diags, baseDir)
+}
+
+func writeSARIF(w io.Writer, diags []Diagnostic, baseDir string) error {
+	run := sarifRun{
+		Tool: sarifTool{
+			Driver: sarifDriver{
+				Name:           "mga",
+				InformationURI: "https://github.com/sagikazarmark/mga",
+			},
+		},
+		Results: make([]sarifResult, 0, len(diags)),
+	}
+
+	rules := make(map[string]bool)
+
+	for _, diag := range diags {
+		if diag.Source != "" && !rules[diag.Source] {
+			rules[diag.Source] = true
+			run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{ID: diag.Source})
+		}
+
+		result := sarifResult{
+			RuleID:  diag.Source,
+			Level:   string(diag.Severity),
+			Message: sarifMessage{Text: diag.Message},
+		}
+
+		if diag.Package != "" {
+			result.Properties = map[string]string{"package": diag.Package}
+		}
+
+		if diag.Position.Filename != "" {
+			location := sarifPhysicalLocation{
+				ArtifactLocation: artifactLocation(diag.Position.Filename, baseDir),
+			}
+
+			if diag.Position.Line > 0 {
+				location.Region = &sarifRegion{
+					StartLine:   diag.Position.Line,
+					StartColumn: diag.Position.Column,
+				}
+			}
+
+			result.Locations = []sarifLocation{{PhysicalLocation: location}}
+		}
+
+		run.Results = append(run.Results, result)
+	}
+
+	sort.Slice(run.Tool.Driver.Rules, func(i, j int) bool {
+		return run.Tool.Driver.Rules[i].ID < run.Tool.Driver.Rules[j].ID
+	})
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	})
+}
+
+func artifactLocation(filename string, baseDir string) sarifArtifactLocation {
+	if rel, err := filepath.Rel(baseDir, filename); err == nil && filepath.IsAbs(filename) && !strings.HasPrefix(rel, "..") {
+		return sarifArtifactLocation{
+			URI:       filepath.ToSlash(rel),
+			URIBaseID: "%SRCROOT%",
+		}
+	}
+
+	if !filepath.IsAbs(filename) {
+		return sarifArtifactLocation{URI: filepath.ToSlash(filename)}
+	}
+
+	return sarifArtifactLocation{URI: (&url.URL{Scheme: "file", Path: filepath.ToSlash(filename)}).String()}
+}
@@ -0,0 +1,205 @@
+package asyncapigen
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/controller-tools/pkg/genall"
+	"sigs.k8s.io/controller-tools/pkg/loader"
+	"sigs.k8s.io/controller-tools/pkg/markers"
+
+	"sagikazarmark.dev/mga/internal/generate/event/asyncapi"
+	"sagikazarmark.dev/mga/internal/generate/event/dispatcher"
+	"sagikazarmark.dev/mga/internal/generate/event/dispatcher/dispatchergen"
+	"sagikazarmark.dev/mga/internal/generate/event/handler"
+	"sagikazarmark.dev/mga/internal/generate/event/handler/handlergen"
+	"sagikazarmark.dev/mga/pkg/generator"
+)
+
+// Generator generates an AsyncAPI document from event dispatchers and event handlers.
+type Generator struct {
+	// Title of the application. Falls back to the package name.
+	Title string `marker:",optional"`
+
+	// Version of the application API.
+	Version string `marker:",optional"`
+
+	// TopicStrategy decides how channels are named (struct or qualified).
+	TopicStrategy string `marker:",optional"`
+}
+
+func (g Generator) RegisterMarkers(into *markers.Registry) error {
+	if err := (dispatchergen.Generator{}).RegisterMarkers(into); err != nil {
+		return err
+	}
+
+	return (handlergen.Generator{}).RegisterMarkers(into)
+}
+
+func (Generator) CheckFilter() loader.NodeFilter {
+	return func(node ast.Node) bool {
+		return true
+	}
+}
+
+// OutputFiles returns the names of the files written by the generator.
+func (Generator) OutputFiles() []string {
+	return []string{"zz_generated.asyncapi.yaml"}
+}
+
+func (g Generator) Generate(ctx *genall.GenerationContext) error {
+	topicStrategy := asyncapi.TopicStrategy(g.TopicStrategy)
+
+	switch topicStrategy {
+	case "":
+		topicStrategy = asyncapi.TopicStrategyStruct
+
+	case asyncapi.TopicStrategyStruct, asyncapi.TopicStrategyQualified:
+
+	default:
+		return fmt.Errorf("unknown topic strategy %q", g.TopicStrategy)
+	}
+
+	for _, root := range ctx.Roots {
+		outContents := g.generatePackage(ctx, topicStrategy, root)
+		if outContents == nil {
+			continue
+		}
+
+		generator.WriteFile(ctx, root, "zz_generated.asyncapi.yaml", outContents)
+	}
+
+	return nil
+}
+
+func (g Generator) generatePackage(
+	ctx *genall.GenerationContext,
+	topicStrategy asyncapi.TopicStrategy,
+	root *loader.Package,
+) []byte {
+	ctx.Checker.Check(root)
+
+	root.NeedTypesInfo()
+
+	var operations []asyncapi.Operation
+	schemas := asyncapi.Schemas{}
+
+	err := markers.EachType(ctx.Collector, root, func(info *markers.TypeInfo) {
+		isDispatcher := info.Markers.Get(dispatchergen.DispatcherMarker.Name) != nil
+		handlerMarker, isHandler := info.Markers.Get(handlergen.HandlerMarker.Name).(handlergen.Marker)
+
+		if !isDispatcher && !isHandler {
+			return
+		}
+
+		typeInfo := root.TypesInfo.TypeOf(info.RawSpec.Name)
+		if typeInfo == types.Typ[types.Invalid] {
+			root.AddError(loader.ErrFromNode(fmt.Errorf("unknown type %s", info.Name), info.RawSpec))
+
+			return
+		}
+
+		obj := root.TypesInfo.ObjectOf(info.RawSpec.Name)
+
+		if isDispatcher {
+			events, err := dispatcher.ParseEvents(obj)
+			if err != nil {
+				root.AddError(loader.ErrFromNode(err, info.RawSpec))
+
+				return
+			}
+
+			err = asyncapi.ParseEventSchemas(obj, schemas)
+			if err != nil {
+				root.AddError(loader.ErrFromNode(err, info.RawSpec))
+
+				return
+			}
+
+			operations = append(operations, asyncapi.SendOperationsFromEvents(events, methodDocs(info.RawSpec))...)
+		}
+
+		if isHandler {
+			event, err := handler.ParseEvent(obj)
+			if err != nil {
+				root.AddError(loader.ErrFromNode(err, info.RawSpec))
+
+				return
+			}
+
+			_, err = asyncapi.ParseSchema(obj.Type(), schemas)
+			if err != nil {
+				root.AddError(loader.ErrFromNode(err, info.RawSpec))
+
+				return
+			}
+
+			operations = append(operations, asyncapi.ReceiveOperationFromEvent(event, handlerMarker.Group, info.Doc))
+		}
+	})
+	if err != nil {
+		root.AddError(err)
+
+		return nil
+	}
+
+	if len(operations) == 0 {
+		return nil
+	}
+
+	sort.SliceStable(operations, func(i, j int) bool {
+		return operations[i].ID < operations[j].ID
+	})
+
+	title := g.Title
+	if title == "" {
+		title = root.Name
+	}
+
+	version := g.Version
+	if version == "" {
+		version = "1.0.0"
+	}
+
+	spec := asyncapi.Spec{
+		Title:         title,
+		Version:       version,
+		TopicStrategy: topicStrategy,
+		Operations:    operations,
+		Schemas:       schemas,
+	}
+
+	outContents, err := asyncapi.Generate(spec)
+	if err != nil {
+		root.AddError(err)
+
+		return nil
+	}
+
+	return outContents
+}
+
+// methodDocs collects documentation of methods declared directly in an interface.
+func methodDocs(spec *ast.TypeSpec) map[string]string {
+	docs := map[string]string{}
+
+	iface, ok := spec.Type.(*ast.InterfaceType)
+	if !ok {
+		return docs
+	}
+
+	for _, method := range iface.Methods.List {
+		if method.Doc == nil {
+			continue
+		}
+
+		for _, name := range method.Names {
+			docs[name.Name] = strings.Join(strings.Fields(method.Doc.Text()), " ")
+		}
+	}
+
+	return docs
+}
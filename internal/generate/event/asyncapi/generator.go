@@ -0,0 +1,184 @@
+package asyncapi
+
+import (
+	"bytes"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"sagikazarmark.dev/mga/pkg/gentypes"
+)
+
+// Spec provides information for generating an AsyncAPI document.
+type Spec struct {
+	// Title of the application.
+	Title string
+
+	// Version of the application API.
+	Version string
+
+	// TopicStrategy decides how channels are named.
+	TopicStrategy TopicStrategy
+
+	// Operations are the messages sent and received by the application.
+	Operations []Operation
+
+	// Schemas are the payload schemas referenced by messages.
+	Schemas Schemas
+}
+
+// Action is the kind of an operation.
+type Action string
+
+// Supported operation actions.
+const (
+	ActionSend    Action = "send"
+	ActionReceive Action = "receive"
+)
+
+// Operation describes an event being sent or received.
+type Operation struct {
+	// ID uniquely identifies the operation in the document.
+	ID string
+
+	Action      Action
+	Description string
+	Message     Message
+}
+
+// Message describes an event.
+type Message struct {
+	Event       gentypes.TypeRef
+	Description string
+}
+
+type document struct {
+	AsyncAPI           string               `yaml:"asyncapi"`
+	Info               info                 `yaml:"info"`
+	DefaultContentType string               `yaml:"defaultContentType"`
+	Channels           map[string]channel   `yaml:"channels,omitempty"`
+	Operations         map[string]operation `yaml:"operations,omitempty"`
+	Components         components           `yaml:"components,omitempty"`
+}
+
+type info struct {
+	Title   string `yaml:"title"`
+	Version string `yaml:"version"`
+}
+
+type channel struct {
+	Address  string         `yaml:"address"`
+	Messages map[string]ref `yaml:"messages"`
+}
+
+type operation struct {
+	Action      Action `yaml:"action"`
+	Channel     ref    `yaml:"channel"`
+	Description string `yaml:"description,omitempty"`
+	Messages    []ref  `yaml:"messages"`
+}
+
+type ref struct {
+	Ref string `yaml:"$ref"`
+}
+
+type components struct {
+	Messages map[string]message `yaml:"messages,omitempty"`
+	Schemas  Schemas            `yaml:"schemas,omitempty"`
+}
+
+type message struct {
+	Name        string  `yaml:"name"`
+	Description string  `yaml:"description,omitempty"`
+	Payload     *Schema `yaml:"payload"`
+}
+
+// Generate generates an AsyncAPI document.
+//
+// Events with the same name from different packages cannot be described in the same document.
+func Generate(spec Spec) ([]byte, error) {
+	doc := document{
+		AsyncAPI: "3.0.0",
+		Info: info{
+			Title:   spec.Title,
+			Version: spec.Version,
+		},
+		DefaultContentType: "application/json",
+		Channels:           map[string]channel{},
+		Operations:         map[string]operation{},
+		Components: components{
+			Messages: map[string]message{},
+			Schemas:  spec.Schemas,
+		},
+	}
+
+	messageTypes := map[string]gentypes.TypeRef{}
+
+	for _, op := range spec.Operations {
+		topic := spec.TopicStrategy.Topic(op.Message.Event)
+		messageName := SchemaName(op.Message.Event)
+
+		if typ, ok := messageTypes[messageName]; ok && typ != op.Message.Event {
+			return nil, nameConflictError("message", messageName, typ, op.Message.Event)
+		}
+
+		messageTypes[messageName] = op.Message.Event
+
+		ch, ok := doc.Channels[topic]
+		if !ok {
+			ch = channel{
+				Address:  topic,
+				Messages: map[string]ref{},
+			}
+
+			doc.Channels[topic] = ch
+		}
+
+		ch.Messages[messageName] = ref{Ref: "#/components/messages/" + escapeRef(messageName)}
+
+		msg, ok := doc.Components.Messages[messageName]
+		if !ok || msg.Description == "" {
+			doc.Components.Messages[messageName] = message{
+				Name:        op.Message.Event.Name,
+				Description: op.Message.Description,
+				Payload:     &Schema{Ref: SchemaRef(op.Message.Event)},
+			}
+		}
+
+		doc.Operations[op.ID] = operation{
+			Action:      op.Action,
+			Channel:     ref{Ref: "#/channels/" + escapeRef(topic)},
+			Description: op.Description,
+			Messages: []ref{
+				{Ref: "#/channels/" + escapeRef(topic) + "/messages/" + escapeRef(messageName)},
+			},
+		}
+	}
+
+	var buf bytes.Buffer
+
+	buf.WriteString("# Code generated by mga tool. DO NOT EDIT.\n\n")
+
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+
+	err := encoder.Encode(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	err = encoder.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// nolint: gochecknoglobals
+var refEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// escapeRef escapes a token in a JSON pointer.
+func escapeRef(token string) string {
+	return refEscaper.Replace(token)
+}
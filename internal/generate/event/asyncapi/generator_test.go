@@ -0,0 +1,141 @@
+package asyncapi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sagikazarmark.dev/mga/pkg/gentypes"
+)
+
+func TestGenerate(t *testing.T) {
+	event := gentypes.TypeRef{
+		Name: "MarkedAsDone",
+		Package: gentypes.PackageRef{
+			Name: "todo",
+			Path: "app.dev/todo",
+		},
+	}
+
+	spec := Spec{
+		Title:         "Todo",
+		Version:       "1.0.0",
+		TopicStrategy: TopicStrategyQualified,
+		Operations: []Operation{
+			{
+				ID:          "Events.MarkedAsDone",
+				Action:      ActionSend,
+				Description: "MarkedAsDone dispatches a MarkedAsDone event.",
+				Message: Message{
+					Event: event,
+				},
+			},
+			{
+				ID:     "MarkedAsDoneHandler.MarkedAsDone",
+				Action: ActionReceive,
+				Message: Message{
+					Event:       event,
+					Description: "MarkedAsDone is published when a todo is marked as done.",
+				},
+			},
+		},
+		Schemas: Schemas{
+			"MarkedAsDone": {
+				Type: "object",
+				Properties: map[string]*Schema{
+					"id": {Type: "string"},
+				},
+				Required: []string{"id"},
+			},
+		},
+	}
+
+	expected := `# Code generated by mga tool. DO NOT EDIT.
+
+asyncapi: 3.0.0
+info:
+  title: Todo
+  version: 1.0.0
+defaultContentType: application/json
+channels:
+  todo.MarkedAsDone:
+    address: todo.MarkedAsDone
+    messages:
+      MarkedAsDone:
+        $ref: '#/components/messages/MarkedAsDone'
+operations:
+  Events.MarkedAsDone:
+    action: send
+    channel:
+      $ref: '#/channels/todo.MarkedAsDone'
+    description: MarkedAsDone dispatches a MarkedAsDone event.
+    messages:
+      - $ref: '#/channels/todo.MarkedAsDone/messages/MarkedAsDone'
+  MarkedAsDoneHandler.MarkedAsDone:
+    action: receive
+    channel:
+      $ref: '#/channels/todo.MarkedAsDone'
+    messages:
+      - $ref: '#/channels/todo.MarkedAsDone/messages/MarkedAsDone'
+components:
+  messages:
+    MarkedAsDone:
+      name: MarkedAsDone
+      description: MarkedAsDone is published when a todo is marked as done.
+      payload:
+        $ref: '#/components/schemas/MarkedAsDone'
+  schemas:
+    MarkedAsDone:
+      type: object
+      properties:
+        id:
+          type: string
+      required:
+        - id
+`
+
+	actual, err := Generate(spec)
+	require.NoError(t, err)
+
+	assert.Equal(t, expected, string(actual), "the generated document does not match the expected one")
+}
+
+func TestGenerate_MessageNameConflict(t *testing.T) {
+	spec := Spec{
+		Title:         "Todo",
+		Version:       "1.0.0",
+		TopicStrategy: TopicStrategyStruct,
+		Operations: []Operation{
+			{
+				ID:     "Events.MarkedAsDone",
+				Action: ActionSend,
+				Message: Message{
+					Event: gentypes.TypeRef{
+						Name:    "MarkedAsDone",
+						Package: gentypes.PackageRef{Name: "todo", Path: "app.dev/todo"},
+					},
+				},
+			},
+			{
+				ID:     "MarkedAsDoneHandler.MarkedAsDone",
+				Action: ActionReceive,
+				Message: Message{
+					Event: gentypes.TypeRef{
+						Name:    "MarkedAsDone",
+						Package: gentypes.PackageRef{Name: "task", Path: "app.dev/task"},
+					},
+				},
+			},
+		},
+	}
+
+	_, err := Generate(spec)
+	require.Error(t, err)
+
+	assert.Equal(
+		t,
+		`message name "MarkedAsDone" of app.dev/task.MarkedAsDone conflicts with app.dev/todo.MarkedAsDone: rename one of the types`,
+		err.Error(),
+	)
+}
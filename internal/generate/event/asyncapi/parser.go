@@ -0,0 +1,313 @@
+package asyncapi
+
+import (
+	"fmt"
+	"go/types"
+	"reflect"
+	"strings"
+
+	"sagikazarmark.dev/mga/pkg/gentypes"
+)
+
+// Schema is a (subset of) JSON Schema describing a message payload.
+type Schema struct {
+	Ref                  string             `yaml:"$ref,omitempty"`
+	Type                 string             `yaml:"type,omitempty"`
+	Format               string             `yaml:"format,omitempty"`
+	Description          string             `yaml:"description,omitempty"`
+	Properties           map[string]*Schema `yaml:"properties,omitempty"`
+	Required             []string           `yaml:"required,omitempty"`
+	Items                *Schema            `yaml:"items,omitempty"`
+	AdditionalProperties *Schema            `yaml:"additionalProperties,omitempty"`
+
+	// typ is the Go type described by a named schema.
+	typ gentypes.TypeRef
+}
+
+// Schemas is a set of named schemas referenced from message payloads.
+type Schemas map[string]*Schema
+
+// ParseSchema parses a Go type as a JSON Schema.
+//
+// Named struct types are registered in schemas and referenced from the returned schema.
+// Types with the same name from different packages cannot be registered in the same set of schemas.
+// Struct fields are parsed the same way encoding/json marshals them.
+func ParseSchema(typ types.Type, schemas Schemas) (*Schema, error) {
+	return parseSchema(typ, schemas, nil)
+}
+
+func parseSchema(typ types.Type, schemas Schemas, stack []*types.Named) (*Schema, error) {
+	switch t := typ.(type) {
+	case *types.Basic:
+		return basicSchema(t)
+
+	case *types.Pointer:
+		return parseSchema(t.Elem(), schemas, stack)
+
+	case *types.Slice:
+		// encoding/json marshals byte slices as base64 strings
+		if basic, ok := t.Elem().(*types.Basic); ok && basic.Kind() == types.Byte {
+			return &Schema{Type: "string", Format: "byte"}, nil
+		}
+
+		items, err := parseSchema(t.Elem(), schemas, stack)
+		if err != nil {
+			return nil, err
+		}
+
+		return &Schema{Type: "array", Items: items}, nil
+
+	case *types.Array:
+		items, err := parseSchema(t.Elem(), schemas, stack)
+		if err != nil {
+			return nil, err
+		}
+
+		return &Schema{Type: "array", Items: items}, nil
+
+	case *types.Map:
+		additionalProperties, err := parseSchema(t.Elem(), schemas, stack)
+		if err != nil {
+			return nil, err
+		}
+
+		return &Schema{Type: "object", AdditionalProperties: additionalProperties}, nil
+
+	case *types.Interface:
+		return &Schema{}, nil
+
+	case *types.Struct:
+		return structSchema(t, schemas, stack)
+
+	case *types.Alias:
+		return parseSchema(types.Unalias(t), schemas, stack)
+
+	case *types.Named:
+		return namedSchema(t, schemas, stack)
+	}
+
+	return nil, fmt.Errorf("unsupported type in event payload: %s", typ.String())
+}
+
+func basicSchema(t *types.Basic) (*Schema, error) {
+	info := t.Info()
+
+	switch {
+	case info&types.IsBoolean != 0:
+		return &Schema{Type: "boolean"}, nil
+
+	case info&types.IsString != 0:
+		return &Schema{Type: "string"}, nil
+
+	case info&types.IsInteger != 0:
+		switch t.Kind() {
+		case types.Int32, types.Uint32, types.Int16, types.Uint16, types.Int8, types.Uint8:
+			return &Schema{Type: "integer", Format: "int32"}, nil
+
+		default:
+			return &Schema{Type: "integer", Format: "int64"}, nil
+		}
+
+	case info&types.IsFloat != 0:
+		if t.Kind() == types.Float32 {
+			return &Schema{Type: "number", Format: "float"}, nil
+		}
+
+		return &Schema{Type: "number", Format: "double"}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported basic type in event payload: %s", t.String())
+}
+
+func namedSchema(t *types.Named, schemas Schemas, stack []*types.Named) (*Schema, error) {
+	obj := t.Obj()
+
+	if obj.Pkg() != nil && obj.Pkg().Path() == "time" && obj.Name() == "Time" {
+		return &Schema{Type: "string", Format: "date-time"}, nil
+	}
+
+	if obj.Pkg() != nil && obj.Pkg().Path() == "encoding/json" && obj.Name() == "RawMessage" {
+		return &Schema{}, nil
+	}
+
+	st, ok := t.Underlying().(*types.Struct)
+	if !ok {
+		return parseSchema(t.Underlying(), schemas, stack)
+	}
+
+	typeRef := TypeRefOf(obj)
+	name := SchemaName(typeRef)
+	ref := SchemaRef(typeRef)
+
+	for _, n := range stack {
+		if n == t {
+			return &Schema{Ref: ref}, nil
+		}
+
+		// schemas are registered once parsed, so types being parsed are checked separately
+		if other := TypeRefOf(n.Obj()); SchemaName(other) == name && other != typeRef {
+			return nil, nameConflictError("schema", name, other, typeRef)
+		}
+	}
+
+	if schema, ok := schemas[name]; ok {
+		if schema.typ != typeRef {
+			return nil, nameConflictError("schema", name, schema.typ, typeRef)
+		}
+
+		return &Schema{Ref: ref}, nil
+	}
+
+	schema, err := structSchema(st, schemas, append(stack, t))
+	if err != nil {
+		return nil, err
+	}
+
+	schema.typ = typeRef
+	schemas[name] = schema
+
+	return &Schema{Ref: ref}, nil
+}
+
+// nameConflictError reports two different types described under the same name.
+func nameConflictError(kind string, name string, registered gentypes.TypeRef, typ gentypes.TypeRef) error {
+	return fmt.Errorf(
+		"%s name %q of %s.%s conflicts with %s.%s: rename one of the types",
+		kind, name,
+		typ.Package.Path, typ.Name,
+		registered.Package.Path, registered.Name,
+	)
+}
+
+func structSchema(t *types.Struct, schemas Schemas, stack []*types.Named) (*Schema, error) {
+	schema := &Schema{
+		Type:       "object",
+		Properties: map[string]*Schema{},
+	}
+
+	for i := 0; i < t.NumFields(); i++ {
+		field := t.Field(i)
+
+		name, omitEmpty, skip := jsonField(field, reflect.StructTag(t.Tag(i)))
+		if skip {
+			continue
+		}
+
+		// Embedded structs without a JSON name are flattened by encoding/json
+		if field.Embedded() && name == "" {
+			embedded := field.Type()
+			if p, ok := embedded.(*types.Pointer); ok {
+				embedded = p.Elem()
+			}
+
+			if st, ok := embedded.Underlying().(*types.Struct); ok {
+				embeddedSchema, err := structSchema(st, schemas, stack)
+				if err != nil {
+					return nil, err
+				}
+
+				for propName, prop := range embeddedSchema.Properties {
+					if _, ok := schema.Properties[propName]; !ok {
+						schema.Properties[propName] = prop
+					}
+				}
+
+				schema.Required = append(schema.Required, embeddedSchema.Required...)
+
+				continue
+			}
+
+			if !field.Exported() {
+				continue
+			}
+		}
+
+		if name == "" {
+			name = field.Name()
+		}
+
+		prop, err := parseSchema(field.Type(), schemas, stack)
+		if err != nil {
+			return nil, err
+		}
+
+		schema.Properties[name] = prop
+
+		if !omitEmpty {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+
+	return schema, nil
+}
+
+// jsonField returns the JSON name of a field (if any), whether it's omitted when empty and whether it's skipped.
+func jsonField(field *types.Var, tag reflect.StructTag) (string, bool, bool) {
+	jsonTag, hasTag := tag.Lookup("json")
+	if jsonTag == "-" {
+		return "", false, true
+	}
+
+	if !field.Exported() && !field.Embedded() {
+		return "", false, true
+	}
+
+	if !hasTag {
+		return "", false, false
+	}
+
+	name, opts, _ := strings.Cut(jsonTag, ",")
+
+	var omitEmpty bool
+
+	for _, opt := range strings.Split(opts, ",") {
+		if opt == "omitempty" || opt == "omitzero" {
+			omitEmpty = true
+		}
+	}
+
+	return name, omitEmpty, false
+}
+
+// TypeRefOf returns a reference to a type object.
+func TypeRefOf(obj types.Object) gentypes.TypeRef {
+	return gentypes.TypeRef{
+		Name: obj.Name(),
+		Package: gentypes.PackageRef{
+			Name: obj.Pkg().Name(),
+			Path: obj.Pkg().Path(),
+		},
+	}
+}
+
+// SchemaName returns the name of the schema component describing a type.
+func SchemaName(ref gentypes.TypeRef) string {
+	return ref.Name
+}
+
+// SchemaRef returns a reference to the schema component describing a type.
+func SchemaRef(ref gentypes.TypeRef) string {
+	return "#/components/schemas/" + SchemaName(ref)
+}
+
+// ParseEventSchemas parses the payload schemas of events dispatched by an event dispatcher interface.
+func ParseEventSchemas(obj types.Object, schemas Schemas) error {
+	iface, ok := obj.Type().Underlying().(*types.Interface)
+	if !ok {
+		return fmt.Errorf("%q is not an interface", obj.Name())
+	}
+
+	for i := 0; i < iface.NumMethods(); i++ {
+		params := iface.Method(i).Type().(*types.Signature).Params()
+		if params.Len() == 0 {
+			continue
+		}
+
+		_, err := ParseSchema(params.At(params.Len()-1).Type(), schemas)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,98 @@
+package asyncapi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/tools/go/packages"
+
+	"sagikazarmark.dev/mga/pkg/gentypes"
+)
+
+func parserTypeRef(name string) gentypes.TypeRef {
+	return gentypes.TypeRef{
+		Name: name,
+		Package: gentypes.PackageRef{
+			Name: "parser",
+			Path: "sagikazarmark.dev/mga/internal/generate/event/asyncapi/testdata/parser",
+		},
+	}
+}
+
+func TestParseSchema(t *testing.T) {
+	cfg := &packages.Config{
+		Mode: packages.NeedName | packages.NeedTypes | packages.NeedDeps | packages.NeedImports,
+	}
+
+	pkgs, err := packages.Load(cfg, "./testdata/parser")
+	require.NoError(t, err)
+
+	obj := pkgs[0].Types.Scope().Lookup("Event")
+	require.NotNil(t, obj)
+
+	schemas := Schemas{}
+
+	schema, err := ParseSchema(obj.Type(), schemas)
+	require.NoError(t, err)
+
+	assert.Equal(t, &Schema{Ref: "#/components/schemas/Event"}, schema)
+
+	expected := Schemas{
+		"Event": {
+			Type: "object",
+			Properties: map[string]*Schema{
+				"correlationId": {Type: "string"},
+				"id":            {Type: "string"},
+				"status":        {Type: "string"},
+				"items": {
+					Type:  "array",
+					Items: &Schema{Ref: "#/components/schemas/Item"},
+				},
+				"labels": {
+					Type:                 "object",
+					AdditionalProperties: &Schema{Type: "string"},
+				},
+				"payload":    {Type: "string", Format: "byte"},
+				"occurredAt": {Type: "string", Format: "date-time"},
+				"parent":     {Ref: "#/components/schemas/Event"},
+				"Ratio":      {Type: "number", Format: "double"},
+			},
+			Required: []string{"id", "status", "items", "payload", "occurredAt", "Ratio"},
+			typ:      parserTypeRef("Event"),
+		},
+		"Item": {
+			Type: "object",
+			Properties: map[string]*Schema{
+				"name":     {Type: "string"},
+				"quantity": {Type: "integer", Format: "int32"},
+			},
+			Required: []string{"name", "quantity"},
+			typ:      parserTypeRef("Item"),
+		},
+	}
+
+	assert.Equal(t, expected, schemas, "the parsed schemas do not match the expected ones")
+}
+
+func TestParseSchema_NameConflict(t *testing.T) {
+	cfg := &packages.Config{
+		Mode: packages.NeedName | packages.NeedTypes | packages.NeedDeps | packages.NeedImports,
+	}
+
+	pkgs, err := packages.Load(cfg, "./testdata/conflict")
+	require.NoError(t, err)
+
+	obj := pkgs[0].Types.Scope().Lookup("Event")
+	require.NotNil(t, obj)
+
+	_, err = ParseSchema(obj.Type(), Schemas{})
+	require.Error(t, err)
+
+	assert.Equal(
+		t,
+		`schema name "Item" of sagikazarmark.dev/mga/internal/generate/event/asyncapi/testdata/conflict/other.Item `+
+			`conflicts with sagikazarmark.dev/mga/internal/generate/event/asyncapi/testdata/parser.Item: rename one of the types`,
+		err.Error(),
+	)
+}
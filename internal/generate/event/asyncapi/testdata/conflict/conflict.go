@@ -0,0 +1,12 @@
+package conflict
+
+import (
+	"sagikazarmark.dev/mga/internal/generate/event/asyncapi/testdata/conflict/other"
+	"sagikazarmark.dev/mga/internal/generate/event/asyncapi/testdata/parser"
+)
+
+// Event refers to types with the same name from different packages.
+type Event struct {
+	Item      parser.Item `json:"item"`
+	OtherItem other.Item  `json:"otherItem"`
+}
@@ -0,0 +1,6 @@
+package other
+
+// Item has the same name as parser.Item.
+type Item struct {
+	ID string `json:"id"`
+}
@@ -0,0 +1,36 @@
+package parser
+
+import (
+	"time"
+)
+
+// Metadata is embedded in events.
+type Metadata struct {
+	CorrelationID string `json:"correlationId,omitempty"`
+}
+
+// Item is a nested struct.
+type Item struct {
+	Name     string `json:"name"`
+	Quantity int32  `json:"quantity"`
+}
+
+// Status is a named basic type.
+type Status string
+
+// Event is something that happened at a given point in time.
+type Event struct {
+	Metadata
+
+	ID         string            `json:"id"`
+	Status     Status            `json:"status"`
+	Items      []Item            `json:"items"`
+	Labels     map[string]string `json:"labels,omitempty"`
+	Payload    []byte            `json:"payload"`
+	OccurredAt time.Time         `json:"occurredAt"`
+	Parent     *Event            `json:"parent,omitempty"`
+	Ratio      float64
+	Ignored    string `json:"-"`
+
+	internal string
+}
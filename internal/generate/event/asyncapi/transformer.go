@@ -0,0 +1,70 @@
+package asyncapi
+
+import (
+	"sagikazarmark.dev/mga/internal/generate/event/dispatcher"
+	"sagikazarmark.dev/mga/internal/generate/event/handler"
+	"sagikazarmark.dev/mga/pkg/gentypes"
+)
+
+// TopicStrategy decides how channels (topics) are named after events.
+type TopicStrategy string
+
+// Supported topic strategies.
+const (
+	// TopicStrategyStruct names topics after the event struct (same as cqrs.StructName in Watermill).
+	TopicStrategyStruct TopicStrategy = "struct"
+
+	// TopicStrategyQualified names topics after the package qualified event struct
+	// (same as cqrs.FullyQualifiedStructName in Watermill).
+	TopicStrategyQualified TopicStrategy = "qualified"
+)
+
+// Topic returns the name of the topic where an event is published.
+func (s TopicStrategy) Topic(event gentypes.TypeRef) string {
+	if s == TopicStrategyQualified {
+		return event.Package.Name + "." + event.Name
+	}
+
+	return event.Name
+}
+
+// SendOperationsFromEvents creates send operations from an event dispatcher interface.
+// nolint: golint
+func SendOperationsFromEvents(events dispatcher.Events, docs map[string]string) []Operation {
+	operations := make([]Operation, 0, len(events.Methods))
+
+	for _, method := range events.Methods {
+		operations = append(operations, Operation{
+			ID:          events.Name + "." + method.Name,
+			Action:      ActionSend,
+			Description: docs[method.Name],
+			Message: Message{
+				Event: method.Event,
+			},
+		})
+	}
+
+	return operations
+}
+
+// ReceiveOperationFromEvent creates a receive operation from an event.
+//
+// Events handled by a group handler are received by the handler named after the group.
+// nolint: golint
+func ReceiveOperationFromEvent(event handler.Event, group string, doc string) Operation {
+	eventHandler := handler.EventHandlerFromEvent(event)
+
+	handlerName := eventHandler.Name
+	if group != "" {
+		handlerName = group
+	}
+
+	return Operation{
+		ID:     handlerName + "Handler." + eventHandler.Name,
+		Action: ActionReceive,
+		Message: Message{
+			Event:       event,
+			Description: doc,
+		},
+	}
+}
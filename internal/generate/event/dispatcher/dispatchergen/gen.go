@@ -4,45 +4,60 @@ import (
 	"fmt"
 	"go/ast"
 	"go/types"
-	"io"
-	"strings"
 
 	"sigs.k8s.io/controller-tools/pkg/genall"
 	"sigs.k8s.io/controller-tools/pkg/loader"
 	"sigs.k8s.io/controller-tools/pkg/markers"
 
 	"sagikazarmark.dev/mga/internal/generate/event/dispatcher"
+	"sagikazarmark.dev/mga/pkg/generator"
 	"sagikazarmark.dev/mga/pkg/gentypes"
 	"sagikazarmark.dev/mga/pkg/genutils"
 )
 
 // nolint: gochecknoglobals
 var (
-	dispatcherMarker = markers.Must(markers.MakeDefinition("mga:event:dispatcher", markers.DescribesType, struct{}{}))
+	// DispatcherMarker enables event dispatcher generation for an interface.
+	DispatcherMarker = markers.Must(markers.MakeDefinition("mga:event:dispatcher", markers.DescribesType, Marker{}))
 )
 
-// Generator generates a Go kit Endpoint for a service.
-type Generator struct {
-	// HeaderFile specifies the header text (e.g. license) to prepend to generated files.
-	HeaderFile string `marker:",optional"`
+// +controllertools:marker:generateHelp:category=Event
+
+// Marker enables generating an event dispatcher for an interface.
+//
+// Every method of the interface dispatches an event (the last argument of the method).
+type Marker struct {
+	// Recorder tells the generator to write an in-memory RecordingEventBus in a test file
+	// with typed accessors for the events dispatched by the interface.
+	Recorder bool `marker:"recorder,optional"`
+
+	// Instrumented tells the generator to write an InstrumentedEventBus decorator
+	// recording metrics and traces (using OpenTelemetry) for the events dispatched by the interface.
+	Instrumented bool `marker:"instrumented,optional"`
+}
 
-	// Year specifies the year to substitute for " YEAR" in the header file.
-	Year string `marker:",optional"`
+// Generator generates event dispatchers for event dispatcher interfaces.
+type Generator struct {
+	generator.Base
 }
 
 func (g Generator) RegisterMarkers(into *markers.Registry) error {
-	if err := into.Register(dispatcherMarker); err != nil {
+	if err := into.Register(DispatcherMarker); err != nil {
 		return err
 	}
 
-	into.AddHelp(
-		dispatcherMarker,
-		markers.SimpleHelp("Kit", "enables event dispatcher generation for events"),
-	)
+	into.AddHelp(DispatcherMarker, Marker{}.Help())
 
 	return nil
 }
 
+// MarkerKinds restricts markers to the kinds of declarations the generator handles.
+func (Generator) MarkerKinds() map[string]string {
+	return map[string]string{
+		DispatcherMarker.Name: genutils.InterfaceKind,
+	}
+}
+
 func (Generator) CheckFilter() loader.NodeFilter {
 	return func(node ast.Node) bool {
 		// ignore non-interfaces
@@ -52,41 +67,35 @@ func (Generator) CheckFilter() loader.NodeFilter {
 	}
 }
 
-func (g Generator) Generate(ctx *genall.GenerationContext) error {
-	var headerText string
-
-	if g.HeaderFile != "" {
-		headerBytes, err := ctx.ReadFile(g.HeaderFile)
-		if err != nil {
-			return err
-		}
+// OutputFiles returns the names of the files written by the generator.
+func (g Generator) OutputFiles() []string {
+	return g.OutputFilePatterns("event_dispatcher", "event_dispatcher_test")
+}
 
-		headerText = string(headerBytes)
+func (g Generator) Generate(ctx *genall.GenerationContext) error {
+	headerText, err := g.HeaderText(ctx)
+	if err != nil {
+		return err
 	}
 
-	headerText = strings.ReplaceAll(headerText, " YEAR", " "+g.Year)
-
 	for _, root := range ctx.Roots {
-		outContents := g.generatePackage(ctx, headerText, root)
-		if outContents == nil {
-			continue
-		}
-
-		writeOut(ctx, root, outContents)
+		g.generatePackage(ctx, headerText, root)
 	}
 
 	return nil
 }
 
-func (g Generator) generatePackage(ctx *genall.GenerationContext, headerText string, root *loader.Package) []byte {
+func (g Generator) generatePackage(ctx *genall.GenerationContext, headerText string, root *loader.Package) {
 	ctx.Checker.Check(root)
 
 	root.NeedTypesInfo()
 
 	var eventDispatchers []dispatcher.EventDispatcher
+	var recordedEventDispatchers []dispatcher.EventDispatcher
 
 	err := markers.EachType(ctx.Collector, root, func(info *markers.TypeInfo) {
-		if marker := info.Markers.Get(dispatcherMarker.Name); marker == nil {
+		marker, ok := info.Markers.Get(DispatcherMarker.Name).(Marker)
+		if !ok {
 			return
 		}
 
@@ -104,29 +113,30 @@ func (g Generator) generatePackage(ctx *genall.GenerationContext, headerText str
 			return
 		}
 
-		eventDispatchers = append(eventDispatchers, dispatcher.EventDispatcherFromEvents(events))
+		eventDispatcher := dispatcher.EventDispatcherFromEvents(events)
+		eventDispatcher.Instrumented = marker.Instrumented
+
+		eventDispatchers = append(eventDispatchers, eventDispatcher)
+
+		if marker.Recorder {
+			recordedEventDispatchers = append(recordedEventDispatchers, eventDispatcher)
+		}
 	})
 	if err != nil {
 		root.AddError(err)
 
-		return nil
+		return
 	}
 
 	if len(eventDispatchers) == 0 {
-		return nil
+		return
 	}
 
-	packageName, packagePath := root.Name, root.PkgPath
-	if pkgrefer, ok := ctx.OutputRule.(genutils.PackageRefer); ok {
-		packageName, packagePath = pkgrefer.PackageRef(root)
-	}
+	outputPackage := generator.OutputPackage(ctx, root)
 
 	file := dispatcher.File{
 		File: gentypes.File{
-			Package: gentypes.PackageRef{
-				Name: packageName,
-				Path: packagePath,
-			},
+			Package:    outputPackage,
 			HeaderText: headerText,
 		},
 		EventDispatchers: eventDispatchers,
@@ -136,28 +146,35 @@ func (g Generator) generatePackage(ctx *genall.GenerationContext, headerText str
 	if err != nil {
 		root.AddError(err)
 
-		return nil
+		return
 	}
 
-	return outContents
-}
-
-// writeOut outputs the given code.
-func writeOut(ctx *genall.GenerationContext, root *loader.Package, outBytes []byte) {
-	outputFile, err := ctx.Open(root, "zz_generated.event_dispatcher.go")
+	fileName, err := g.OutputFileName("event_dispatcher", "")
 	if err != nil {
 		root.AddError(err)
 
 		return
 	}
-	defer outputFile.Close()
-	n, err := outputFile.Write(outBytes)
-	if err != nil {
-		root.AddError(err)
 
-		return
-	}
-	if n < len(outBytes) {
-		root.AddError(io.ErrShortWrite)
+	generator.WriteFile(ctx, root, fileName, outContents)
+
+	if len(recordedEventDispatchers) > 0 {
+		file.EventDispatchers = recordedEventDispatchers
+
+		outContents, err := dispatcher.GenerateRecorder(file)
+		if err != nil {
+			root.AddError(err)
+
+			return
+		}
+
+		fileName, err := g.OutputFileName("event_dispatcher_test", "")
+		if err != nil {
+			root.AddError(err)
+
+			return
+		}
+
+		generator.WriteFile(ctx, root, fileName, outContents)
 	}
 }
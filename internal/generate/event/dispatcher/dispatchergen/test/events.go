@@ -10,7 +10,7 @@ type Event struct {
 
 //go:generate go run sagikazarmark.dev/mga generate event dispatcher
 
-// +mga:event:dispatcher
+// +mga:event:dispatcher:recorder=true,instrumented=true
 type Events interface {
 	// Event dispatches an Event event.
 	Event(event Event)
@@ -23,4 +23,10 @@ type Events interface {
 
 	// EventWithError returns an error when something goes wrong during dispatching the event.
 	EventWithError(event Event) error
+
+	// Events dispatches multiple events at once.
+	Events(events []Event)
+
+	// EventsWithContextAndError dispatches multiple events at once and returns an error when something goes wrong.
+	EventsWithContextAndError(ctx context.Context, events []Event) error
 }
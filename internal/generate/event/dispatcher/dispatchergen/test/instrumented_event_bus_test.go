@@ -0,0 +1,218 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"emperror.dev/errors"
+	"github.com/ThreeDotsLabs/watermill/components/cqrs"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type metadataEventBus struct {
+	event    interface{}
+	metadata map[string]string
+}
+
+func (b *metadataEventBus) Publish(_ context.Context, event interface{}) error {
+	b.event = event
+
+	return nil
+}
+
+func (b *metadataEventBus) PublishWithMetadata(_ context.Context, event interface{}, metadata map[string]string) error {
+	b.event = event
+	b.metadata = metadata
+
+	return nil
+}
+
+func setUpInstrumentedEventBus(t *testing.T, bus EventBus) (InstrumentedEventBus, *tracetest.SpanRecorder, *sdkmetric.ManualReader) {
+	spans := tracetest.NewSpanRecorder()
+	metrics := sdkmetric.NewManualReader()
+
+	instrumentedBus, err := NewInstrumentedEventBus(
+		bus,
+		sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)),
+		sdkmetric.NewMeterProvider(sdkmetric.WithReader(metrics)),
+		propagation.TraceContext{},
+	)
+	require.NoError(t, err)
+
+	return instrumentedBus, spans, metrics
+}
+
+func collectSum(t *testing.T, reader *sdkmetric.ManualReader, name string) int64 {
+	var rm metricdata.ResourceMetrics
+
+	err := reader.Collect(context.Background(), &rm)
+	require.NoError(t, err)
+
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name != name {
+				continue
+			}
+
+			var sum int64
+
+			for _, dp := range m.Data.(metricdata.Sum[int64]).DataPoints {
+				sum += dp.Value
+			}
+
+			return sum
+		}
+	}
+
+	return 0
+}
+
+func TestInstrumentedEventBus_Publish(t *testing.T) {
+	bus := &metadataEventBus{}
+	instrumentedBus, spans, metrics := setUpInstrumentedEventBus(t, bus)
+
+	events := NewEventDispatcher(instrumentedBus)
+
+	event := Event{
+		ID: "id",
+	}
+
+	err := events.EventWithContextAndError(context.Background(), event)
+	require.NoError(t, err)
+
+	assert.Equal(t, event, bus.event)
+
+	require.Len(t, spans.Ended(), 1)
+
+	span := spans.Ended()[0]
+
+	assert.Equal(t, "publish Event", span.Name())
+	assert.Equal(t, trace.SpanKindProducer, span.SpanKind())
+
+	spanContext := propagation.TraceContext{}.
+		Extract(context.Background(), propagation.MapCarrier(bus.metadata))
+
+	assert.Equal(t, span.SpanContext().TraceID(), trace.SpanContextFromContext(spanContext).TraceID())
+
+	assert.Equal(t, int64(1), collectSum(t, metrics, "event.published"))
+	assert.Equal(t, int64(0), collectSum(t, metrics, "event.publish.errors"))
+}
+
+func TestInstrumentedEventBus_Publish_Error(t *testing.T) {
+	failure := errors.NewPlain("error")
+	instrumentedBus, spans, metrics := setUpInstrumentedEventBus(t, failingEventBus{err: failure})
+
+	events := NewEventDispatcher(instrumentedBus)
+
+	err := events.EventWithError(Event{ID: "id"})
+
+	assert.Equal(t, failure, errors.Cause(err))
+
+	require.Len(t, spans.Ended(), 1)
+	assert.Equal(t, codes.Error, spans.Ended()[0].Status().Code)
+
+	assert.Equal(t, int64(0), collectSum(t, metrics, "event.published"))
+	assert.Equal(t, int64(1), collectSum(t, metrics, "event.publish.errors"))
+}
+
+type recordingPublisher struct {
+	messages []*message.Message
+}
+
+func (p *recordingPublisher) Publish(_ string, messages ...*message.Message) error {
+	p.messages = append(p.messages, messages...)
+
+	return nil
+}
+
+func (p *recordingPublisher) Close() error {
+	return nil
+}
+
+func TestInstrumentedEventBus_Publish_WatermillEventBus(t *testing.T) {
+	publisher := &recordingPublisher{}
+
+	bus, err := cqrs.NewEventBusWithConfig(publisher, cqrs.EventBusConfig{
+		GeneratePublishTopic: func(params cqrs.GenerateEventPublishTopicParams) (string, error) {
+			return params.EventName, nil
+		},
+		OnPublish: func(params cqrs.OnEventSendParams) error {
+			for key, value := range PublishMetadataFromContext(params.Message.Context()) {
+				params.Message.Metadata.Set(key, value)
+			}
+
+			return nil
+		},
+		Marshaler: cqrs.JSONMarshaler{},
+	})
+	require.NoError(t, err)
+
+	instrumentedBus, spans, _ := setUpInstrumentedEventBus(t, bus)
+
+	events := NewEventDispatcher(instrumentedBus)
+
+	err = events.EventWithContextAndError(context.Background(), Event{ID: "id"})
+	require.NoError(t, err)
+
+	require.Len(t, publisher.messages, 1)
+	require.Len(t, spans.Ended(), 1)
+
+	spanContext := propagation.TraceContext{}.
+		Extract(context.Background(), propagation.MapCarrier(publisher.messages[0].Metadata))
+
+	assert.Equal(t, spans.Ended()[0].SpanContext().TraceID(), trace.SpanContextFromContext(spanContext).TraceID())
+}
+
+func TestInstrumentedEventBus_PublishBatch(t *testing.T) {
+	bus := &batchEventBus{}
+	instrumentedBus, spans, metrics := setUpInstrumentedEventBus(t, bus)
+
+	events := NewEventDispatcher(instrumentedBus)
+
+	err := events.EventsWithContextAndError(context.Background(), []Event{{ID: "1"}, {ID: "2"}})
+	require.NoError(t, err)
+
+	assert.Equal(t, [][]interface{}{{Event{ID: "1"}, Event{ID: "2"}}}, bus.batches)
+
+	require.Len(t, spans.Ended(), 1)
+
+	span := spans.Ended()[0]
+
+	assert.Equal(t, "publish batch", span.Name())
+	assert.Equal(t, trace.SpanKindProducer, span.SpanKind())
+
+	spanContext := propagation.TraceContext{}.
+		Extract(context.Background(), propagation.MapCarrier(bus.metadata))
+
+	assert.Equal(t, span.SpanContext().TraceID(), trace.SpanContextFromContext(spanContext).TraceID())
+
+	assert.Equal(t, int64(2), collectSum(t, metrics, "event.published"))
+	assert.Equal(t, int64(0), collectSum(t, metrics, "event.publish.errors"))
+}
+
+func TestInstrumentedEventBus_PublishBatch_OneByOne(t *testing.T) {
+	bus := &metadataEventBus{}
+	instrumentedBus, spans, metrics := setUpInstrumentedEventBus(t, bus)
+
+	events := NewEventDispatcher(instrumentedBus)
+
+	err := events.EventsWithContextAndError(context.Background(), []Event{{ID: "1"}, {ID: "2"}})
+	require.NoError(t, err)
+
+	assert.Equal(t, Event{ID: "2"}, bus.event)
+	assert.NotEmpty(t, bus.metadata)
+
+	require.Len(t, spans.Ended(), 2)
+	assert.Equal(t, "publish Event", spans.Ended()[0].Name())
+
+	assert.Equal(t, int64(2), collectSum(t, metrics, "event.published"))
+}
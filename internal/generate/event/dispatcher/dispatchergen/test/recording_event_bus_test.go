@@ -0,0 +1,53 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"emperror.dev/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordingEventBus(t *testing.T) {
+	bus := NewRecordingEventBus()
+
+	events := NewEventDispatcher(bus)
+
+	event1 := Event{ID: "1"}
+	event2 := Event{ID: "2"}
+
+	events.Event(event1)
+
+	err := events.EventWithContextAndError(context.Background(), event2)
+	require.NoError(t, err)
+
+	assert.Equal(t, []interface{}{event1, event2}, bus.Published())
+	assert.Equal(t, []Event{event1, event2}, bus.PublishedEvent())
+
+	bus.Reset()
+
+	assert.Empty(t, bus.Published())
+	assert.Empty(t, bus.PublishedEvent())
+}
+
+func TestRecordingEventBus_FailWith(t *testing.T) {
+	bus := NewRecordingEventBus()
+
+	events := NewEventDispatcher(bus)
+
+	failure := errors.NewPlain("error")
+
+	bus.FailWith(failure, nil)
+
+	err := events.EventWithError(Event{ID: "1"})
+	assert.Equal(t, failure, errors.Cause(err))
+
+	err = events.EventWithError(Event{ID: "2"})
+	require.NoError(t, err)
+
+	err = events.EventWithError(Event{ID: "3"})
+	require.NoError(t, err)
+
+	assert.Equal(t, []Event{{ID: "2"}, {ID: "3"}}, bus.PublishedEvent())
+}
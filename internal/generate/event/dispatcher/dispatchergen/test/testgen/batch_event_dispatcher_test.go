@@ -0,0 +1,62 @@
+package testgen
+
+import (
+	"context"
+	"testing"
+
+	"emperror.dev/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type batchEventBus struct {
+	failingEventBus
+
+	batches  [][]interface{}
+	metadata map[string]string
+}
+
+func (b *batchEventBus) PublishBatch(ctx context.Context, events []interface{}) error {
+	b.batches = append(b.batches, events)
+	b.metadata = PublishMetadataFromContext(ctx)
+
+	return nil
+}
+
+func TestEventDispatcher_Events(t *testing.T) {
+	bus := NewRecordingEventBus()
+
+	events := NewEventDispatcher(bus)
+
+	events.Events([]Event{{ID: "1"}, {ID: "2"}})
+
+	assert.Equal(t, []Event{{ID: "1"}, {ID: "2"}}, bus.PublishedEvent())
+}
+
+func TestEventDispatcher_EventsWithContextAndError_BatchEventBus(t *testing.T) {
+	bus := &batchEventBus{}
+
+	events := NewEventDispatcher(bus)
+
+	err := events.EventsWithContextAndError(context.Background(), []Event{{ID: "1"}, {ID: "2"}})
+	require.NoError(t, err)
+
+	assert.Equal(t, [][]interface{}{{Event{ID: "1"}, Event{ID: "2"}}}, bus.batches)
+}
+
+func TestEventDispatcher_EventsWithContextAndError_Error(t *testing.T) {
+	bus := NewRecordingEventBus()
+	bus.FailWith(nil, errors.NewPlain("error"), errors.NewPlain("another error"))
+
+	events := NewEventDispatcher(bus)
+
+	err := events.EventsWithContextAndError(context.Background(), []Event{{ID: "1"}, {ID: "2"}, {ID: "3"}})
+	require.Error(t, err)
+
+	errs := errors.GetErrors(errors.Cause(err))
+	require.Len(t, errs, 2)
+
+	assert.Subset(t, errors.GetDetails(errs[0]), []interface{}{"index", 1})
+	assert.Subset(t, errors.GetDetails(errs[1]), []interface{}{"index", 2})
+	assert.Equal(t, []Event{{ID: "1"}}, bus.PublishedEvent())
+}
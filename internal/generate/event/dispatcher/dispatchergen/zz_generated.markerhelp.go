@@ -0,0 +1,31 @@
+//go:build !ignore_autogenerated
+
+// Marker documentation shown by the markers command.
+
+// Code generated by helpgen. DO NOT EDIT.
+
+package dispatchergen
+
+import (
+	"sigs.k8s.io/controller-tools/pkg/markers"
+)
+
+func (Marker) Help() *markers.DefinitionHelp {
+	return &markers.DefinitionHelp{
+		Category: "Event",
+		DetailedHelp: markers.DetailedHelp{
+			Summary: "enables generating an event dispatcher for an interface.",
+			Details: "Every method of the interface dispatches an event (the last argument of the method).",
+		},
+		FieldHelp: map[string]markers.DetailedHelp{
+			"Recorder": {
+				Summary: "tells the generator to write an in-memory RecordingEventBus in a test file",
+				Details: "with typed accessors for the events dispatched by the interface.",
+			},
+			"Instrumented": {
+				Summary: "tells the generator to write an InstrumentedEventBus decorator",
+				Details: "recording metrics and traces (using OpenTelemetry) for the events dispatched by the interface.",
+			},
+		},
+	}
+}
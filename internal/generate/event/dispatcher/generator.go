@@ -21,6 +21,9 @@ type File struct {
 type EventDispatcher struct {
 	Name              string
 	DispatcherMethods []EventMethod
+
+	// Instrumented tells the generator to record metrics and traces for events dispatched by the dispatcher.
+	Instrumented bool
 }
 
 // Generate generates an event dispatcher.
@@ -40,8 +43,23 @@ func Generate(file File) ([]byte, error) {
 	const eventBusTypeName = "EventBus"
 	generateEventBus(code, eventBusTypeName)
 
+	batch := hasBatchMethods(file.EventDispatchers)
+	if batch {
+		generateBatchEventBus(code, eventBusTypeName)
+	}
+
+	var instrumentedEventDispatchers []EventDispatcher
+
 	for _, eventDispatcher := range file.EventDispatchers {
 		generateEventDispatcher(code, eventDispatcher)
+
+		if eventDispatcher.Instrumented {
+			instrumentedEventDispatchers = append(instrumentedEventDispatchers, eventDispatcher)
+		}
+	}
+
+	if len(instrumentedEventDispatchers) > 0 {
+		generateInstrumentedEventBus(code, file.Package.Path, recordedEvents(instrumentedEventDispatchers), batch)
 	}
 
 	var buf bytes.Buffer
@@ -95,6 +113,12 @@ func generateEventDispatcher(code *jen.File, eventDispatcher EventDispatcher) {
 	for _, method := range eventDispatcher.DispatcherMethods {
 		code.ImportName(method.Event.Package.Path, method.Event.Package.Name)
 
+		if method.Batch {
+			generateBatchEventDispatcherMethod(code, eventDispatcherTypeName, method)
+
+			continue
+		}
+
 		var params []jen.Code
 
 		if method.ReceivesContext {
@@ -149,3 +173,154 @@ func generateEventDispatcher(code *jen.File, eventDispatcher EventDispatcher) {
 		fn.Block(block...).Line()
 	}
 }
+
+func hasBatchMethods(eventDispatchers []EventDispatcher) bool {
+	for _, eventDispatcher := range eventDispatchers {
+		for _, method := range eventDispatcher.DispatcherMethods {
+			if method.Batch {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+const batchEventBusTypeName = "BatchEventBus"
+
+func generateBatchEventBus(code *jen.File, eventBusTypeName string) {
+	code.Commentf("%s is implemented by event buses that can publish multiple events at once.", batchEventBusTypeName)
+	code.Comment("")
+	code.Commentf(
+		"Event dispatchers fall back to publishing events one by one when the %s does not implement it.",
+		eventBusTypeName,
+	)
+	code.Type().Id(batchEventBusTypeName).Interface(
+		jen.Comment("PublishBatch sends multiple events to the underlying message bus."),
+		jen.Id("PublishBatch").Params(
+			jen.Id("ctx").Qual("context", "Context"),
+			jen.Id("events").Index().Interface(),
+		).Error(),
+	).Line()
+
+	code.Commentf("publishBatch publishes multiple events through a(n) %s.", eventBusTypeName)
+	code.Comment("")
+	code.Commentf(
+		"When the %s is not a(n) %s, events are published one by one and errors are aggregated.",
+		eventBusTypeName,
+		batchEventBusTypeName,
+	)
+	code.Func().
+		Id("publishBatch").
+		Params(
+			jen.Id("ctx").Qual("context", "Context"),
+			jen.Id("bus").Id(eventBusTypeName),
+			jen.Id("events").Index().Interface(),
+		).
+		Error().
+		Block(
+			jen.If(
+				jen.List(jen.Id("batchBus"), jen.Id("ok")).Op(":=").Id("bus").Assert(jen.Id(batchEventBusTypeName)),
+				jen.Id("ok"),
+			).Block(
+				jen.Return(jen.Id("batchBus").Dot("PublishBatch").Call(jen.Id("ctx"), jen.Id("events"))),
+			),
+			jen.Line(),
+			jen.Return(jen.Id("publishEach").Call(jen.Id("ctx"), jen.Id("bus"), jen.Id("events"))),
+		).
+		Line()
+
+	code.Commentf("publishEach publishes multiple events one by one through a(n) %s and aggregates errors.", eventBusTypeName)
+	code.Func().
+		Id("publishEach").
+		Params(
+			jen.Id("ctx").Qual("context", "Context"),
+			jen.Id("bus").Id(eventBusTypeName),
+			jen.Id("events").Index().Interface(),
+		).
+		Error().
+		Block(
+			jen.Var().Id("errs").Index().Error(),
+			jen.Line(),
+			jen.For(jen.List(jen.Id("i"), jen.Id("event")).Op(":=").Range().Id("events")).Block(
+				jen.Err().Op(":=").Id("bus").Dot("Publish").Call(jen.Id("ctx"), jen.Id("event")),
+				jen.If(jen.Err().Op("!=").Nil()).Block(
+					jen.Id("errs").Op("=").Append(
+						jen.Id("errs"),
+						jen.Qual("emperror.dev/errors", "WithDetails").Call(jen.Err(), jen.Lit("index"), jen.Id("i")),
+					),
+				),
+			),
+			jen.Line(),
+			jen.Return(jen.Qual("emperror.dev/errors", "Combine").Call(jen.Id("errs").Op("..."))),
+		).
+		Line()
+}
+
+func generateBatchEventDispatcherMethod(code *jen.File, eventDispatcherTypeName string, method EventMethod) {
+	const eventBusVarName = "bus"
+
+	var params []jen.Code
+
+	if method.ReceivesContext {
+		params = append(params, jen.Id("ctx").Qual("context", "Context"))
+	}
+
+	params = append(params, jen.Id("events").Index().Qual(method.Event.Package.Path, method.Event.Name))
+
+	code.Commentf("%s dispatches %s events.", method.Name, method.Event.Name)
+	fn := code.Func().Params(
+		jen.Id("d").Id(eventDispatcherTypeName),
+	).Id(method.Name).Params(params...)
+
+	if method.ReturnsError {
+		fn = fn.Error()
+	}
+
+	var block []jen.Code
+
+	if !method.ReceivesContext {
+		block = append(block, jen.Id("ctx").Op(":=").Qual("context", "Background").Call())
+	}
+
+	block = append(
+		block,
+		jen.Id("batch").Op(":=").Make(jen.Index().Interface(), jen.Lit(0), jen.Len(jen.Id("events"))),
+		jen.For(jen.List(jen.Id("_"), jen.Id("event")).Op(":=").Range().Id("events")).Block(
+			jen.Id("batch").Op("=").Append(jen.Id("batch"), jen.Id("event")),
+		),
+		jen.Line(),
+	)
+
+	if method.ReturnsError {
+		block = append(
+			block,
+			jen.Err().Op(":=").Id("publishBatch").Call(
+				jen.Id("ctx"),
+				jen.Id("d").Dot(eventBusVarName),
+				jen.Id("batch"),
+			),
+			jen.If(
+				jen.Err().Op("!=").Nil(),
+			).Block(
+				jen.Return(jen.Qual("emperror.dev/errors", "WithDetails").Call(
+					jen.Qual("emperror.dev/errors", "WithMessage").Call(
+						jen.Err(),
+						jen.Lit("failed to dispatch events"),
+					),
+					jen.Lit("event"), jen.Lit(method.Event.Name),
+				)),
+			),
+			jen.Line(),
+			jen.Return(jen.Nil()),
+		)
+	} else {
+		block = append(block, jen.Id("_").Op("=").Id("publishBatch").Call(
+			jen.Id("ctx"),
+			jen.Id("d").Dot(eventBusVarName),
+			jen.Id("batch"),
+		))
+	}
+
+	fn.Block(block...).Line()
+}
@@ -124,3 +124,119 @@ func (d EventDispatcher) MarkedAsDone3(ctx context.Context, event pkg.MarkedAsDo
 
 	assert.Equal(t, expected, string(actual), "the generated code does not match the expected one")
 }
+
+func TestGenerate_Batch(t *testing.T) {
+	file := File{
+		File: gentypes.File{
+			Package: gentypes.PackageRef{
+				Name: "pkggen",
+				Path: "app.dev/pkg/pkggen",
+			},
+		},
+		EventDispatchers: []EventDispatcher{
+			{
+				Name: "Todo",
+				DispatcherMethods: []EventMethod{
+					{
+						Name: "TodosImported",
+						Event: gentypes.TypeRef{
+							Name: "TodoImported",
+							Package: gentypes.PackageRef{
+								Name: "pkg",
+								Path: "app.dev/pkg",
+							},
+						},
+						ReceivesContext: true,
+						ReturnsError:    true,
+						Batch:           true,
+					},
+				},
+			},
+		},
+	}
+
+	expected := `//go:build !ignore_autogenerated
+// +build !ignore_autogenerated
+
+// Code generated by mga tool. DO NOT EDIT.
+
+package pkggen
+
+import (
+	"app.dev/pkg"
+	"context"
+	"emperror.dev/errors"
+)
+
+// EventBus is a generic event bus.
+type EventBus interface {
+	// Publish sends an event to the underlying message bus.
+	Publish(ctx context.Context, event interface{}) error
+}
+
+// BatchEventBus is implemented by event buses that can publish multiple events at once.
+//
+// Event dispatchers fall back to publishing events one by one when the EventBus does not implement it.
+type BatchEventBus interface {
+	// PublishBatch sends multiple events to the underlying message bus.
+	PublishBatch(ctx context.Context, events []interface{}) error
+}
+
+// publishBatch publishes multiple events through a(n) EventBus.
+//
+// When the EventBus is not a(n) BatchEventBus, events are published one by one and errors are aggregated.
+func publishBatch(ctx context.Context, bus EventBus, events []interface{}) error {
+	if batchBus, ok := bus.(BatchEventBus); ok {
+		return batchBus.PublishBatch(ctx, events)
+	}
+
+	return publishEach(ctx, bus, events)
+}
+
+// publishEach publishes multiple events one by one through a(n) EventBus and aggregates errors.
+func publishEach(ctx context.Context, bus EventBus, events []interface{}) error {
+	var errs []error
+
+	for i, event := range events {
+		err := bus.Publish(ctx, event)
+		if err != nil {
+			errs = append(errs, errors.WithDetails(err, "index", i))
+		}
+	}
+
+	return errors.Combine(errs...)
+}
+
+// TodoEventDispatcher dispatches events through the underlying generic event bus.
+type TodoEventDispatcher struct {
+	bus EventBus
+}
+
+// NewTodoEventDispatcher returns a new TodoEventDispatcher instance.
+func NewTodoEventDispatcher(bus EventBus) TodoEventDispatcher {
+	return TodoEventDispatcher{bus: bus}
+}
+
+// TodosImported dispatches TodoImported events.
+func (d TodoEventDispatcher) TodosImported(ctx context.Context, events []pkg.TodoImported) error {
+	batch := make([]interface{}, 0, len(events))
+	for _, event := range events {
+		batch = append(batch, event)
+	}
+
+	err := publishBatch(ctx, d.bus, batch)
+	if err != nil {
+		return errors.WithDetails(errors.WithMessage(err, "failed to dispatch events"), "event", "TodoImported")
+	}
+
+	return nil
+}
+`
+
+	actual, err := Generate(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, expected, string(actual), "the generated code does not match the expected one")
+}
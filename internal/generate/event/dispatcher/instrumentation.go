@@ -0,0 +1,337 @@
+package dispatcher
+
+import (
+	"github.com/dave/jennifer/jen"
+
+	"sagikazarmark.dev/mga/internal/generate/event/otelgen"
+)
+
+// generatePublishMetadataContext generates functions passing event metadata (eg. trace context) to event buses in the context.
+func generatePublishMetadataContext(code *jen.File) {
+	const publishMetadataContextKeyName = "publishMetadataContextKey"
+
+	code.Type().Id(publishMetadataContextKeyName).Struct().Line()
+
+	code.Comment("ContextWithPublishMetadata returns a new context carrying metadata (eg. headers) of the message an event is published in.")
+	code.Func().
+		Id("ContextWithPublishMetadata").
+		Params(
+			jen.Id("ctx").Qual("context", "Context"),
+			jen.Id("metadata").Map(jen.String()).String(),
+		).
+		Qual("context", "Context").
+		Block(
+			jen.Return(jen.Qual("context", "WithValue").Call(
+				jen.Id("ctx"),
+				jen.Id(publishMetadataContextKeyName).Values(),
+				jen.Id("metadata"),
+			)),
+		).
+		Line()
+
+	code.Comment("PublishMetadataFromContext returns the metadata of the message an event is published in (if any).")
+	code.Comment("")
+	code.Comment("The instrumented event bus injects trace context into it.")
+	code.Func().
+		Id("PublishMetadataFromContext").
+		Params(jen.Id("ctx").Qual("context", "Context")).
+		Map(jen.String()).String().
+		Block(
+			jen.List(jen.Id("metadata"), jen.Id("_")).Op(":=").Id("ctx").Dot("Value").Call(
+				jen.Id(publishMetadataContextKeyName).Values(),
+			).Assert(jen.Map(jen.String()).String()),
+			jen.Line(),
+			jen.Return(jen.Id("metadata")),
+		).
+		Line()
+}
+
+// generateInstrumentedEventBus generates an event bus decorator recording metrics and traces for published events.
+//
+// When batch is true, the decorator implements the batch event bus interface as well.
+func generateInstrumentedEventBus(code *jen.File, instrumentationName string, events []recordedEvent, batch bool) {
+	const (
+		metadataEventBusTypeName     = "MetadataEventBus"
+		instrumentedEventBusTypeName = "InstrumentedEventBus"
+		eventBusTypeName             = "EventBus"
+		recv                         = "b"
+	)
+
+	otelgen.ImportNames(code)
+
+	generatePublishMetadataContext(code)
+
+	code.Commentf(
+		"%s is implemented by event buses that can publish events with metadata (eg. as message headers).",
+		metadataEventBusTypeName,
+	)
+	code.Comment("")
+	code.Commentf(
+		"%s passes event metadata (including trace context) to the underlying event bus when it implements it.",
+		instrumentedEventBusTypeName,
+	)
+	code.Type().Id(metadataEventBusTypeName).Interface(
+		jen.Comment("PublishWithMetadata sends an event with metadata to the underlying message bus."),
+		jen.Id("PublishWithMetadata").Params(
+			jen.Id("ctx").Qual("context", "Context"),
+			jen.Id("event").Interface(),
+			jen.Id("metadata").Map(jen.String()).String(),
+		).Error(),
+	).Line()
+
+	code.Commentf(
+		"%s records metrics and traces for events published through the underlying generic event bus.",
+		instrumentedEventBusTypeName,
+	)
+	code.Comment("")
+	code.Comment("Trace context is injected into the metadata passed in the context of the underlying event bus")
+	code.Comment("(see PublishMetadataFromContext). Event buses can set it on the published message, eg. in the OnPublish")
+	code.Comment("hook of a Watermill cqrs.EventBus:")
+	code.Comment("")
+	code.Comment("\tOnPublish: func(params cqrs.OnEventSendParams) error {")
+	code.Comment("\t\tfor key, value := range PublishMetadataFromContext(params.Message.Context()) {")
+	code.Comment("\t\t\tparams.Message.Metadata.Set(key, value)")
+	code.Comment("\t\t}")
+	code.Comment("")
+	code.Comment("\t\treturn nil")
+	code.Comment("\t},")
+	code.Type().Id(instrumentedEventBusTypeName).Struct(
+		jen.Id("bus").Id(eventBusTypeName),
+		jen.Id("tracer").Qual("go.opentelemetry.io/otel/trace", "Tracer"),
+		jen.Id("propagator").Qual("go.opentelemetry.io/otel/propagation", "TextMapPropagator"),
+		jen.Line(),
+		jen.Id("published").Qual("go.opentelemetry.io/otel/metric", "Int64Counter"),
+		jen.Id("failures").Qual("go.opentelemetry.io/otel/metric", "Int64Counter"),
+		jen.Id("duration").Qual("go.opentelemetry.io/otel/metric", "Float64Histogram"),
+	).Line()
+
+	code.Commentf("New%s returns a new %s instance.", instrumentedEventBusTypeName, instrumentedEventBusTypeName)
+	code.Func().
+		Id("New"+instrumentedEventBusTypeName).
+		Params(
+			jen.Id("bus").Id(eventBusTypeName),
+			jen.Id("tracerProvider").Qual("go.opentelemetry.io/otel/trace", "TracerProvider"),
+			jen.Id("meterProvider").Qual("go.opentelemetry.io/otel/metric", "MeterProvider"),
+			jen.Id("propagator").Qual("go.opentelemetry.io/otel/propagation", "TextMapPropagator"),
+		).
+		Params(jen.Id(instrumentedEventBusTypeName), jen.Error()).
+		Block(
+			jen.Id("meter").Op(":=").Id("meterProvider").Dot("Meter").Call(jen.Lit(instrumentationName)),
+			jen.Line(),
+			otelgen.Instrument("published", "Int64Counter", "event.published", "Number of published events.", ""),
+			otelgen.InstrumentError(instrumentedEventBusTypeName),
+			jen.Line(),
+			otelgen.Instrument("failures", "Int64Counter", "event.publish.errors", "Number of events that failed to publish.", ""),
+			otelgen.InstrumentError(instrumentedEventBusTypeName),
+			jen.Line(),
+			otelgen.Instrument("duration", "Float64Histogram", "event.publish.duration", "Duration of publishing events.", "s"),
+			otelgen.InstrumentError(instrumentedEventBusTypeName),
+			jen.Line(),
+			jen.Return(
+				jen.Id(instrumentedEventBusTypeName).Values(jen.Dict{
+					jen.Id("bus"):        jen.Id("bus"),
+					jen.Id("tracer"):     jen.Id("tracerProvider").Dot("Tracer").Call(jen.Lit(instrumentationName)),
+					jen.Id("propagator"): jen.Id("propagator"),
+					jen.Id("published"):  jen.Id("published"),
+					jen.Id("failures"):   jen.Id("failures"),
+					jen.Id("duration"):   jen.Id("duration"),
+				}),
+				jen.Nil(),
+			),
+		).
+		Line()
+
+	code.Comment("Publish sends an event to the underlying event bus.")
+	code.Func().
+		Params(jen.Id(recv).Id(instrumentedEventBusTypeName)).
+		Id("Publish").
+		Params(
+			jen.Id("ctx").Qual("context", "Context"),
+			jen.Id("event").Interface(),
+		).
+		Error().
+		Block(
+			jen.Id("name").Op(":=").Id("publishedEventName").Call(jen.Id("event")),
+			jen.Id("attrs").Op(":=").Qual("go.opentelemetry.io/otel/metric", "WithAttributes").Call(
+				jen.Qual("go.opentelemetry.io/otel/attribute", "String").Call(jen.Lit("event.name"), jen.Id("name")),
+			),
+			jen.Line(),
+			jen.List(jen.Id("ctx"), jen.Id("span")).Op(":=").Id(recv).Dot("tracer").Dot("Start").Call(
+				jen.Id("ctx"),
+				jen.Lit("publish ").Op("+").Id("name"),
+				jen.Qual("go.opentelemetry.io/otel/trace", "WithSpanKind").Call(
+					jen.Qual("go.opentelemetry.io/otel/trace", "SpanKindProducer"),
+				),
+				jen.Qual("go.opentelemetry.io/otel/trace", "WithAttributes").Call(
+					jen.Qual("go.opentelemetry.io/otel/attribute", "String").Call(jen.Lit("event.name"), jen.Id("name")),
+				),
+			),
+			jen.Defer().Id("span").Dot("End").Call(),
+			jen.Line(),
+			jen.List(jen.Id("ctx"), jen.Id("metadata")).Op(":=").Id(recv).Dot("injectMetadata").Call(jen.Id("ctx")),
+			jen.Line(),
+			jen.Id("start").Op(":=").Qual("time", "Now").Call(),
+			jen.Line(),
+			jen.Var().Err().Error(),
+			jen.Line(),
+			jen.If(
+				jen.List(jen.Id("bus"), jen.Id("ok")).Op(":=").Id(recv).Dot("bus").Assert(jen.Id(metadataEventBusTypeName)),
+				jen.Id("ok"),
+			).Block(
+				jen.Err().Op("=").Id("bus").Dot("PublishWithMetadata").Call(jen.Id("ctx"), jen.Id("event"), jen.Id("metadata")),
+			).Else().Block(
+				jen.Err().Op("=").Id(recv).Dot("bus").Dot("Publish").Call(jen.Id("ctx"), jen.Id("event")),
+			),
+			jen.Line(),
+			jen.Id(recv).Dot("duration").Dot("Record").Call(
+				jen.Id("ctx"),
+				jen.Qual("time", "Since").Call(jen.Id("start")).Dot("Seconds").Call(),
+				jen.Id("attrs"),
+			),
+			jen.Line(),
+			jen.If(jen.Err().Op("!=").Nil()).Block(
+				jen.Id(recv).Dot("failures").Dot("Add").Call(jen.Id("ctx"), jen.Lit(1), jen.Id("attrs")),
+				jen.Id("span").Dot("RecordError").Call(jen.Err()),
+				jen.Id("span").Dot("SetStatus").Call(
+					jen.Qual("go.opentelemetry.io/otel/codes", "Error"),
+					jen.Err().Dot("Error").Call(),
+				),
+				jen.Line(),
+				jen.Return(jen.Err()),
+			),
+			jen.Line(),
+			jen.Id(recv).Dot("published").Dot("Add").Call(jen.Id("ctx"), jen.Lit(1), jen.Id("attrs")),
+			jen.Line(),
+			jen.Return(jen.Nil()),
+		).
+		Line()
+
+	code.Comment("injectMetadata injects trace context into the metadata passed in the context (see PublishMetadataFromContext).")
+	code.Func().
+		Params(jen.Id(recv).Id(instrumentedEventBusTypeName)).
+		Id("injectMetadata").
+		Params(jen.Id("ctx").Qual("context", "Context")).
+		Params(jen.Qual("context", "Context"), jen.Map(jen.String()).String()).
+		Block(
+			jen.Id("metadata").Op(":=").Qual("go.opentelemetry.io/otel/propagation", "MapCarrier").Values(),
+			jen.Line(),
+			jen.For(jen.List(jen.Id("key"), jen.Id("value")).Op(":=").Range().Id("PublishMetadataFromContext").Call(jen.Id("ctx"))).Block(
+				jen.Id("metadata").Index(jen.Id("key")).Op("=").Id("value"),
+			),
+			jen.Line(),
+			jen.Id(recv).Dot("propagator").Dot("Inject").Call(jen.Id("ctx"), jen.Id("metadata")),
+			jen.Line(),
+			jen.Return(jen.Id("ContextWithPublishMetadata").Call(jen.Id("ctx"), jen.Id("metadata")), jen.Id("metadata")),
+		).
+		Line()
+
+	if batch {
+		generateInstrumentedPublishBatch(code, instrumentedEventBusTypeName, recv)
+	}
+
+	cases := make([]jen.Code, 0, len(events)+1)
+
+	for _, event := range events {
+		cases = append(
+			cases,
+			jen.Case(
+				jen.Qual(event.Event.Package.Path, event.Event.Name),
+				jen.Op("*").Qual(event.Event.Package.Path, event.Event.Name),
+			).Block(
+				jen.Return(jen.Lit(event.Event.Name)),
+			),
+		)
+	}
+
+	cases = append(
+		cases,
+		jen.Default().Block(
+			jen.Return(jen.Qual("fmt", "Sprintf").Call(jen.Lit("%T"), jen.Id("event"))),
+		),
+	)
+
+	code.Comment("publishedEventName returns the name of an event used in metrics and traces.")
+	code.Func().
+		Id("publishedEventName").
+		Params(jen.Id("event").Interface()).
+		String().
+		Block(
+			jen.Switch(jen.Id("event").Assert(jen.Type())).Block(cases...),
+		)
+}
+
+// generateInstrumentedPublishBatch generates a method recording metrics and traces for events published at once.
+func generateInstrumentedPublishBatch(code *jen.File, instrumentedEventBusTypeName string, recv string) {
+	code.Comment("PublishBatch sends multiple events to the underlying event bus.")
+	code.Comment("")
+	code.Commentf(
+		"Events are published (and recorded) one by one when the underlying event bus is not a(n) %s.",
+		batchEventBusTypeName,
+	)
+	code.Commentf(
+		"Events published through a(n) %s share the trace context of the batch (see PublishMetadataFromContext).",
+		batchEventBusTypeName,
+	)
+	code.Func().
+		Params(jen.Id(recv).Id(instrumentedEventBusTypeName)).
+		Id("PublishBatch").
+		Params(
+			jen.Id("ctx").Qual("context", "Context"),
+			jen.Id("events").Index().Interface(),
+		).
+		Error().
+		Block(
+			jen.List(jen.Id("bus"), jen.Id("ok")).Op(":=").Id(recv).Dot("bus").Assert(jen.Id(batchEventBusTypeName)),
+			jen.If(jen.Op("!").Id("ok")).Block(
+				jen.Return(jen.Id("publishEach").Call(jen.Id("ctx"), jen.Id(recv), jen.Id("events"))),
+			),
+			jen.Line(),
+			jen.List(jen.Id("ctx"), jen.Id("span")).Op(":=").Id(recv).Dot("tracer").Dot("Start").Call(
+				jen.Id("ctx"),
+				jen.Lit("publish batch"),
+				jen.Qual("go.opentelemetry.io/otel/trace", "WithSpanKind").Call(
+					jen.Qual("go.opentelemetry.io/otel/trace", "SpanKindProducer"),
+				),
+				jen.Qual("go.opentelemetry.io/otel/trace", "WithAttributes").Call(
+					jen.Qual("go.opentelemetry.io/otel/attribute", "Int").Call(jen.Lit("event.count"), jen.Len(jen.Id("events"))),
+				),
+			),
+			jen.Defer().Id("span").Dot("End").Call(),
+			jen.Line(),
+			jen.List(jen.Id("ctx"), jen.Id("_")).Op("=").Id(recv).Dot("injectMetadata").Call(jen.Id("ctx")),
+			jen.Line(),
+			jen.Id("start").Op(":=").Qual("time", "Now").Call(),
+			jen.Line(),
+			jen.Err().Op(":=").Id("bus").Dot("PublishBatch").Call(jen.Id("ctx"), jen.Id("events")),
+			jen.Line(),
+			jen.Id("duration").Op(":=").Qual("time", "Since").Call(jen.Id("start")).Dot("Seconds").Call(),
+			jen.Line(),
+			jen.For(jen.List(jen.Id("_"), jen.Id("event")).Op(":=").Range().Id("events")).Block(
+				jen.Id("attrs").Op(":=").Qual("go.opentelemetry.io/otel/metric", "WithAttributes").Call(
+					jen.Qual("go.opentelemetry.io/otel/attribute", "String").Call(
+						jen.Lit("event.name"),
+						jen.Id("publishedEventName").Call(jen.Id("event")),
+					),
+				),
+				jen.Line(),
+				jen.Id(recv).Dot("duration").Dot("Record").Call(jen.Id("ctx"), jen.Id("duration"), jen.Id("attrs")),
+				jen.Line(),
+				jen.If(jen.Err().Op("!=").Nil()).Block(
+					jen.Id(recv).Dot("failures").Dot("Add").Call(jen.Id("ctx"), jen.Lit(1), jen.Id("attrs")),
+				).Else().Block(
+					jen.Id(recv).Dot("published").Dot("Add").Call(jen.Id("ctx"), jen.Lit(1), jen.Id("attrs")),
+				),
+			),
+			jen.Line(),
+			jen.If(jen.Err().Op("!=").Nil()).Block(
+				jen.Id("span").Dot("RecordError").Call(jen.Err()),
+				jen.Id("span").Dot("SetStatus").Call(
+					jen.Qual("go.opentelemetry.io/otel/codes", "Error"),
+					jen.Err().Dot("Error").Call(),
+				),
+			),
+			jen.Line(),
+			jen.Return(jen.Err()),
+		).
+		Line()
+}
@@ -23,6 +23,9 @@ type EventMethod struct {
 	Event           gentypes.TypeRef
 	ReceivesContext bool
 	ReturnsError    bool
+
+	// Batch is true when the method accepts a slice of events.
+	Batch bool
 }
 
 // Parse parses a given package, looks for an interface and returns it as a normalized structure.
@@ -93,6 +96,10 @@ func ParseEvents(obj types.Object) (Events, error) {
 
 		firstParam := sig.Params().At(0)
 		firstParamType, ok := firstParam.Type().(*types.Named)
+		if slice, isSlice := firstParam.Type().(*types.Slice); isSlice && sig.Params().Len() == 1 {
+			firstParamType, ok = slice.Elem().(*types.Named)
+			method.Batch = true
+		}
 		if !ok {
 			return events, fmt.Errorf("parameter %q in dispatcher method %q is not a valid type", firstParam.Name(), m.Name())
 		}
@@ -106,6 +113,10 @@ func ParseEvents(obj types.Object) (Events, error) {
 
 			secondParam := sig.Params().At(1)
 			secondParamType, ok := secondParam.Type().(*types.Named)
+			if slice, isSlice := secondParam.Type().(*types.Slice); isSlice {
+				secondParamType, ok = slice.Elem().(*types.Named)
+				method.Batch = true
+			}
 			if !ok {
 				return events, fmt.Errorf("parameter %q in dispatcher method %q is not a valid type", secondParam.Name(), m.Name())
 			}
@@ -91,6 +91,19 @@ func TestParse(t *testing.T) {
 				ReceivesContext: false,
 				ReturnsError:    true,
 			},
+			{
+				Name: "EventsWithContextAndError",
+				Event: gentypes.TypeRef{
+					Name: "Event",
+					Package: gentypes.PackageRef{
+						Name: "parser",
+						Path: "sagikazarmark.dev/mga/internal/generate/event/dispatcher/testdata/parser",
+					},
+				},
+				ReceivesContext: true,
+				ReturnsError:    true,
+				Batch:           true,
+			},
 			// TODO: figure out why this doesn't work at the moment
 			// {
 			// 	Name: "ImportedAliasedEvent",
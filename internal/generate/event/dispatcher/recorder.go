@@ -0,0 +1,199 @@
+package dispatcher
+
+import (
+	"bytes"
+	"go/format"
+
+	"github.com/dave/jennifer/jen"
+
+	"sagikazarmark.dev/mga/pkg/gentypes"
+	"sagikazarmark.dev/mga/pkg/jenutils"
+)
+
+// GenerateRecorder generates an in-memory event bus recording events dispatched by event dispatchers.
+func GenerateRecorder(file File) ([]byte, error) {
+	code := jen.NewFilePathName(file.Package.Path, file.Package.Name)
+
+	code.HeaderComment("//go:build !ignore_autogenerated\n// +build !ignore_autogenerated\n")
+
+	if file.HeaderText != "" {
+		code.HeaderComment(file.HeaderText)
+	}
+
+	code.HeaderComment("Code generated by mga tool. DO NOT EDIT.")
+
+	generateRecordingEventBus(code, recordedEvents(file.EventDispatchers))
+
+	var buf bytes.Buffer
+
+	err := code.Render(&buf)
+	if err != nil {
+		return nil, err
+	}
+
+	return format.Source(buf.Bytes())
+}
+
+type recordedEvent struct {
+	Event        gentypes.TypeRef
+	AccessorName string
+}
+
+// recordedEvents collects unique events dispatched by event dispatchers (in order of appearance).
+func recordedEvents(eventDispatchers []EventDispatcher) []recordedEvent {
+	var events []recordedEvent
+
+	seen := map[gentypes.TypeRef]bool{}
+	names := map[string]int{}
+
+	for _, eventDispatcher := range eventDispatchers {
+		for _, method := range eventDispatcher.DispatcherMethods {
+			if seen[method.Event] {
+				continue
+			}
+
+			seen[method.Event] = true
+			names[method.Event.Name]++
+
+			events = append(events, recordedEvent{Event: method.Event})
+		}
+	}
+
+	for i, event := range events {
+		name := event.Event.Name
+
+		// Qualify accessors when events from different packages share the same name
+		if names[name] > 1 {
+			name = jenutils.Export(event.Event.Package.Name) + name
+		}
+
+		events[i].AccessorName = "Published" + name
+	}
+
+	return events
+}
+
+func generateRecordingEventBus(code *jen.File, events []recordedEvent) {
+	const (
+		recordingEventBusTypeName = "RecordingEventBus"
+		recv                      = "b"
+	)
+
+	lock := func() []jen.Code {
+		return []jen.Code{
+			jen.Id(recv).Dot("mu").Dot("Lock").Call(),
+			jen.Defer().Id(recv).Dot("mu").Dot("Unlock").Call(),
+			jen.Line(),
+		}
+	}
+
+	code.Commentf("%s is an in-memory event bus recording published events.", recordingEventBusTypeName)
+	code.Comment("")
+	code.Comment("It is safe for concurrent use and is meant to be used in tests.")
+	code.Type().Id(recordingEventBusTypeName).Struct(
+		jen.Id("mu").Qual("sync", "Mutex"),
+		jen.Line(),
+		jen.Id("events").Index().Interface(),
+		jen.Id("errs").Index().Error(),
+	).Line()
+
+	code.Commentf("New%s returns a new %s instance.", recordingEventBusTypeName, recordingEventBusTypeName)
+	code.Func().
+		Id("New" + recordingEventBusTypeName).
+		Params().
+		Op("*").Id(recordingEventBusTypeName).
+		Block(
+			jen.Return(jen.Op("&").Id(recordingEventBusTypeName).Values()),
+		).
+		Line()
+
+	code.Comment("Publish records an event or returns the next injected error.")
+	code.Func().
+		Params(jen.Id(recv).Op("*").Id(recordingEventBusTypeName)).
+		Id("Publish").
+		Params(
+			jen.Id("_").Qual("context", "Context"),
+			jen.Id("event").Interface(),
+		).
+		Error().
+		Block(append(
+			lock(),
+			jen.If(jen.Len(jen.Id(recv).Dot("errs")).Op(">").Lit(0)).Block(
+				jen.Err().Op(":=").Id(recv).Dot("errs").Index(jen.Lit(0)),
+				jen.Id(recv).Dot("errs").Op("=").Id(recv).Dot("errs").Index(jen.Lit(1), jen.Empty()),
+				jen.Line(),
+				jen.If(jen.Err().Op("!=").Nil()).Block(
+					jen.Return(jen.Err()),
+				),
+			),
+			jen.Line(),
+			jen.Id(recv).Dot("events").Op("=").Append(jen.Id(recv).Dot("events"), jen.Id("event")),
+			jen.Line(),
+			jen.Return(jen.Nil()),
+		)...).
+		Line()
+
+	code.Comment("FailWith injects errors returned by subsequent Publish calls in order.")
+	code.Comment("")
+	code.Comment("A nil error lets the corresponding event through.")
+	code.Func().
+		Params(jen.Id(recv).Op("*").Id(recordingEventBusTypeName)).
+		Id("FailWith").
+		Params(jen.Id("errs").Op("...").Error()).
+		Block(append(
+			lock(),
+			jen.Id(recv).Dot("errs").Op("=").Append(jen.Id(recv).Dot("errs"), jen.Id("errs").Op("...")),
+		)...).
+		Line()
+
+	code.Comment("Published returns all recorded events in the order they were published.")
+	code.Func().
+		Params(jen.Id(recv).Op("*").Id(recordingEventBusTypeName)).
+		Id("Published").
+		Params().
+		Index().Interface().
+		Block(append(
+			lock(),
+			jen.Return(jen.Append(jen.Index().Interface().Call(jen.Nil()), jen.Id(recv).Dot("events").Op("..."))),
+		)...).
+		Line()
+
+	code.Comment("Reset removes recorded events and injected errors.")
+	code.Func().
+		Params(jen.Id(recv).Op("*").Id(recordingEventBusTypeName)).
+		Id("Reset").
+		Params().
+		Block(append(
+			lock(),
+			jen.Id(recv).Dot("events").Op("=").Nil(),
+			jen.Id(recv).Dot("errs").Op("=").Nil(),
+		)...).
+		Line()
+
+	for _, event := range events {
+		code.ImportName(event.Event.Package.Path, event.Event.Package.Name)
+
+		code.Commentf("%s returns recorded %s events in the order they were published.", event.AccessorName, event.Event.Name)
+		code.Func().
+			Params(jen.Id(recv).Op("*").Id(recordingEventBusTypeName)).
+			Id(event.AccessorName).
+			Params().
+			Index().Qual(event.Event.Package.Path, event.Event.Name).
+			Block(append(
+				lock(),
+				jen.Var().Id("events").Index().Qual(event.Event.Package.Path, event.Event.Name),
+				jen.Line(),
+				jen.For(jen.List(jen.Id("_"), jen.Id("event")).Op(":=").Range().Id(recv).Dot("events")).Block(
+					jen.If(
+						jen.List(jen.Id("e"), jen.Id("ok")).Op(":=").Id("event").Assert(jen.Qual(event.Event.Package.Path, event.Event.Name)),
+						jen.Id("ok"),
+					).Block(
+						jen.Id("events").Op("=").Append(jen.Id("events"), jen.Id("e")),
+					),
+				),
+				jen.Line(),
+				jen.Return(jen.Id("events")),
+			)...).
+			Line()
+	}
+}
@@ -0,0 +1,178 @@
+package dispatcher
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sagikazarmark.dev/mga/pkg/gentypes"
+)
+
+func TestGenerateRecorder(t *testing.T) {
+	file := File{
+		File: gentypes.File{
+			Package: gentypes.PackageRef{
+				Name: "pkggen",
+				Path: "app.dev/pkg/pkggen",
+			},
+			HeaderText: "",
+		},
+		EventDispatchers: []EventDispatcher{
+			{
+				Name: "",
+				DispatcherMethods: []EventMethod{
+					{
+						Name: "MarkedAsDone",
+						Event: gentypes.TypeRef{
+							Name: "MarkedAsDone",
+							Package: gentypes.PackageRef{
+								Name: "pkg",
+								Path: "app.dev/pkg",
+							},
+						},
+						ReceivesContext: true,
+						ReturnsError:    true,
+					},
+					{
+						Name: "MarkedAsDoneAgain",
+						Event: gentypes.TypeRef{
+							Name: "MarkedAsDone",
+							Package: gentypes.PackageRef{
+								Name: "pkg",
+								Path: "app.dev/pkg",
+							},
+						},
+						ReceivesContext: false,
+						ReturnsError:    true,
+					},
+					{
+						Name: "OtherMarkedAsDone",
+						Event: gentypes.TypeRef{
+							Name: "MarkedAsDone",
+							Package: gentypes.PackageRef{
+								Name: "other",
+								Path: "app.dev/other",
+							},
+						},
+						ReceivesContext: true,
+						ReturnsError:    false,
+					},
+				},
+			},
+		},
+	}
+
+	expected := `//go:build !ignore_autogenerated
+// +build !ignore_autogenerated
+
+// Code generated by mga tool. DO NOT EDIT.
+
+package pkggen
+
+import (
+	"app.dev/other"
+	"app.dev/pkg"
+	"context"
+	"sync"
+)
+
+// RecordingEventBus is an in-memory event bus recording published events.
+//
+// It is safe for concurrent use and is meant to be used in tests.
+type RecordingEventBus struct {
+	mu sync.Mutex
+
+	events []interface{}
+	errs   []error
+}
+
+// NewRecordingEventBus returns a new RecordingEventBus instance.
+func NewRecordingEventBus() *RecordingEventBus {
+	return &RecordingEventBus{}
+}
+
+// Publish records an event or returns the next injected error.
+func (b *RecordingEventBus) Publish(_ context.Context, event interface{}) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(b.errs) > 0 {
+		err := b.errs[0]
+		b.errs = b.errs[1:]
+
+		if err != nil {
+			return err
+		}
+	}
+
+	b.events = append(b.events, event)
+
+	return nil
+}
+
+// FailWith injects errors returned by subsequent Publish calls in order.
+//
+// A nil error lets the corresponding event through.
+func (b *RecordingEventBus) FailWith(errs ...error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.errs = append(b.errs, errs...)
+}
+
+// Published returns all recorded events in the order they were published.
+func (b *RecordingEventBus) Published() []interface{} {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return append([]interface{}(nil), b.events...)
+}
+
+// Reset removes recorded events and injected errors.
+func (b *RecordingEventBus) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.events = nil
+	b.errs = nil
+}
+
+// PublishedPkgMarkedAsDone returns recorded MarkedAsDone events in the order they were published.
+func (b *RecordingEventBus) PublishedPkgMarkedAsDone() []pkg.MarkedAsDone {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var events []pkg.MarkedAsDone
+
+	for _, event := range b.events {
+		if e, ok := event.(pkg.MarkedAsDone); ok {
+			events = append(events, e)
+		}
+	}
+
+	return events
+}
+
+// PublishedOtherMarkedAsDone returns recorded MarkedAsDone events in the order they were published.
+func (b *RecordingEventBus) PublishedOtherMarkedAsDone() []other.MarkedAsDone {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var events []other.MarkedAsDone
+
+	for _, event := range b.events {
+		if e, ok := event.(other.MarkedAsDone); ok {
+			events = append(events, e)
+		}
+	}
+
+	return events
+}
+`
+
+	actual, err := GenerateRecorder(file)
+	require.NoError(t, err)
+
+	assert.Equal(t, expected, string(actual), "the generated code does not match the expected one")
+}
@@ -32,6 +32,9 @@ type Events interface {
 	// EventWithError returns an error when something goes wrong during dispatching the event.
 	EventWithError(event Event) error
 
+	// EventsWithContextAndError dispatches multiple Event events at once.
+	EventsWithContextAndError(ctx context.Context, events []Event) error
+
 	// ImportedAliasedEvent dispatches an aliased event type.
 	// TODO: figure out why this doesn't work at the moment
 	// ImportedAliasedEvent(ctx context.Context, event ImportedAliasedEvent) error
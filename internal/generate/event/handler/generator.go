@@ -15,12 +15,47 @@ type File struct {
 
 	// EventHandlers represents event handlers to be generated for matching events.
 	EventHandlers []EventHandler
+
+	// EventHandlerGroups represents event handlers to be generated for groups of matching events.
+	EventHandlerGroups []EventHandlerGroup
 }
 
 // EventDispatcher describes an event handler.
 type EventHandler struct {
 	Name  string
 	Event gentypes.TypeRef
+
+	// Upcasts lists older versions of the event (in ascending order).
+	// Each version is upcasted to the next one (the last one to Event) before calling the handler.
+	Upcasts []gentypes.TypeRef
+
+	// Instrumented tells the generator to record metrics and traces for handled events.
+	Instrumented bool
+
+	// Idempotent tells the generator to generate a decorator handling each event at most once.
+	Idempotent bool
+
+	// KeyFields maps event names (including older versions) to the field used as idempotency key.
+	// Events without a key field are identified by the ID of the message they were received in.
+	KeyFields map[string]string
+
+	// Retry tells the generator to generate a decorator retrying failed events (if any).
+	Retry *RetryPolicy
+}
+
+// EventHandlerGroup describes an event handler handling a group of events.
+type EventHandlerGroup struct {
+	Name          string
+	EventHandlers []EventHandler
+
+	// Instrumented tells the generator to record metrics and traces for handled events.
+	Instrumented bool
+
+	// Idempotent tells the generator to generate a decorator handling each event at most once.
+	Idempotent bool
+
+	// Retry tells the generator to generate a decorator retrying failed events (if any).
+	Retry *RetryPolicy
 }
 
 // Generate generates an event handler.
@@ -37,8 +72,118 @@ func Generate(file File) ([]byte, error) {
 
 	code.ImportName("emperror.dev/errors", "errors")
 
+	if hasIdempotentEventHandlers(file) {
+		generateDedupStore(code)
+	}
+
+	if hasDeadLetters(file) {
+		generateDeadLetterSink(code)
+	}
+
+	if hasInstrumentedEventHandlers(file) {
+		generateMetadataContext(code)
+	}
+
+	if hasDecoratedEventHandlers(file) {
+		generateDecoratableEventHandler(
+			code,
+			decoratableEventHandlerTypeName,
+			jen.Comment("NewEvent returns a new empty event used for serialization."),
+			jen.Id("NewEvent").Params().Interface(),
+		)
+	}
+
+	if hasDecoratedEventHandlerGroups(file) {
+		generateDecoratableEventHandler(
+			code,
+			decoratableEventHandlerGroupTypeName,
+			jen.Comment("NewEvents returns new empty events (one for each handled event) used for serialization."),
+			jen.Id("NewEvents").Params().Index().Interface(),
+		)
+	}
+
 	for _, eventHandler := range file.EventHandlers {
 		generateEventHandler(code, eventHandler)
+
+		if eventHandler.Instrumented {
+			generateInstrumentedEventHandler(
+				code,
+				file.Package.Path,
+				eventHandler.Name+"EventHandler",
+				decoratableEventHandlerTypeName,
+				eventHandler.Event.Name,
+				nil,
+			)
+		}
+
+		if eventHandler.Idempotent {
+			generateIdempotentEventHandler(
+				code,
+				eventHandler.Name+"EventHandler",
+				decoratableEventHandlerTypeName,
+				eventHandler.Event.Name,
+				append(append([]gentypes.TypeRef{}, eventHandler.Upcasts...), eventHandler.Event),
+				eventHandler.KeyFields,
+			)
+		}
+
+		if eventHandler.Retry != nil {
+			generateRetryingEventHandler(
+				code,
+				eventHandler.Name+"EventHandler",
+				decoratableEventHandlerTypeName,
+				eventHandler.Event.Name,
+				*eventHandler.Retry,
+			)
+		}
+	}
+
+	for _, eventHandlerGroup := range file.EventHandlerGroups {
+		generateEventHandlerGroup(code, eventHandlerGroup)
+
+		var events []gentypes.TypeRef
+		keyFields := map[string]string{}
+
+		for _, eventHandler := range eventHandlerGroup.EventHandlers {
+			events = append(events, eventHandler.Upcasts...)
+			events = append(events, eventHandler.Event)
+
+			for name, field := range eventHandler.KeyFields {
+				keyFields[name] = field
+			}
+		}
+
+		if eventHandlerGroup.Instrumented {
+			generateInstrumentedEventHandler(
+				code,
+				file.Package.Path,
+				eventHandlerGroup.Name+"EventHandler",
+				decoratableEventHandlerGroupTypeName,
+				eventHandlerGroup.Name,
+				events,
+			)
+		}
+
+		if eventHandlerGroup.Idempotent {
+			generateIdempotentEventHandler(
+				code,
+				eventHandlerGroup.Name+"EventHandler",
+				decoratableEventHandlerGroupTypeName,
+				eventHandlerGroup.Name,
+				events,
+				keyFields,
+			)
+		}
+
+		if eventHandlerGroup.Retry != nil {
+			generateRetryingEventHandler(
+				code,
+				eventHandlerGroup.Name+"EventHandler",
+				decoratableEventHandlerGroupTypeName,
+				eventHandlerGroup.Name,
+				*eventHandlerGroup.Retry,
+			)
+		}
 	}
 
 	var buf bytes.Buffer
@@ -51,6 +196,52 @@ func Generate(file File) ([]byte, error) {
 	return format.Source(buf.Bytes())
 }
 
+// Interfaces wrapped by event handler decorators.
+const (
+	decoratableEventHandlerTypeName      = "DecoratableEventHandler"
+	decoratableEventHandlerGroupTypeName = "DecoratableEventHandlerGroup"
+)
+
+// generateDecoratableEventHandler generates the interface of event handlers wrapped by decorators.
+//
+// Decorators implement the interface as well, so they can wrap each other.
+func generateDecoratableEventHandler(code *jen.File, typeName string, newEventDoc jen.Code, newEventMethod jen.Code) {
+	code.Commentf("%s is an event handler (or a decorator of one) that decorators can wrap.", typeName)
+	code.Type().Id(typeName).Interface(
+		jen.Comment("HandlerName returns the name of the event handler."),
+		jen.Id("HandlerName").Params().String(),
+		jen.Line(),
+		newEventDoc,
+		newEventMethod,
+		jen.Line(),
+		jen.Comment("Handle handles an event."),
+		jen.Id("Handle").Params(
+			jen.Id("ctx").Qual("context", "Context"),
+			jen.Id("event").Interface(),
+		).Error(),
+	).Line()
+}
+
+func hasDecoratedEventHandlers(file File) bool {
+	for _, eventHandler := range file.EventHandlers {
+		if eventHandler.Instrumented || eventHandler.Idempotent || eventHandler.Retry != nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+func hasDecoratedEventHandlerGroups(file File) bool {
+	for _, eventHandlerGroup := range file.EventHandlerGroups {
+		if eventHandlerGroup.Instrumented || eventHandlerGroup.Idempotent || eventHandlerGroup.Retry != nil {
+			return true
+		}
+	}
+
+	return false
+}
+
 func generateEventHandler(code *jen.File, eventHandler EventHandler) {
 	handlerTypeName := eventHandler.Name + "Handler"
 	eventHandlerTypeName := eventHandler.Name + "EventHandler"
@@ -71,29 +262,16 @@ func generateEventHandler(code *jen.File, eventHandler EventHandler) {
 		).Error(),
 	).Line()
 
-	code.Commentf("%s handles %s events.", eventHandlerTypeName, eventHandler.Name)
-	code.Type().Id(eventHandlerTypeName).Struct(
-		jen.Id(handlerVarName).Id(handlerTypeName),
-		jen.Id(handlerNameVarName).String(),
-	).Line()
+	upcasterTypeName := eventHandler.Name + "Upcaster"
+	var upcasters []EventHandler
 
-	code.Commentf("New%s returns a new %s instance.", eventHandlerTypeName, eventHandlerTypeName)
-	code.Func().
-		Id("New"+eventHandlerTypeName).
-		Params(
-			jen.Id(handlerVarName).Id(handlerTypeName),
-			jen.Id(handlerNameVarName).String(),
-		).
-		Id(eventHandlerTypeName).
-		Block(
-			jen.Return(
-				jen.Id(eventHandlerTypeName).Values(jen.Dict{
-					jen.Id(handlerVarName):     jen.Id(handlerVarName),
-					jen.Id(handlerNameVarName): jen.Id(handlerNameVarName),
-				}),
-			),
-		).
-		Line()
+	if len(eventHandler.Upcasts) > 0 {
+		upcasters = append(upcasters, eventHandler)
+
+		generateUpcaster(code, upcasterTypeName, eventHandler.Name, upcasters)
+	}
+
+	generateEventHandlerStruct(code, eventHandlerTypeName, eventHandler.Name, handlerTypeName, upcasterTypeName, upcasters)
 
 	code.Comment("HandlerName returns the name of the event handler.")
 	code.Func().
@@ -126,12 +304,31 @@ func generateEventHandler(code *jen.File, eventHandler EventHandler) {
 			jen.Id("event").Interface(),
 		).
 		Params(jen.Error()).
-		Block(
-			jen.List(jen.Id("e"), jen.Id("ok")).
+		BlockFunc(func(g *jen.Group) {
+			if len(eventHandler.Upcasts) > 0 {
+				g.Switch(jen.Id("e").Op(":=").Id("event").Assert(jen.Type())).Block(
+					append(
+						upcastCases(eventHandler),
+						jen.Case(jen.Op("*").Qual(eventHandler.Event.Package.Path, eventHandler.Event.Name)).Block(
+							jen.Return(
+								jen.Id("h").Dot(handlerVarName).Dot(eventHandler.Name).Call(
+									jen.Id("ctx"),
+									jen.Op("*").Id("e"),
+								),
+							),
+						),
+						unexpectedEventCase(),
+					)...,
+				)
+
+				return
+			}
+
+			g.List(jen.Id("e"), jen.Id("ok")).
 				Op(":=").
 				Id("event").
-				Assert(jen.Op("*").Qual(eventHandler.Event.Package.Path, eventHandler.Event.Name)),
-			jen.If(jen.Op("!").Id("ok")).Block(
+				Assert(jen.Op("*").Qual(eventHandler.Event.Package.Path, eventHandler.Event.Name))
+			g.If(jen.Op("!").Id("ok")).Block(
 				jen.Return(
 					jen.Qual("emperror.dev/errors", "NewWithDetails").Call(
 						jen.Lit("unexpected event type"),
@@ -142,13 +339,347 @@ func generateEventHandler(code *jen.File, eventHandler EventHandler) {
 						),
 					),
 				),
+			)
+			g.Line()
+			g.Return(
+				jen.Id("h").Dot(handlerVarName).Dot(eventHandler.Name).Call(
+					jen.Id("ctx"),
+					jen.Op("*").Id("e"),
+				),
+			)
+		})
+
+	if len(eventHandler.Upcasts) > 0 {
+		generateVersionEventHandler(code, eventHandlerTypeName, eventHandler)
+	}
+}
+
+// generateVersionEventHandler generates event handlers for older versions of an event.
+//
+// Event processors passing only events matching NewEvent to a handler (eg. Watermill's CQRS event processor)
+// would never pass older versions to the upcasting handler otherwise.
+func generateVersionEventHandler(code *jen.File, eventHandlerTypeName string, eventHandler EventHandler) {
+	versionEventHandlerTypeName := eventHandler.Name + "VersionEventHandler"
+
+	const recv = "h"
+
+	code.Commentf("%s handles an older version of %s events.", versionEventHandlerTypeName, eventHandler.Name)
+	code.Comment("")
+	code.Comment("Register it next to the handler of the latest version with event processors")
+	code.Comment("passing only events returned by NewEvent to a handler (eg. Watermill's CQRS event processor).")
+	code.Type().Id(versionEventHandlerTypeName).Struct(
+		jen.Id(eventHandlerTypeName),
+		jen.Line(),
+		jen.Id("version").Int(),
+		jen.Id("newEvent").Func().Params().Interface(),
+	).Line()
+
+	versions := make([]jen.Code, 0, len(eventHandler.Upcasts))
+
+	for i, upcast := range eventHandler.Upcasts {
+		versions = append(versions, jen.Values(jen.Dict{
+			jen.Id(eventHandlerTypeName): jen.Id(recv),
+			jen.Id("version"):            jen.Lit(i + 1),
+			jen.Id("newEvent"): jen.Func().Params().Interface().Block(
+				jen.Return(jen.Op("&").Qual(upcast.Package.Path, upcast.Name).Values()),
 			),
-			jen.Line(),
+		}))
+	}
+
+	code.Commentf("VersionHandlers returns an event handler for each older version of %s events.", eventHandler.Name)
+	code.Func().
+		Params(jen.Id(recv).Id(eventHandlerTypeName)).
+		Id("VersionHandlers").
+		Params().
+		Index().Id(versionEventHandlerTypeName).
+		Block(
+			jen.Return(jen.Index().Id(versionEventHandlerTypeName).Values(versions...)),
+		).
+		Line()
+
+	code.Comment("HandlerName returns the name of the event handler (suffixed with the event version).")
+	code.Func().
+		Params(jen.Id(recv).Id(versionEventHandlerTypeName)).
+		Id("HandlerName").
+		Params().
+		String().
+		Block(
 			jen.Return(
-				jen.Id("h").Dot(handlerVarName).Dot(eventHandler.Name).Call(
+				jen.Qual("fmt", "Sprintf").Call(
+					jen.Lit("%s_v%d"),
+					jen.Id(recv).Dot(eventHandlerTypeName).Dot("HandlerName").Call(),
+					jen.Id(recv).Dot("version"),
+				),
+			),
+		).
+		Line()
+
+	code.Comment("NewEvent returns a new empty event used for serialization.")
+	code.Func().
+		Params(jen.Id(recv).Id(versionEventHandlerTypeName)).
+		Id("NewEvent").
+		Params().
+		Interface().
+		Block(jen.Return(jen.Id(recv).Dot("newEvent").Call()))
+}
+
+func generateEventHandlerGroup(code *jen.File, eventHandlerGroup EventHandlerGroup) {
+	handlerTypeName := eventHandlerGroup.Name + "Handler"
+	noopHandlerTypeName := "Noop" + handlerTypeName
+	eventHandlerTypeName := eventHandlerGroup.Name + "EventHandler"
+
+	const (
+		handlerVarName     = "handler"
+		handlerNameVarName = "name"
+	)
+
+	var methods []jen.Code
+
+	for i, eventHandler := range eventHandlerGroup.EventHandlers {
+		code.ImportName(eventHandler.Event.Package.Path, eventHandler.Event.Package.Name)
+
+		if i > 0 {
+			methods = append(methods, jen.Line())
+		}
+
+		methods = append(
+			methods,
+			jen.Commentf("%s handles a(n) %s event.", eventHandler.Name, eventHandler.Name),
+			jen.Id(eventHandler.Name).Params(
+				jen.Id("ctx").Qual("context", "Context"),
+				jen.Id("event").Qual(eventHandler.Event.Package.Path, eventHandler.Event.Name),
+			).Error(),
+		)
+	}
+
+	code.Commentf("%s handles %s events.", handlerTypeName, eventHandlerGroup.Name)
+	code.Type().Id(handlerTypeName).Interface(methods...).Line()
+
+	code.Commentf("%s is a(n) %s that ignores every event.", noopHandlerTypeName, handlerTypeName)
+	code.Comment("")
+	code.Comment("Embed it in handler implementations to handle only a subset of events.")
+	code.Type().Id(noopHandlerTypeName).Struct().Line()
+
+	for _, eventHandler := range eventHandlerGroup.EventHandlers {
+		code.Commentf("%s ignores a(n) %s event.", eventHandler.Name, eventHandler.Name)
+		code.Func().
+			Params(jen.Id(noopHandlerTypeName)).
+			Id(eventHandler.Name).
+			Params(
+				jen.Id("_").Qual("context", "Context"),
+				jen.Id("_").Qual(eventHandler.Event.Package.Path, eventHandler.Event.Name),
+			).
+			Error().
+			Block(jen.Return(jen.Nil())).
+			Line()
+	}
+
+	upcasterTypeName := eventHandlerGroup.Name + "Upcaster"
+
+	var upcasters []EventHandler
+
+	for _, eventHandler := range eventHandlerGroup.EventHandlers {
+		if len(eventHandler.Upcasts) > 0 {
+			upcasters = append(upcasters, eventHandler)
+		}
+	}
+
+	if len(upcasters) > 0 {
+		generateUpcaster(code, upcasterTypeName, eventHandlerGroup.Name, upcasters)
+	}
+
+	generateEventHandlerStruct(code, eventHandlerTypeName, eventHandlerGroup.Name, handlerTypeName, upcasterTypeName, upcasters)
+
+	code.Comment("HandlerName returns the name of the event handler.")
+	code.Func().
+		Params(
+			jen.Id("h").Id(eventHandlerTypeName),
+		).
+		Id("HandlerName").
+		Params().
+		Params(jen.String()).
+		Block(jen.Return(jen.Id("h").Dot(handlerNameVarName)))
+
+	var newEvents []jen.Code
+
+	for _, eventHandler := range eventHandlerGroup.EventHandlers {
+		for _, upcast := range eventHandler.Upcasts {
+			newEvents = append(newEvents, jen.Op("&").Qual(upcast.Package.Path, upcast.Name).Values())
+		}
+
+		newEvents = append(newEvents, jen.Op("&").Qual(eventHandler.Event.Package.Path, eventHandler.Event.Name).Values())
+	}
+
+	code.Comment("NewEvents returns new empty events (one for each handled event) used for serialization.")
+	code.Func().
+		Params(
+			jen.Id("h").Id(eventHandlerTypeName),
+		).
+		Id("NewEvents").
+		Params().
+		Params(jen.Index().Interface()).
+		Block(jen.Return(jen.Index().Interface().Values(newEvents...)))
+
+	var cases []jen.Code
+
+	for _, eventHandler := range eventHandlerGroup.EventHandlers {
+		cases = append(cases, upcastCases(eventHandler)...)
+		cases = append(
+			cases,
+			jen.Case(jen.Op("*").Qual(eventHandler.Event.Package.Path, eventHandler.Event.Name)).Block(
+				jen.Return(
+					jen.Id("h").Dot(handlerVarName).Dot(eventHandler.Name).Call(
+						jen.Id("ctx"),
+						jen.Op("*").Id("e"),
+					),
+				),
+			),
+		)
+	}
+
+	cases = append(cases, unexpectedEventCase())
+
+	code.Comment("Handle handles an event.")
+	code.Func().
+		Params(
+			jen.Id("h").Id(eventHandlerTypeName),
+		).
+		Id("Handle").
+		Params(
+			jen.Id("ctx").Qual("context", "Context"),
+			jen.Id("event").Interface(),
+		).
+		Params(jen.Error()).
+		Block(
+			jen.Switch(jen.Id("e").Op(":=").Id("event").Assert(jen.Type())).Block(cases...),
+		)
+}
+
+func generateEventHandlerStruct(
+	code *jen.File,
+	eventHandlerTypeName string,
+	name string,
+	handlerTypeName string,
+	upcasterTypeName string,
+	upcasters []EventHandler,
+) {
+	const (
+		handlerVarName     = "handler"
+		upcasterVarName    = "upcaster"
+		handlerNameVarName = "name"
+	)
+
+	fields := []jen.Code{jen.Id(handlerVarName).Id(handlerTypeName)}
+	params := []jen.Code{jen.Id(handlerVarName).Id(handlerTypeName)}
+	values := jen.Dict{jen.Id(handlerVarName): jen.Id(handlerVarName)}
+
+	if len(upcasters) > 0 {
+		fields = append(fields, jen.Id(upcasterVarName).Id(upcasterTypeName))
+		params = append(params, jen.Id(upcasterVarName).Id(upcasterTypeName))
+		values[jen.Id(upcasterVarName)] = jen.Id(upcasterVarName)
+	}
+
+	fields = append(fields, jen.Id(handlerNameVarName).String())
+	params = append(params, jen.Id(handlerNameVarName).String())
+	values[jen.Id(handlerNameVarName)] = jen.Id(handlerNameVarName)
+
+	code.Commentf("%s handles %s events.", eventHandlerTypeName, name)
+	code.Type().Id(eventHandlerTypeName).Struct(fields...).Line()
+
+	code.Commentf("New%s returns a new %s instance.", eventHandlerTypeName, eventHandlerTypeName)
+	code.Func().
+		Id("New" + eventHandlerTypeName).
+		Params(params...).
+		Id(eventHandlerTypeName).
+		Block(
+			jen.Return(
+				jen.Id(eventHandlerTypeName).Values(values),
+			),
+		).
+		Line()
+}
+
+// generateUpcaster generates an interface upcasting older versions of events to their next version.
+func generateUpcaster(code *jen.File, upcasterTypeName string, name string, eventHandlers []EventHandler) {
+	var methods []jen.Code
+
+	for _, eventHandler := range eventHandlers {
+		for i, upcast := range eventHandler.Upcasts {
+			code.ImportName(upcast.Package.Path, upcast.Package.Name)
+
+			next := eventHandler.Event
+			if i+1 < len(eventHandler.Upcasts) {
+				next = eventHandler.Upcasts[i+1]
+			}
+
+			if len(methods) > 0 {
+				methods = append(methods, jen.Line())
+			}
+
+			methods = append(
+				methods,
+				jen.Commentf("Upcast%s upcasts a(n) %s event to %s.", upcast.Name, upcast.Name, next.Name),
+				jen.Id("Upcast"+upcast.Name).Params(
+					jen.Id("ctx").Qual("context", "Context"),
+					jen.Id("event").Qual(upcast.Package.Path, upcast.Name),
+				).Params(
+					jen.Qual(next.Package.Path, next.Name),
+					jen.Error(),
+				),
+			)
+		}
+	}
+
+	code.Commentf("%s upcasts older versions of %s events.", upcasterTypeName, name)
+	code.Type().Id(upcasterTypeName).Interface(methods...).Line()
+}
+
+// upcastCases generates type switch cases upcasting older versions of an event and handling the upcasted event.
+func upcastCases(eventHandler EventHandler) []jen.Code {
+	cases := make([]jen.Code, 0, len(eventHandler.Upcasts))
+
+	for _, upcast := range eventHandler.Upcasts {
+		cases = append(
+			cases,
+			jen.Case(jen.Op("*").Qual(upcast.Package.Path, upcast.Name)).Block(
+				jen.List(jen.Id("next"), jen.Err()).
+					Op(":=").
+					Id("h").Dot("upcaster").Dot("Upcast"+upcast.Name).Call(
 					jen.Id("ctx"),
 					jen.Op("*").Id("e"),
 				),
+				jen.If(jen.Err().Op("!=").Nil()).Block(
+					jen.Return(
+						jen.Qual("emperror.dev/errors", "WithDetails").Call(
+							jen.Qual("emperror.dev/errors", "WithMessage").Call(
+								jen.Err(),
+								jen.Lit("failed to upcast event"),
+							),
+							jen.Lit("event"),
+							jen.Lit(upcast.Name),
+						),
+					),
+				),
+				jen.Line(),
+				jen.Return(jen.Id("h").Dot("Handle").Call(jen.Id("ctx"), jen.Op("&").Id("next"))),
 			),
 		)
+	}
+
+	return cases
+}
+
+func unexpectedEventCase() jen.Code {
+	return jen.Default().Block(
+		jen.Return(
+			jen.Qual("emperror.dev/errors", "NewWithDetails").Call(
+				jen.Lit("unexpected event type"),
+				jen.Lit("type"),
+				jen.Qual("fmt", "Sprintf").Call(
+					jen.Lit("%T"),
+					jen.Id("event"),
+				),
+			),
+		),
+	)
 }
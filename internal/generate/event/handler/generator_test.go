@@ -102,3 +102,545 @@ func (h EventEventHandler) Handle(ctx context.Context, event interface{}) error
 
 	assert.Equal(t, expected, string(actual), "the generated code does not match the expected one")
 }
+
+func TestGenerate_Group(t *testing.T) {
+	file := File{
+		File: gentypes.File{
+			Package: gentypes.PackageRef{
+				Name: "pkggen",
+				Path: "app.dev/pkg/pkggen",
+			},
+		},
+		EventHandlerGroups: []EventHandlerGroup{
+			{
+				Name: "Todo",
+				EventHandlers: []EventHandler{
+					{
+						Name: "TodoCreated",
+						Event: Event{
+							Name: "TodoCreated",
+							Package: gentypes.PackageRef{
+								Name: "pkg",
+								Path: "app.dev/pkg",
+							},
+						},
+					},
+					{
+						Name: "TodoDone",
+						Event: Event{
+							Name: "TodoDone",
+							Package: gentypes.PackageRef{
+								Name: "pkg",
+								Path: "app.dev/pkg",
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	expected := `//go:build !ignore_autogenerated
+// +build !ignore_autogenerated
+
+// Code generated by mga tool. DO NOT EDIT.
+
+package pkggen
+
+import (
+	"app.dev/pkg"
+	"context"
+	"emperror.dev/errors"
+	"fmt"
+)
+
+// TodoHandler handles Todo events.
+type TodoHandler interface {
+	// TodoCreated handles a(n) TodoCreated event.
+	TodoCreated(ctx context.Context, event pkg.TodoCreated) error
+
+	// TodoDone handles a(n) TodoDone event.
+	TodoDone(ctx context.Context, event pkg.TodoDone) error
+}
+
+// NoopTodoHandler is a(n) TodoHandler that ignores every event.
+//
+// Embed it in handler implementations to handle only a subset of events.
+type NoopTodoHandler struct{}
+
+// TodoCreated ignores a(n) TodoCreated event.
+func (NoopTodoHandler) TodoCreated(_ context.Context, _ pkg.TodoCreated) error {
+	return nil
+}
+
+// TodoDone ignores a(n) TodoDone event.
+func (NoopTodoHandler) TodoDone(_ context.Context, _ pkg.TodoDone) error {
+	return nil
+}
+
+// TodoEventHandler handles Todo events.
+type TodoEventHandler struct {
+	handler TodoHandler
+	name    string
+}
+
+// NewTodoEventHandler returns a new TodoEventHandler instance.
+func NewTodoEventHandler(handler TodoHandler, name string) TodoEventHandler {
+	return TodoEventHandler{
+		handler: handler,
+		name:    name,
+	}
+}
+
+// HandlerName returns the name of the event handler.
+func (h TodoEventHandler) HandlerName() string {
+	return h.name
+}
+
+// NewEvents returns new empty events (one for each handled event) used for serialization.
+func (h TodoEventHandler) NewEvents() []interface{} {
+	return []interface{}{&pkg.TodoCreated{}, &pkg.TodoDone{}}
+}
+
+// Handle handles an event.
+func (h TodoEventHandler) Handle(ctx context.Context, event interface{}) error {
+	switch e := event.(type) {
+	case *pkg.TodoCreated:
+		return h.handler.TodoCreated(ctx, *e)
+	case *pkg.TodoDone:
+		return h.handler.TodoDone(ctx, *e)
+	default:
+		return errors.NewWithDetails("unexpected event type", "type", fmt.Sprintf("%T", event))
+	}
+}
+`
+
+	actual, err := Generate(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, expected, string(actual), "the generated code does not match the expected one")
+}
+
+func TestGenerate_GroupUpcast(t *testing.T) {
+	pkg := gentypes.PackageRef{
+		Name: "pkg",
+		Path: "app.dev/pkg",
+	}
+
+	file := File{
+		File: gentypes.File{
+			Package: gentypes.PackageRef{
+				Name: "pkggen",
+				Path: "app.dev/pkg/pkggen",
+			},
+		},
+		EventHandlerGroups: []EventHandlerGroup{
+			{
+				Name: "Todo",
+				EventHandlers: []EventHandler{
+					{
+						Name:  "TodoCreated",
+						Event: Event{Name: "TodoCreated", Package: pkg},
+						Upcasts: []gentypes.TypeRef{
+							{Name: "TodoCreatedV1", Package: pkg},
+						},
+					},
+					{
+						Name:  "TodoDone",
+						Event: Event{Name: "TodoDone", Package: pkg},
+					},
+				},
+			},
+		},
+	}
+
+	expected := `//go:build !ignore_autogenerated
+// +build !ignore_autogenerated
+
+// Code generated by mga tool. DO NOT EDIT.
+
+package pkggen
+
+import (
+	"app.dev/pkg"
+	"context"
+	"emperror.dev/errors"
+	"fmt"
+)
+
+// TodoHandler handles Todo events.
+type TodoHandler interface {
+	// TodoCreated handles a(n) TodoCreated event.
+	TodoCreated(ctx context.Context, event pkg.TodoCreated) error
+
+	// TodoDone handles a(n) TodoDone event.
+	TodoDone(ctx context.Context, event pkg.TodoDone) error
+}
+
+// NoopTodoHandler is a(n) TodoHandler that ignores every event.
+//
+// Embed it in handler implementations to handle only a subset of events.
+type NoopTodoHandler struct{}
+
+// TodoCreated ignores a(n) TodoCreated event.
+func (NoopTodoHandler) TodoCreated(_ context.Context, _ pkg.TodoCreated) error {
+	return nil
+}
+
+// TodoDone ignores a(n) TodoDone event.
+func (NoopTodoHandler) TodoDone(_ context.Context, _ pkg.TodoDone) error {
+	return nil
+}
+
+// TodoUpcaster upcasts older versions of Todo events.
+type TodoUpcaster interface {
+	// UpcastTodoCreatedV1 upcasts a(n) TodoCreatedV1 event to TodoCreated.
+	UpcastTodoCreatedV1(ctx context.Context, event pkg.TodoCreatedV1) (pkg.TodoCreated, error)
+}
+
+// TodoEventHandler handles Todo events.
+type TodoEventHandler struct {
+	handler  TodoHandler
+	upcaster TodoUpcaster
+	name     string
+}
+
+// NewTodoEventHandler returns a new TodoEventHandler instance.
+func NewTodoEventHandler(handler TodoHandler, upcaster TodoUpcaster, name string) TodoEventHandler {
+	return TodoEventHandler{
+		handler:  handler,
+		name:     name,
+		upcaster: upcaster,
+	}
+}
+
+// HandlerName returns the name of the event handler.
+func (h TodoEventHandler) HandlerName() string {
+	return h.name
+}
+
+// NewEvents returns new empty events (one for each handled event) used for serialization.
+func (h TodoEventHandler) NewEvents() []interface{} {
+	return []interface{}{&pkg.TodoCreatedV1{}, &pkg.TodoCreated{}, &pkg.TodoDone{}}
+}
+
+// Handle handles an event.
+func (h TodoEventHandler) Handle(ctx context.Context, event interface{}) error {
+	switch e := event.(type) {
+	case *pkg.TodoCreatedV1:
+		next, err := h.upcaster.UpcastTodoCreatedV1(ctx, *e)
+		if err != nil {
+			return errors.WithDetails(errors.WithMessage(err, "failed to upcast event"), "event", "TodoCreatedV1")
+		}
+
+		return h.Handle(ctx, &next)
+	case *pkg.TodoCreated:
+		return h.handler.TodoCreated(ctx, *e)
+	case *pkg.TodoDone:
+		return h.handler.TodoDone(ctx, *e)
+	default:
+		return errors.NewWithDetails("unexpected event type", "type", fmt.Sprintf("%T", event))
+	}
+}
+`
+
+	actual, err := Generate(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, expected, string(actual), "the generated code does not match the expected one")
+}
+
+func TestGenerate_GroupIdempotent(t *testing.T) {
+	pkg := gentypes.PackageRef{
+		Name: "pkg",
+		Path: "app.dev/pkg",
+	}
+
+	file := File{
+		File: gentypes.File{
+			Package: gentypes.PackageRef{
+				Name: "pkggen",
+				Path: "app.dev/pkg/pkggen",
+			},
+		},
+		EventHandlerGroups: []EventHandlerGroup{
+			{
+				Name: "Todo",
+				EventHandlers: []EventHandler{
+					{
+						Name:  "TodoCreated",
+						Event: Event{Name: "TodoCreated", Package: pkg},
+						KeyFields: map[string]string{
+							"TodoCreated": "ID",
+						},
+					},
+					{
+						Name:  "TodoDone",
+						Event: Event{Name: "TodoDone", Package: pkg},
+					},
+				},
+				Idempotent: true,
+			},
+		},
+	}
+
+	expected := `//go:build !ignore_autogenerated
+// +build !ignore_autogenerated
+
+// Code generated by mga tool. DO NOT EDIT.
+
+package pkggen
+
+import (
+	"app.dev/pkg"
+	"context"
+	"emperror.dev/errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DedupStore keeps track of events that have already been handled.
+type DedupStore interface {
+	// Seen checks whether an event with the given key has already been handled.
+	Seen(ctx context.Context, key string) (bool, error)
+
+	// MarkSeen records that an event with the given key has been handled successfully.
+	MarkSeen(ctx context.Context, key string) error
+}
+
+// InMemoryDedupStore is a DedupStore keeping event keys in memory for a limited time.
+//
+// It is meant to be used in tests and single instance deployments.
+// Expired keys are removed at most once per TTL (when marking an event as seen).
+type InMemoryDedupStore struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	keys    map[string]time.Time
+	sweepAt time.Time
+}
+
+// NewInMemoryDedupStore returns a new InMemoryDedupStore instance.
+//
+// Keys expire after the given TTL.
+func NewInMemoryDedupStore(ttl time.Duration) *InMemoryDedupStore {
+	return &InMemoryDedupStore{
+		keys: make(map[string]time.Time),
+		ttl:  ttl,
+	}
+}
+
+// Seen checks whether an event with the given key has already been handled.
+func (s *InMemoryDedupStore) Seen(_ context.Context, key string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	expiresAt, ok := s.keys[key]
+	if !ok {
+		return false, nil
+	}
+
+	if time.Now().After(expiresAt) {
+		delete(s.keys, key)
+
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// MarkSeen records that an event with the given key has been handled successfully.
+func (s *InMemoryDedupStore) MarkSeen(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+
+	// Remove expired keys to keep memory usage bounded (without scanning every key each time)
+	if now.After(s.sweepAt) {
+		for k, expiresAt := range s.keys {
+			if now.After(expiresAt) {
+				delete(s.keys, k)
+			}
+		}
+
+		s.sweepAt = now.Add(s.ttl)
+	}
+
+	s.keys[key] = now.Add(s.ttl)
+
+	return nil
+}
+
+type messageIDContextKey struct{}
+
+// ContextWithMessageID returns a new context carrying the ID of the message an event was received in.
+//
+// Idempotent event handlers use the message ID as key for events without a key field.
+// It is not set automatically: pass it to event handlers, eg. with a Watermill router middleware:
+//
+//	func(h message.HandlerFunc) message.HandlerFunc {
+//		return func(msg *message.Message) ([]*message.Message, error) {
+//			msg.SetContext(ContextWithMessageID(msg.Context(), msg.UUID))
+//
+//			return h(msg)
+//		}
+//	}
+func ContextWithMessageID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, messageIDContextKey{}, id)
+}
+
+// MessageIDFromContext returns the ID of the message an event was received in (if any).
+func MessageIDFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(messageIDContextKey{}).(string)
+
+	return id, ok && id != ""
+}
+
+// DecoratableEventHandlerGroup is an event handler (or a decorator of one) that decorators can wrap.
+type DecoratableEventHandlerGroup interface {
+	// HandlerName returns the name of the event handler.
+	HandlerName() string
+
+	// NewEvents returns new empty events (one for each handled event) used for serialization.
+	NewEvents() []interface{}
+
+	// Handle handles an event.
+	Handle(ctx context.Context, event interface{}) error
+}
+
+// TodoHandler handles Todo events.
+type TodoHandler interface {
+	// TodoCreated handles a(n) TodoCreated event.
+	TodoCreated(ctx context.Context, event pkg.TodoCreated) error
+
+	// TodoDone handles a(n) TodoDone event.
+	TodoDone(ctx context.Context, event pkg.TodoDone) error
+}
+
+// NoopTodoHandler is a(n) TodoHandler that ignores every event.
+//
+// Embed it in handler implementations to handle only a subset of events.
+type NoopTodoHandler struct{}
+
+// TodoCreated ignores a(n) TodoCreated event.
+func (NoopTodoHandler) TodoCreated(_ context.Context, _ pkg.TodoCreated) error {
+	return nil
+}
+
+// TodoDone ignores a(n) TodoDone event.
+func (NoopTodoHandler) TodoDone(_ context.Context, _ pkg.TodoDone) error {
+	return nil
+}
+
+// TodoEventHandler handles Todo events.
+type TodoEventHandler struct {
+	handler TodoHandler
+	name    string
+}
+
+// NewTodoEventHandler returns a new TodoEventHandler instance.
+func NewTodoEventHandler(handler TodoHandler, name string) TodoEventHandler {
+	return TodoEventHandler{
+		handler: handler,
+		name:    name,
+	}
+}
+
+// HandlerName returns the name of the event handler.
+func (h TodoEventHandler) HandlerName() string {
+	return h.name
+}
+
+// NewEvents returns new empty events (one for each handled event) used for serialization.
+func (h TodoEventHandler) NewEvents() []interface{} {
+	return []interface{}{&pkg.TodoCreated{}, &pkg.TodoDone{}}
+}
+
+// Handle handles an event.
+func (h TodoEventHandler) Handle(ctx context.Context, event interface{}) error {
+	switch e := event.(type) {
+	case *pkg.TodoCreated:
+		return h.handler.TodoCreated(ctx, *e)
+	case *pkg.TodoDone:
+		return h.handler.TodoDone(ctx, *e)
+	default:
+		return errors.NewWithDetails("unexpected event type", "type", fmt.Sprintf("%T", event))
+	}
+}
+
+// IdempotentTodoEventHandler handles Todo events at most once (per key).
+//
+// Events are identified by their key field or by the ID of the message they were received in
+// (which has to be passed in the context with ContextWithMessageID).
+//
+// It wraps a(n) TodoEventHandler or another decorator of it.
+type IdempotentTodoEventHandler struct {
+	DecoratableEventHandlerGroup
+
+	store DedupStore
+}
+
+// NewIdempotentTodoEventHandler returns a new IdempotentTodoEventHandler instance.
+func NewIdempotentTodoEventHandler(handler DecoratableEventHandlerGroup, store DedupStore) IdempotentTodoEventHandler {
+	return IdempotentTodoEventHandler{
+		DecoratableEventHandlerGroup: handler,
+		store:                        store,
+	}
+}
+
+// Handle handles an event unless it has already been handled.
+func (h IdempotentTodoEventHandler) Handle(ctx context.Context, event interface{}) error {
+	var key string
+
+	switch e := event.(type) {
+	case *pkg.TodoCreated:
+		key = "TodoCreated/" + fmt.Sprint(e.ID)
+	case *pkg.TodoDone:
+		id, ok := MessageIDFromContext(ctx)
+		if !ok {
+			return errors.NewWithDetails("missing message ID", "event", "TodoDone")
+		}
+
+		key = "TodoDone/" + id
+	default:
+		return errors.NewWithDetails("unexpected event type", "type", fmt.Sprintf("%T", event))
+	}
+
+	key = h.HandlerName() + "/" + key
+
+	seen, err := h.store.Seen(ctx, key)
+	if err != nil {
+		return errors.WithDetails(errors.WithMessage(err, "failed to check if event has been handled"), "key", key)
+	}
+
+	if seen {
+		return nil
+	}
+
+	err = h.DecoratableEventHandlerGroup.Handle(ctx, event)
+	if err != nil {
+		return err
+	}
+
+	err = h.store.MarkSeen(ctx, key)
+	if err != nil {
+		return errors.WithDetails(errors.WithMessage(err, "failed to record handled event"), "key", key)
+	}
+
+	return nil
+}
+`
+
+	actual, err := Generate(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, expected, string(actual), "the generated code does not match the expected one")
+}
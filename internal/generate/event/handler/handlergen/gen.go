@@ -3,46 +3,153 @@ package handlergen
 import (
 	"fmt"
 	"go/ast"
+	"go/token"
 	"go/types"
-	"io"
 	"strings"
+	"time"
 
 	"sigs.k8s.io/controller-tools/pkg/genall"
 	"sigs.k8s.io/controller-tools/pkg/loader"
 	"sigs.k8s.io/controller-tools/pkg/markers"
 
 	"sagikazarmark.dev/mga/internal/generate/event/handler"
+	"sagikazarmark.dev/mga/pkg/generator"
 	"sagikazarmark.dev/mga/pkg/gentypes"
 	"sagikazarmark.dev/mga/pkg/genutils"
 )
 
 // nolint: gochecknoglobals
 var (
-	handlerMarker = markers.Must(markers.MakeDefinition("mga:event:handler", markers.DescribesType, struct{}{}))
+	// HandlerMarker enables event handler generation for an event struct.
+	HandlerMarker = markers.Must(markers.MakeDefinition("mga:event:handler", markers.DescribesType, Marker{}))
+
+	// VersionMarker marks an event struct as a version of an event.
+	VersionMarker = markers.Must(markers.MakeDefinition("mga:event:version", markers.DescribesType, 0))
+
+	// KeyMarker marks an event field as the key used by idempotent event handlers.
+	KeyMarker = markers.Must(markers.MakeDefinition("mga:event:key", markers.DescribesField, struct{}{}))
 )
 
-// Generator generates a Go kit Endpoint for a service.
-type Generator struct {
-	// HeaderFile specifies the header text (e.g. license) to prepend to generated files.
-	HeaderFile string `marker:",optional"`
+// +controllertools:marker:generateHelp:category=Event
+
+// Marker enables generating an event handler for an event struct.
+type Marker struct {
+	// Group generates a single handler for every event in the same group (instead of one handler per event).
+	//
+	// The group name is used as a prefix for the generated handler types, so it must be a valid Go identifier.
+	Group string `marker:"group,optional"`
+
+	// Instrumented tells the generator to write an Instrumented...EventHandler decorator
+	// recording metrics and traces (using OpenTelemetry) for handled events.
+	//
+	// A group is instrumented when any of its events is marked as instrumented.
+	Instrumented bool `marker:"instrumented,optional"`
+
+	// Idempotent tells the generator to write an Idempotent...EventHandler decorator
+	// handling each event at most once (using a deduplication store).
+	//
+	// Events are identified by their field marked with +mga:event:key
+	// or by the ID of the message they were received in.
+	//
+	// A group is idempotent when any of its events is marked as idempotent.
+	Idempotent bool `marker:"idempotent,optional"`
+
+	// MaxAttempts tells the generator to write a Retrying...EventHandler decorator
+	// handling each event at most the given number of times.
+	MaxAttempts int `marker:"maxAttempts,optional"`
+
+	// Backoff is the delay before the first retry (eg. 100ms). It is doubled after each attempt.
+	//
+	// It requires maxAttempts to be greater than 1.
+	Backoff string `marker:"backoff,optional"`
+
+	// DeadLetter is the destination (eg. topic) where events failing every attempt are sent to
+	// using a DeadLetterSink.
+	DeadLetter string `marker:"deadLetter,optional"`
+}
+
+// RetryPolicy returns the retry policy described by the marker (if any).
+func (m Marker) RetryPolicy() (*handler.RetryPolicy, error) {
+	if m.MaxAttempts == 0 && m.Backoff == "" && m.DeadLetter == "" {
+		return nil, nil
+	}
+
+	if m.MaxAttempts < 0 {
+		return nil, fmt.Errorf("invalid max attempts %d", m.MaxAttempts)
+	}
+
+	// events are never retried without multiple attempts
+	if m.Backoff != "" && m.MaxAttempts < 2 {
+		return nil, fmt.Errorf("backoff %q requires maxAttempts to be greater than 1", m.Backoff)
+	}
+
+	policy := handler.RetryPolicy{
+		MaxAttempts: m.MaxAttempts,
+		DeadLetter:  m.DeadLetter,
+	}
+
+	if policy.MaxAttempts == 0 {
+		policy.MaxAttempts = 1
+	}
+
+	if m.Backoff != "" {
+		backoff, err := time.ParseDuration(m.Backoff)
+		if err != nil {
+			return nil, fmt.Errorf("invalid backoff %q: %w", m.Backoff, err)
+		}
+
+		if backoff < 0 {
+			return nil, fmt.Errorf("invalid backoff %q: must not be negative", m.Backoff)
+		}
 
-	// Year specifies the year to substitute for " YEAR" in the header file.
-	Year string `marker:",optional"`
+		policy.Backoff = backoff
+	}
+
+	return &policy, nil
+}
+
+// Generator generates event handlers for events.
+type Generator struct {
+	generator.Base
 }
 
 func (g Generator) RegisterMarkers(into *markers.Registry) error {
-	if err := into.Register(handlerMarker); err != nil {
+	if err := into.Register(HandlerMarker); err != nil {
+		return err
+	}
+
+	into.AddHelp(HandlerMarker, Marker{}.Help())
+
+	if err := into.Register(VersionMarker); err != nil {
 		return err
 	}
 
 	into.AddHelp(
-		handlerMarker,
-		markers.SimpleHelp("Kit", "enables event handler generation for an event"),
+		VersionMarker,
+		markers.SimpleHelp("Event", "marks an event struct as a version of an event (older versions are upcasted to the handled one)"),
+	)
+
+	if err := into.Register(KeyMarker); err != nil {
+		return err
+	}
+
+	into.AddHelp(
+		KeyMarker,
+		markers.SimpleHelp("Event", "marks an event field as the key used by idempotent event handlers"),
 	)
 
 	return nil
 }
 
+// MarkerKinds restricts markers to the kinds of declarations the generator handles.
+func (Generator) MarkerKinds() map[string]string {
+	return map[string]string{
+		HandlerMarker.Name: genutils.StructKind,
+		VersionMarker.Name: genutils.StructKind,
+		KeyMarker.Name:     genutils.FieldKind,
+	}
+}
+
 func (Generator) CheckFilter() loader.NodeFilter {
 	return func(node ast.Node) bool {
 		// ignore non-interfaces
@@ -52,19 +159,21 @@ func (Generator) CheckFilter() loader.NodeFilter {
 	}
 }
 
-func (g Generator) Generate(ctx *genall.GenerationContext) error {
-	var headerText string
-
-	if g.HeaderFile != "" {
-		headerBytes, err := ctx.ReadFile(g.HeaderFile)
-		if err != nil {
-			return err
-		}
+// OutputFiles returns the names of the files written by the generator.
+func (g Generator) OutputFiles() []string {
+	return g.OutputFilePatterns("event_handler")
+}
 
-		headerText = string(headerBytes)
+func (g Generator) Generate(ctx *genall.GenerationContext) error {
+	headerText, err := g.HeaderText(ctx)
+	if err != nil {
+		return err
 	}
 
-	headerText = strings.ReplaceAll(headerText, " YEAR", " "+g.Year)
+	fileName, err := g.OutputFileName("event_handler", "")
+	if err != nil {
+		return err
+	}
 
 	for _, root := range ctx.Roots {
 		outContents := g.generatePackage(ctx, headerText, root)
@@ -72,7 +181,7 @@ func (g Generator) Generate(ctx *genall.GenerationContext) error {
 			continue
 		}
 
-		writeOut(ctx, root, outContents)
+		generator.WriteFile(ctx, root, fileName, outContents)
 	}
 
 	return nil
@@ -83,13 +192,61 @@ func (g Generator) generatePackage(ctx *genall.GenerationContext, headerText str
 
 	root.NeedTypesInfo()
 
-	var eventHandlers []handler.EventHandler
+	type handledEvent struct {
+		event   handler.Event
+		version int
+		marker  Marker
+		spec    *ast.TypeSpec
+		retry   *handler.RetryPolicy
+	}
+
+	var handledEvents []handledEvent
+	families := map[string]handler.EventVersions{}
+	keyFields := map[string]string{}
 
 	err := markers.EachType(ctx.Collector, root, func(info *markers.TypeInfo) {
-		if marker := info.Markers.Get(handlerMarker.Name); marker == nil {
+		marker, isHandler := info.Markers.Get(HandlerMarker.Name).(Marker)
+		version, isVersion := info.Markers.Get(VersionMarker.Name).(int)
+
+		if !isHandler && !isVersion {
 			return
 		}
 
+		if isHandler && marker.Group != "" && !token.IsIdentifier(marker.Group) {
+			root.AddError(loader.ErrFromNode(
+				fmt.Errorf("invalid event handler group %q of event %s: must be a valid Go identifier", marker.Group, info.Name),
+				markerComment(info, HandlerMarker.Name),
+			))
+
+			return
+		}
+
+		for _, field := range info.Fields {
+			if field.Markers.Get(KeyMarker.Name) == nil {
+				continue
+			}
+
+			if other, ok := keyFields[info.Name]; ok {
+				root.AddError(loader.ErrFromNode(
+					fmt.Errorf("event %s has multiple key fields: %s and %s", info.Name, other, field.Name),
+					field.RawField,
+				))
+
+				continue
+			}
+
+			if !ast.IsExported(field.Name) {
+				root.AddError(loader.ErrFromNode(
+					fmt.Errorf("key field %s of event %s must be exported", field.Name, info.Name),
+					field.RawField,
+				))
+
+				continue
+			}
+
+			keyFields[info.Name] = field.Name
+		}
+
 		typeInfo := root.TypesInfo.TypeOf(info.RawSpec.Name)
 		if typeInfo == types.Typ[types.Invalid] {
 			root.AddError(loader.ErrFromNode(fmt.Errorf("unknown type %s", info.Name), info.RawSpec))
@@ -104,7 +261,38 @@ func (g Generator) generatePackage(ctx *genall.GenerationContext, headerText str
 			return
 		}
 
-		eventHandlers = append(eventHandlers, handler.EventHandlerFromEvent(event))
+		if isVersion {
+			if version < 1 {
+				root.AddError(loader.ErrFromNode(fmt.Errorf("invalid version %d of event %s", version, info.Name), info.RawSpec))
+
+				return
+			}
+
+			family := handler.EventFamily(event.Name, version)
+			if families[family] == nil {
+				families[family] = handler.EventVersions{}
+			}
+
+			if other, ok := families[family][version]; ok {
+				root.AddError(loader.ErrFromNode(
+					fmt.Errorf("events %s and %s share the same version %d", other.Name, event.Name, version),
+					info.RawSpec,
+				))
+
+				return
+			}
+
+			families[family][version] = event
+		}
+
+		if isHandler {
+			handledEvents = append(handledEvents, handledEvent{
+				event:   event,
+				version: version,
+				marker:  marker,
+				spec:    info.RawSpec,
+			})
+		}
 	})
 	if err != nil {
 		root.AddError(err)
@@ -112,24 +300,111 @@ func (g Generator) generatePackage(ctx *genall.GenerationContext, headerText str
 		return nil
 	}
 
-	if len(eventHandlers) == 0 {
+	var eventHandlers []handler.EventHandler
+	var groups []string
+	groupedEventHandlers := map[string][]handler.EventHandler{}
+
+	// the first event of each group (every event in a group has to share its retry policy)
+	groupFirsts := map[string]handledEvent{}
+
+	for _, handledEvent := range handledEvents {
+		eventHandler := handler.EventHandlerFromEvent(handledEvent.event)
+
+		upcasts, err := handler.UpcastChain(
+			handledEvent.event,
+			handledEvent.version,
+			families[handler.EventFamily(handledEvent.event.Name, handledEvent.version)],
+		)
+		if err != nil {
+			root.AddError(loader.ErrFromNode(err, handledEvent.spec))
+
+			continue
+		}
+
+		retry, err := handledEvent.marker.RetryPolicy()
+		if err != nil {
+			root.AddError(loader.ErrFromNode(err, handledEvent.spec))
+
+			continue
+		}
+
+		eventHandler.Upcasts = upcasts
+		eventHandler.Retry = retry
+		eventHandler.Instrumented = handledEvent.marker.Instrumented
+		eventHandler.Idempotent = handledEvent.marker.Idempotent
+
+		if eventHandler.Idempotent || handledEvent.marker.Group != "" {
+			eventHandler.KeyFields = map[string]string{}
+
+			for _, event := range append(upcasts, handledEvent.event) {
+				if keyField, ok := keyFields[event.Name]; ok {
+					eventHandler.KeyFields[event.Name] = keyField
+				}
+			}
+		}
+
+		if group := handledEvent.marker.Group; group != "" {
+			other, ok := groupFirsts[group]
+			if ok && !sameRetryPolicy(other.retry, retry) {
+				root.AddError(loader.ErrFromNode(
+					fmt.Errorf(
+						"retry policy of event %s conflicts with the one of event %s in event handler group %s",
+						handledEvent.event.Name, other.event.Name, group,
+					),
+					handledEvent.spec,
+				))
+
+				continue
+			}
+
+			if !ok {
+				handledEvent.retry = retry
+				groupFirsts[group] = handledEvent
+			}
+
+			if _, ok := groupedEventHandlers[group]; !ok {
+				groups = append(groups, group)
+			}
+
+			groupedEventHandlers[group] = append(groupedEventHandlers[group], eventHandler)
+
+			continue
+		}
+
+		eventHandlers = append(eventHandlers, eventHandler)
+	}
+
+	if len(eventHandlers) == 0 && len(groups) == 0 {
 		return nil
 	}
 
-	packageName, packagePath := root.Name, root.PkgPath
-	if pkgrefer, ok := ctx.OutputRule.(genutils.PackageRefer); ok {
-		packageName, packagePath = pkgrefer.PackageRef(root)
+	eventHandlerGroups := make([]handler.EventHandlerGroup, 0, len(groups))
+
+	for _, group := range groups {
+		eventHandlerGroup := handler.EventHandlerGroup{
+			Name:          group,
+			EventHandlers: groupedEventHandlers[group],
+		}
+
+		for _, eventHandler := range eventHandlerGroup.EventHandlers {
+			eventHandlerGroup.Instrumented = eventHandlerGroup.Instrumented || eventHandler.Instrumented
+			eventHandlerGroup.Idempotent = eventHandlerGroup.Idempotent || eventHandler.Idempotent
+		}
+
+		eventHandlerGroup.Retry = groupFirsts[group].retry
+
+		eventHandlerGroups = append(eventHandlerGroups, eventHandlerGroup)
 	}
 
+	outputPackage := generator.OutputPackage(ctx, root)
+
 	file := handler.File{
 		File: gentypes.File{
-			Package: gentypes.PackageRef{
-				Name: packageName,
-				Path: packagePath,
-			},
+			Package:    outputPackage,
 			HeaderText: headerText,
 		},
-		EventHandlers: eventHandlers,
+		EventHandlers:      eventHandlers,
+		EventHandlerGroups: eventHandlerGroups,
 	}
 
 	outContents, err := handler.Generate(file)
@@ -142,22 +417,30 @@ func (g Generator) generatePackage(ctx *genall.GenerationContext, headerText str
 	return outContents
 }
 
-// writeOut outputs the given code.
-func writeOut(ctx *genall.GenerationContext, root *loader.Package, outBytes []byte) {
-	outputFile, err := ctx.Open(root, "zz_generated.event_handler.go")
-	if err != nil {
-		root.AddError(err)
+// markerComment returns the comment a marker is declared in (or the type spec if it cannot be found).
+func markerComment(info *markers.TypeInfo, name string) ast.Node {
+	for _, doc := range []*ast.CommentGroup{info.RawSpec.Doc, info.RawDecl.Doc} {
+		if doc == nil {
+			continue
+		}
 
-		return
-	}
-	defer outputFile.Close()
-	n, err := outputFile.Write(outBytes)
-	if err != nil {
-		root.AddError(err)
+		for _, comment := range doc.List {
+			text := strings.TrimSpace(strings.TrimPrefix(comment.Text, "//"))
 
-		return
+			if text == "+"+name || strings.HasPrefix(text, "+"+name+":") {
+				return comment
+			}
+		}
 	}
-	if n < len(outBytes) {
-		root.AddError(io.ErrShortWrite)
+
+	return info.RawSpec
+}
+
+// sameRetryPolicy reports whether two retry policies are the same (nil meaning no retry policy).
+func sameRetryPolicy(a *handler.RetryPolicy, b *handler.RetryPolicy) bool {
+	if a == nil || b == nil {
+		return a == b
 	}
+
+	return *a == *b
 }
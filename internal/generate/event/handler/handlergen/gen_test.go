@@ -0,0 +1,13 @@
+package handlergen
+
+import (
+	"testing"
+
+	"sagikazarmark.dev/mga/pkg/genutils/gentest"
+)
+
+func TestGenerator_Errors(t *testing.T) {
+	gentest.RunFile(t, "testdata/errors.txtar", gentest.Test{
+		Generator: Generator{},
+	})
+}
@@ -0,0 +1,12 @@
+package test
+
+// +mga:event:handler:group=Todo,instrumented=true
+type TodoCreated struct {
+	ID   string
+	Text string
+}
+
+// +mga:event:handler:group=Todo
+type TodoDone struct {
+	ID string
+}
@@ -0,0 +1,74 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type todoHandlerStub struct {
+	NoopTodoHandler
+
+	ctx   context.Context
+	event TodoCreated
+}
+
+func (s *todoHandlerStub) TodoCreated(ctx context.Context, event TodoCreated) error {
+	s.ctx = ctx
+	s.event = event
+
+	return nil
+}
+
+func TestTodoEventHandler_HandlerName(t *testing.T) {
+	handler := NewTodoEventHandler(&todoHandlerStub{}, "todo_handler")
+
+	name := handler.HandlerName()
+
+	assert.Equal(t, "todo_handler", name)
+}
+
+func TestTodoEventHandler_NewEvents(t *testing.T) {
+	handler := NewTodoEventHandler(&todoHandlerStub{}, "todo_handler")
+
+	events := handler.NewEvents()
+
+	assert.Equal(t, []interface{}{&TodoCreated{}, &TodoDone{}}, events)
+}
+
+func TestTodoEventHandler_Handle(t *testing.T) {
+	h := &todoHandlerStub{}
+	handler := NewTodoEventHandler(h, "todo_handler")
+
+	ctx := context.Background()
+	event := TodoCreated{
+		ID:   "1234",
+		Text: "Do something",
+	}
+
+	err := handler.Handle(ctx, &event)
+	require.NoError(t, err)
+
+	assert.Equal(t, h.ctx, ctx)
+	assert.Equal(t, h.event, event)
+}
+
+func TestTodoEventHandler_Handle_Noop(t *testing.T) {
+	h := &todoHandlerStub{}
+	handler := NewTodoEventHandler(h, "todo_handler")
+
+	err := handler.Handle(context.Background(), &TodoDone{ID: "1234"})
+	require.NoError(t, err)
+
+	assert.Nil(t, h.ctx)
+}
+
+func TestTodoEventHandler_Handle_UnexpectedEvent(t *testing.T) {
+	handler := NewTodoEventHandler(&todoHandlerStub{}, "todo_handler")
+
+	err := handler.Handle(context.Background(), &Event{})
+
+	assert.EqualError(t, err, "unexpected event type")
+}
@@ -0,0 +1,12 @@
+package test
+
+// +mga:event:handler:idempotent=true
+type TodoDeleted struct {
+	// +mga:event:key
+	ID string
+}
+
+// +mga:event:handler:idempotent=true
+type TodoRestored struct {
+	ID string
+}
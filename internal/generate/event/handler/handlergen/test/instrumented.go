@@ -0,0 +1,6 @@
+package test
+
+// +mga:event:handler:instrumented=true
+type TodoArchived struct {
+	ID string
+}
@@ -0,0 +1,166 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"emperror.dev/errors"
+	"github.com/ThreeDotsLabs/watermill/components/cqrs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type todoArchivedHandlerStub struct {
+	ctx   context.Context
+	event TodoArchived
+	err   error
+}
+
+func (s *todoArchivedHandlerStub) TodoArchived(ctx context.Context, event TodoArchived) error {
+	s.ctx = ctx
+	s.event = event
+
+	return s.err
+}
+
+func collectSum(t *testing.T, reader *sdkmetric.ManualReader, name string) int64 {
+	var rm metricdata.ResourceMetrics
+
+	err := reader.Collect(context.Background(), &rm)
+	require.NoError(t, err)
+
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name != name {
+				continue
+			}
+
+			var sum int64
+
+			for _, dp := range m.Data.(metricdata.Sum[int64]).DataPoints {
+				sum += dp.Value
+			}
+
+			return sum
+		}
+	}
+
+	return 0
+}
+
+func setUpInstrumentedTodoArchivedEventHandler(
+	t *testing.T,
+	h TodoArchivedHandler,
+) (InstrumentedTodoArchivedEventHandler, *tracetest.SpanRecorder, *sdkmetric.ManualReader) {
+	spans := tracetest.NewSpanRecorder()
+	metrics := sdkmetric.NewManualReader()
+
+	handler, err := NewInstrumentedTodoArchivedEventHandler(
+		NewTodoArchivedEventHandler(h, "todo_archived_handler"),
+		sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)),
+		sdkmetric.NewMeterProvider(sdkmetric.WithReader(metrics)),
+		propagation.TraceContext{},
+	)
+	require.NoError(t, err)
+
+	return handler, spans, metrics
+}
+
+func TestInstrumentedTodoArchivedEventHandler(t *testing.T) {
+	handler, _, _ := setUpInstrumentedTodoArchivedEventHandler(t, &todoArchivedHandlerStub{})
+
+	assert.Implements(t, (*cqrs.EventHandler)(nil), handler)
+}
+
+func TestInstrumentedTodoArchivedEventHandler_HandleWithMetadata(t *testing.T) {
+	h := &todoArchivedHandlerStub{}
+	handler, spans, metrics := setUpInstrumentedTodoArchivedEventHandler(t, h)
+
+	traceID, err := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	require.NoError(t, err)
+
+	metadata := map[string]string{
+		"traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+	}
+
+	event := TodoArchived{
+		ID: "1234",
+	}
+
+	err = handler.HandleWithMetadata(context.Background(), &event, metadata)
+	require.NoError(t, err)
+
+	assert.Equal(t, event, h.event)
+
+	require.Len(t, spans.Ended(), 1)
+
+	span := spans.Ended()[0]
+
+	assert.Equal(t, "handle TodoArchived", span.Name())
+	assert.Equal(t, trace.SpanKindConsumer, span.SpanKind())
+	assert.Equal(t, traceID, span.SpanContext().TraceID())
+	assert.Equal(t, traceID, trace.SpanContextFromContext(h.ctx).TraceID())
+
+	assert.Equal(t, int64(1), collectSum(t, metrics, "event.handled"))
+	assert.Equal(t, int64(0), collectSum(t, metrics, "event.handle.errors"))
+}
+
+func TestInstrumentedTodoArchivedEventHandler_Handle_ContextWithMetadata(t *testing.T) {
+	h := &todoArchivedHandlerStub{}
+	handler, spans, _ := setUpInstrumentedTodoArchivedEventHandler(t, h)
+
+	traceID, err := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	require.NoError(t, err)
+
+	// Set by a message handler middleware in the event processor (eg. from Watermill message metadata)
+	ctx := ContextWithMetadata(context.Background(), map[string]string{
+		"traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+	})
+
+	err = handler.Handle(ctx, &TodoArchived{ID: "1234"})
+	require.NoError(t, err)
+
+	require.Len(t, spans.Ended(), 1)
+	assert.Equal(t, traceID, spans.Ended()[0].SpanContext().TraceID())
+	assert.Equal(t, traceID, trace.SpanContextFromContext(h.ctx).TraceID())
+}
+
+func TestInstrumentedTodoArchivedEventHandler_Handle_Error(t *testing.T) {
+	failure := errors.NewPlain("error")
+	handler, spans, metrics := setUpInstrumentedTodoArchivedEventHandler(t, &todoArchivedHandlerStub{err: failure})
+
+	err := handler.Handle(context.Background(), &TodoArchived{ID: "1234"})
+
+	assert.Equal(t, failure, err)
+
+	require.Len(t, spans.Ended(), 1)
+	assert.Equal(t, codes.Error, spans.Ended()[0].Status().Code)
+
+	assert.Equal(t, int64(1), collectSum(t, metrics, "event.handled"))
+	assert.Equal(t, int64(1), collectSum(t, metrics, "event.handle.errors"))
+}
+
+func TestInstrumentedTodoEventHandler_Handle(t *testing.T) {
+	spans := tracetest.NewSpanRecorder()
+
+	handler, err := NewInstrumentedTodoEventHandler(
+		NewTodoEventHandler(&todoHandlerStub{}, "todo_handler"),
+		sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)),
+		sdkmetric.NewMeterProvider(),
+		propagation.TraceContext{},
+	)
+	require.NoError(t, err)
+
+	err = handler.Handle(context.Background(), &TodoDone{ID: "1234"})
+	require.NoError(t, err)
+
+	require.Len(t, spans.Ended(), 1)
+	assert.Equal(t, "handle TodoDone", spans.Ended()[0].Name())
+}
@@ -0,0 +1,17 @@
+package test
+
+// +mga:event:handler:maxAttempts=3,backoff=1ms,deadLetter="todo.dead_letter"
+type TodoPurged struct {
+	ID string
+}
+
+// +mga:event:handler:maxAttempts=2
+type TodoPinned struct {
+	ID string
+}
+
+// +mga:event:handler:idempotent=true,maxAttempts=3
+type TodoUnpinned struct {
+	// +mga:event:key
+	ID string
+}
@@ -0,0 +1,170 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/ThreeDotsLabs/watermill/components/cqrs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type todoPurgedHandlerStub struct {
+	calls    int
+	failures int
+}
+
+func (s *todoPurgedHandlerStub) TodoPurged(_ context.Context, _ TodoPurged) error {
+	s.calls++
+
+	if s.calls <= s.failures {
+		return errors.New("error")
+	}
+
+	return nil
+}
+
+type todoPinnedHandlerStub struct {
+	calls int
+}
+
+func (s *todoPinnedHandlerStub) TodoPinned(_ context.Context, _ TodoPinned) error {
+	s.calls++
+
+	return errors.New("error")
+}
+
+type deadLetterSinkStub struct {
+	destination string
+	event       interface{}
+	err         error
+
+	sinkErr error
+}
+
+func (s *deadLetterSinkStub) DeadLetter(_ context.Context, destination string, event interface{}, err error) error {
+	s.destination = destination
+	s.event = event
+	s.err = err
+
+	return s.sinkErr
+}
+
+func TestRetryingTodoPurgedEventHandler(t *testing.T) {
+	handler := NewRetryingTodoPurgedEventHandler(
+		NewTodoPurgedEventHandler(&todoPurgedHandlerStub{}, "todo_purged"),
+		&deadLetterSinkStub{},
+	)
+
+	assert.Implements(t, (*cqrs.EventHandler)(nil), handler)
+	assert.Equal(t, "todo_purged", handler.HandlerName())
+}
+
+func TestRetryingTodoPurgedEventHandler_Handle(t *testing.T) {
+	h := &todoPurgedHandlerStub{failures: 2}
+	sink := &deadLetterSinkStub{}
+	handler := NewRetryingTodoPurgedEventHandler(NewTodoPurgedEventHandler(h, "todo_purged"), sink)
+
+	err := handler.Handle(context.Background(), &TodoPurged{ID: "1234"})
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, h.calls)
+	assert.Nil(t, sink.event)
+}
+
+func TestRetryingTodoPurgedEventHandler_Handle_DeadLetter(t *testing.T) {
+	h := &todoPurgedHandlerStub{failures: 5}
+	sink := &deadLetterSinkStub{}
+	handler := NewRetryingTodoPurgedEventHandler(NewTodoPurgedEventHandler(h, "todo_purged"), sink)
+
+	event := &TodoPurged{ID: "1234"}
+
+	err := handler.Handle(context.Background(), event)
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, h.calls)
+	assert.Equal(t, "todo.dead_letter", sink.destination)
+	assert.Equal(t, event, sink.event)
+	require.Error(t, sink.err)
+	assert.Equal(t, []interface{}{"handler", "todo_purged", "attempts", 3}, errors.GetDetails(sink.err))
+}
+
+func TestRetryingTodoPurgedEventHandler_Handle_DeadLetterError(t *testing.T) {
+	h := &todoPurgedHandlerStub{failures: 5}
+	sink := &deadLetterSinkStub{sinkErr: errors.New("sink error")}
+	handler := NewRetryingTodoPurgedEventHandler(NewTodoPurgedEventHandler(h, "todo_purged"), sink)
+
+	err := handler.Handle(context.Background(), &TodoPurged{ID: "1234"})
+	require.Error(t, err)
+
+	assert.Len(t, errors.GetErrors(err), 2)
+}
+
+func TestRetryingTodoPurgedEventHandler_Handle_Canceled(t *testing.T) {
+	h := &todoPurgedHandlerStub{failures: 5}
+	sink := &deadLetterSinkStub{}
+	handler := NewRetryingTodoPurgedEventHandler(NewTodoPurgedEventHandler(h, "todo_purged"), sink)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := handler.Handle(ctx, &TodoPurged{ID: "1234"})
+	require.Error(t, err)
+
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.Equal(t, 1, h.calls)
+	assert.Nil(t, sink.event)
+}
+
+func TestRetryingTodoPinnedEventHandler_Handle(t *testing.T) {
+	h := &todoPinnedHandlerStub{}
+	handler := NewRetryingTodoPinnedEventHandler(NewTodoPinnedEventHandler(h, "todo_pinned"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	err := handler.Handle(ctx, &TodoPinned{ID: "1234"})
+	require.Error(t, err)
+
+	assert.Equal(t, 2, h.calls)
+	assert.Equal(t, []interface{}{"handler", "todo_pinned", "attempts", 2}, errors.GetDetails(err))
+}
+
+type todoUnpinnedHandlerStub struct {
+	calls    int
+	failures int
+}
+
+func (s *todoUnpinnedHandlerStub) TodoUnpinned(_ context.Context, _ TodoUnpinned) error {
+	s.calls++
+
+	if s.calls <= s.failures {
+		return errors.New("error")
+	}
+
+	return nil
+}
+
+func TestRetryingTodoUnpinnedEventHandler_Decorated(t *testing.T) {
+	h := &todoUnpinnedHandlerStub{failures: 2}
+
+	// decorators wrap each other: retries happen before the event is marked as handled
+	handler := NewIdempotentTodoUnpinnedEventHandler(
+		NewRetryingTodoUnpinnedEventHandler(NewTodoUnpinnedEventHandler(h, "todo_unpinned")),
+		NewInMemoryDedupStore(time.Minute),
+	)
+
+	assert.Implements(t, (*cqrs.EventHandler)(nil), handler)
+	assert.Equal(t, "todo_unpinned", handler.HandlerName())
+	assert.Equal(t, &TodoUnpinned{}, handler.NewEvent())
+
+	err := handler.Handle(context.Background(), &TodoUnpinned{ID: "1234"})
+	require.NoError(t, err)
+
+	err = handler.Handle(context.Background(), &TodoUnpinned{ID: "1234"})
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, h.calls)
+}
@@ -5,3 +5,25 @@ import (
 )
 
 type Event = test.Event
+
+type TodoCreated = test.TodoCreated
+
+type TodoDone = test.TodoDone
+
+type TodoRenamedV1 = test.TodoRenamedV1
+
+type TodoRenamedV2 = test.TodoRenamedV2
+
+type TodoRenamed = test.TodoRenamed
+
+type TodoArchived = test.TodoArchived
+
+type TodoDeleted = test.TodoDeleted
+
+type TodoRestored = test.TodoRestored
+
+type TodoPurged = test.TodoPurged
+
+type TodoPinned = test.TodoPinned
+
+type TodoUnpinned = test.TodoUnpinned
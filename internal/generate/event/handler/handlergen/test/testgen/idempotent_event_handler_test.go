@@ -0,0 +1,167 @@
+package testgen
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/ThreeDotsLabs/watermill/components/cqrs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type todoDeletedHandlerStub struct {
+	calls int
+	err   error
+}
+
+func (s *todoDeletedHandlerStub) TodoDeleted(_ context.Context, _ TodoDeleted) error {
+	s.calls++
+
+	return s.err
+}
+
+type todoRestoredHandlerStub struct {
+	calls int
+}
+
+func (s *todoRestoredHandlerStub) TodoRestored(_ context.Context, _ TodoRestored) error {
+	s.calls++
+
+	return nil
+}
+
+type dedupStoreStub struct {
+	err error
+}
+
+func (s dedupStoreStub) Seen(_ context.Context, _ string) (bool, error) {
+	return false, s.err
+}
+
+func (s dedupStoreStub) MarkSeen(_ context.Context, _ string) error {
+	return s.err
+}
+
+func TestIdempotentTodoDeletedEventHandler(t *testing.T) {
+	handler := NewIdempotentTodoDeletedEventHandler(
+		NewTodoDeletedEventHandler(&todoDeletedHandlerStub{}, "todo_deleted"),
+		NewInMemoryDedupStore(time.Minute),
+	)
+
+	assert.Implements(t, (*cqrs.EventHandler)(nil), handler)
+	assert.Equal(t, "todo_deleted", handler.HandlerName())
+}
+
+func TestIdempotentTodoDeletedEventHandler_Handle(t *testing.T) {
+	h := &todoDeletedHandlerStub{}
+	handler := NewIdempotentTodoDeletedEventHandler(
+		NewTodoDeletedEventHandler(h, "todo_deleted"),
+		NewInMemoryDedupStore(time.Minute),
+	)
+
+	ctx := context.Background()
+
+	require.NoError(t, handler.Handle(ctx, &TodoDeleted{ID: "1234"}))
+	require.NoError(t, handler.Handle(ctx, &TodoDeleted{ID: "1234"}))
+	require.NoError(t, handler.Handle(ctx, &TodoDeleted{ID: "5678"}))
+
+	assert.Equal(t, 2, h.calls)
+}
+
+func TestIdempotentTodoDeletedEventHandler_Handle_Error(t *testing.T) {
+	h := &todoDeletedHandlerStub{err: errors.New("error")}
+	handler := NewIdempotentTodoDeletedEventHandler(
+		NewTodoDeletedEventHandler(h, "todo_deleted"),
+		NewInMemoryDedupStore(time.Minute),
+	)
+
+	ctx := context.Background()
+
+	require.Error(t, handler.Handle(ctx, &TodoDeleted{ID: "1234"}))
+
+	h.err = nil
+
+	require.NoError(t, handler.Handle(ctx, &TodoDeleted{ID: "1234"}))
+
+	assert.Equal(t, 2, h.calls, "failed events should be handled again")
+}
+
+func TestIdempotentTodoDeletedEventHandler_Handle_StoreError(t *testing.T) {
+	h := &todoDeletedHandlerStub{}
+	handler := NewIdempotentTodoDeletedEventHandler(
+		NewTodoDeletedEventHandler(h, "todo_deleted"),
+		dedupStoreStub{err: errors.New("error")},
+	)
+
+	err := handler.Handle(context.Background(), &TodoDeleted{ID: "1234"})
+	require.Error(t, err)
+
+	assert.Equal(t, []interface{}{"key", "todo_deleted/TodoDeleted/1234"}, errors.GetDetails(err))
+	assert.Equal(t, 0, h.calls)
+}
+
+func TestIdempotentTodoDeletedEventHandler_Handle_Expired(t *testing.T) {
+	h := &todoDeletedHandlerStub{}
+	handler := NewIdempotentTodoDeletedEventHandler(
+		NewTodoDeletedEventHandler(h, "todo_deleted"),
+		NewInMemoryDedupStore(10*time.Millisecond),
+	)
+
+	ctx := context.Background()
+
+	require.NoError(t, handler.Handle(ctx, &TodoDeleted{ID: "1234"}))
+
+	time.Sleep(20 * time.Millisecond)
+
+	require.NoError(t, handler.Handle(ctx, &TodoDeleted{ID: "1234"}))
+
+	assert.Equal(t, 2, h.calls)
+}
+
+func TestInMemoryDedupStore_MarkSeen_Sweep(t *testing.T) {
+	store := NewInMemoryDedupStore(10 * time.Millisecond)
+
+	ctx := context.Background()
+
+	require.NoError(t, store.MarkSeen(ctx, "1"))
+	require.NoError(t, store.MarkSeen(ctx, "2"))
+
+	assert.Len(t, store.keys, 2)
+
+	time.Sleep(20 * time.Millisecond)
+
+	require.NoError(t, store.MarkSeen(ctx, "3"))
+
+	assert.Len(t, store.keys, 1, "expired keys should be removed once the TTL has passed")
+}
+
+func TestIdempotentTodoRestoredEventHandler_Handle(t *testing.T) {
+	h := &todoRestoredHandlerStub{}
+	handler := NewIdempotentTodoRestoredEventHandler(
+		NewTodoRestoredEventHandler(h, "todo_restored"),
+		NewInMemoryDedupStore(time.Minute),
+	)
+
+	ctx := ContextWithMessageID(context.Background(), "message-1")
+
+	require.NoError(t, handler.Handle(ctx, &TodoRestored{ID: "1234"}))
+	require.NoError(t, handler.Handle(ctx, &TodoRestored{ID: "1234"}))
+	require.NoError(t, handler.Handle(ContextWithMessageID(context.Background(), "message-2"), &TodoRestored{ID: "1234"}))
+
+	assert.Equal(t, 2, h.calls)
+}
+
+func TestIdempotentTodoRestoredEventHandler_Handle_MissingMessageID(t *testing.T) {
+	h := &todoRestoredHandlerStub{}
+	handler := NewIdempotentTodoRestoredEventHandler(
+		NewTodoRestoredEventHandler(h, "todo_restored"),
+		NewInMemoryDedupStore(time.Minute),
+	)
+
+	err := handler.Handle(context.Background(), &TodoRestored{ID: "1234"})
+	require.Error(t, err)
+
+	assert.Equal(t, 0, h.calls)
+}
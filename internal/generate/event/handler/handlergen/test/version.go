@@ -0,0 +1,21 @@
+package test
+
+// +mga:event:version=1
+type TodoRenamedV1 struct {
+	ID   string
+	Name string
+}
+
+// +mga:event:version=2
+type TodoRenamedV2 struct {
+	ID    string
+	Title string
+}
+
+// TodoRenamed is the latest (third) version of the event.
+// +mga:event:handler
+type TodoRenamed struct {
+	ID     string
+	Title  string
+	Reason string
+}
@@ -0,0 +1,146 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"emperror.dev/errors"
+	"github.com/ThreeDotsLabs/watermill/components/cqrs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type todoRenamedHandlerStub struct {
+	event TodoRenamed
+}
+
+func (s *todoRenamedHandlerStub) TodoRenamed(_ context.Context, event TodoRenamed) error {
+	s.event = event
+
+	return nil
+}
+
+type todoRenamedUpcasterStub struct {
+	err error
+}
+
+func (s todoRenamedUpcasterStub) UpcastTodoRenamedV1(_ context.Context, event TodoRenamedV1) (TodoRenamedV2, error) {
+	return TodoRenamedV2{ID: event.ID, Title: event.Name}, s.err
+}
+
+func (s todoRenamedUpcasterStub) UpcastTodoRenamedV2(_ context.Context, event TodoRenamedV2) (TodoRenamed, error) {
+	return TodoRenamed{ID: event.ID, Title: event.Title, Reason: "unknown"}, s.err
+}
+
+func TestTodoRenamedEventHandler_NewEvent(t *testing.T) {
+	handler := NewTodoRenamedEventHandler(&todoRenamedHandlerStub{}, todoRenamedUpcasterStub{}, "todo_renamed_handler")
+
+	event := handler.NewEvent()
+
+	assert.IsType(t, &TodoRenamed{}, event)
+}
+
+func TestTodoRenamedEventHandler_Handle(t *testing.T) {
+	h := &todoRenamedHandlerStub{}
+	handler := NewTodoRenamedEventHandler(h, todoRenamedUpcasterStub{}, "todo_renamed_handler")
+
+	event := TodoRenamed{
+		ID:     "1234",
+		Title:  "Do something",
+		Reason: "typo",
+	}
+
+	err := handler.Handle(context.Background(), &event)
+	require.NoError(t, err)
+
+	assert.Equal(t, event, h.event)
+}
+
+func TestTodoRenamedEventHandler_Handle_Upcast(t *testing.T) {
+	h := &todoRenamedHandlerStub{}
+	handler := NewTodoRenamedEventHandler(h, todoRenamedUpcasterStub{}, "todo_renamed_handler")
+
+	event := TodoRenamedV1{
+		ID:   "1234",
+		Name: "Do something",
+	}
+
+	err := handler.Handle(context.Background(), &event)
+	require.NoError(t, err)
+
+	expected := TodoRenamed{
+		ID:     "1234",
+		Title:  "Do something",
+		Reason: "unknown",
+	}
+
+	assert.Equal(t, expected, h.event)
+}
+
+func TestTodoRenamedEventHandler_Handle_UpcastError(t *testing.T) {
+	failure := errors.NewPlain("error")
+	handler := NewTodoRenamedEventHandler(&todoRenamedHandlerStub{}, todoRenamedUpcasterStub{err: failure}, "todo_renamed_handler")
+
+	err := handler.Handle(context.Background(), &TodoRenamedV2{ID: "1234"})
+
+	assert.Equal(t, failure, errors.Cause(err))
+}
+
+func TestTodoRenamedEventHandler_VersionHandlers(t *testing.T) {
+	handler := NewTodoRenamedEventHandler(&todoRenamedHandlerStub{}, todoRenamedUpcasterStub{}, "todo_renamed_handler")
+
+	versionHandlers := handler.VersionHandlers()
+	require.Len(t, versionHandlers, 2)
+
+	assert.Implements(t, (*cqrs.EventHandler)(nil), versionHandlers[0])
+
+	assert.Equal(t, "todo_renamed_handler_v1", versionHandlers[0].HandlerName())
+	assert.IsType(t, &TodoRenamedV1{}, versionHandlers[0].NewEvent())
+
+	assert.Equal(t, "todo_renamed_handler_v2", versionHandlers[1].HandlerName())
+	assert.IsType(t, &TodoRenamedV2{}, versionHandlers[1].NewEvent())
+}
+
+func TestTodoRenamedEventHandler_VersionHandlers_Marshaler(t *testing.T) {
+	h := &todoRenamedHandlerStub{}
+	handler := NewTodoRenamedEventHandler(h, todoRenamedUpcasterStub{}, "todo_renamed_handler")
+
+	handlers := []cqrs.EventHandler{handler}
+	for _, versionHandler := range handler.VersionHandlers() {
+		handlers = append(handlers, versionHandler)
+	}
+
+	marshaler := cqrs.JSONMarshaler{}
+
+	msg, err := marshaler.Marshal(&TodoRenamedV1{ID: "1234", Name: "Do something"})
+	require.NoError(t, err)
+
+	// Dispatch the message the way Watermill's CQRS event processor does: by the name of the event returned by NewEvent
+	var handled int
+
+	for _, handler := range handlers {
+		if marshaler.Name(handler.NewEvent()) != marshaler.NameFromMessage(msg) {
+			continue
+		}
+
+		event := handler.NewEvent()
+
+		err := marshaler.Unmarshal(msg, event)
+		require.NoError(t, err)
+
+		err = handler.Handle(context.Background(), event)
+		require.NoError(t, err)
+
+		handled++
+	}
+
+	require.Equal(t, 1, handled)
+
+	expected := TodoRenamed{
+		ID:     "1234",
+		Title:  "Do something",
+		Reason: "unknown",
+	}
+
+	assert.Equal(t, expected, h.event)
+}
@@ -0,0 +1,47 @@
+//go:build !ignore_autogenerated
+
+// Marker documentation shown by the markers command.
+
+// Code generated by helpgen. DO NOT EDIT.
+
+package handlergen
+
+import (
+	"sigs.k8s.io/controller-tools/pkg/markers"
+)
+
+func (Marker) Help() *markers.DefinitionHelp {
+	return &markers.DefinitionHelp{
+		Category: "Event",
+		DetailedHelp: markers.DetailedHelp{
+			Summary: "enables generating an event handler for an event struct.",
+			Details: "",
+		},
+		FieldHelp: map[string]markers.DetailedHelp{
+			"Group": {
+				Summary: "generates a single handler for every event in the same group (instead of one handler per event).",
+				Details: "The group name is used as a prefix for the generated handler types, so it must be a valid Go identifier.",
+			},
+			"Instrumented": {
+				Summary: "tells the generator to write an Instrumented...EventHandler decorator",
+				Details: "recording metrics and traces (using OpenTelemetry) for handled events.\n\nA group is instrumented when any of its events is marked as instrumented.",
+			},
+			"Idempotent": {
+				Summary: "tells the generator to write an Idempotent...EventHandler decorator",
+				Details: "handling each event at most once (using a deduplication store).\n\nEvents are identified by their field marked with +mga:event:key\nor by the ID of the message they were received in.\n\nA group is idempotent when any of its events is marked as idempotent.",
+			},
+			"MaxAttempts": {
+				Summary: "tells the generator to write a Retrying...EventHandler decorator",
+				Details: "handling each event at most the given number of times.",
+			},
+			"Backoff": {
+				Summary: "is the delay before the first retry (eg. 100ms). It is doubled after each attempt.",
+				Details: "It requires maxAttempts to be greater than 1.",
+			},
+			"DeadLetter": {
+				Summary: "is the destination (eg. topic) where events failing every attempt are sent to",
+				Details: "using a DeadLetterSink.",
+			},
+		},
+	}
+}
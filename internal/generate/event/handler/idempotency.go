@@ -0,0 +1,317 @@
+package handler
+
+import (
+	"github.com/dave/jennifer/jen"
+
+	"sagikazarmark.dev/mga/pkg/gentypes"
+)
+
+// generateDedupStore generates a store interface for deduplicating events,
+// an in-memory implementation and helpers for passing message IDs in a context.
+func generateDedupStore(code *jen.File) {
+	const (
+		dedupStoreTypeName         = "DedupStore"
+		inMemoryDedupStoreTypeName = "InMemoryDedupStore"
+		messageIDContextKeyName    = "messageIDContextKey"
+		recv                       = "s"
+	)
+
+	code.Commentf("%s keeps track of events that have already been handled.", dedupStoreTypeName)
+	code.Type().Id(dedupStoreTypeName).Interface(
+		jen.Comment("Seen checks whether an event with the given key has already been handled."),
+		jen.Id("Seen").Params(
+			jen.Id("ctx").Qual("context", "Context"),
+			jen.Id("key").String(),
+		).Params(jen.Bool(), jen.Error()),
+		jen.Line(),
+		jen.Comment("MarkSeen records that an event with the given key has been handled successfully."),
+		jen.Id("MarkSeen").Params(
+			jen.Id("ctx").Qual("context", "Context"),
+			jen.Id("key").String(),
+		).Error(),
+	).Line()
+
+	code.Commentf("%s is a %s keeping event keys in memory for a limited time.", inMemoryDedupStoreTypeName, dedupStoreTypeName)
+	code.Comment("")
+	code.Comment("It is meant to be used in tests and single instance deployments.")
+	code.Comment("Expired keys are removed at most once per TTL (when marking an event as seen).")
+	code.Type().Id(inMemoryDedupStoreTypeName).Struct(
+		jen.Id("ttl").Qual("time", "Duration"),
+		jen.Line(),
+		jen.Id("mu").Qual("sync", "Mutex"),
+		jen.Id("keys").Map(jen.String()).Qual("time", "Time"),
+		jen.Id("sweepAt").Qual("time", "Time"),
+	).Line()
+
+	code.Commentf("New%s returns a new %s instance.", inMemoryDedupStoreTypeName, inMemoryDedupStoreTypeName)
+	code.Comment("")
+	code.Comment("Keys expire after the given TTL.")
+	code.Func().
+		Id("New" + inMemoryDedupStoreTypeName).
+		Params(jen.Id("ttl").Qual("time", "Duration")).
+		Op("*").Id(inMemoryDedupStoreTypeName).
+		Block(
+			jen.Return(
+				jen.Op("&").Id(inMemoryDedupStoreTypeName).Values(jen.Dict{
+					jen.Id("ttl"):  jen.Id("ttl"),
+					jen.Id("keys"): jen.Make(jen.Map(jen.String()).Qual("time", "Time")),
+				}),
+			),
+		).
+		Line()
+
+	code.Comment("Seen checks whether an event with the given key has already been handled.")
+	code.Func().
+		Params(jen.Id(recv).Op("*").Id(inMemoryDedupStoreTypeName)).
+		Id("Seen").
+		Params(
+			jen.Id("_").Qual("context", "Context"),
+			jen.Id("key").String(),
+		).
+		Params(jen.Bool(), jen.Error()).
+		Block(
+			jen.Id(recv).Dot("mu").Dot("Lock").Call(),
+			jen.Defer().Id(recv).Dot("mu").Dot("Unlock").Call(),
+			jen.Line(),
+			jen.List(jen.Id("expiresAt"), jen.Id("ok")).Op(":=").Id(recv).Dot("keys").Index(jen.Id("key")),
+			jen.If(jen.Op("!").Id("ok")).Block(
+				jen.Return(jen.False(), jen.Nil()),
+			),
+			jen.Line(),
+			jen.If(jen.Qual("time", "Now").Call().Dot("After").Call(jen.Id("expiresAt"))).Block(
+				jen.Delete(jen.Id(recv).Dot("keys"), jen.Id("key")),
+				jen.Line(),
+				jen.Return(jen.False(), jen.Nil()),
+			),
+			jen.Line(),
+			jen.Return(jen.True(), jen.Nil()),
+		).
+		Line()
+
+	code.Comment("MarkSeen records that an event with the given key has been handled successfully.")
+	code.Func().
+		Params(jen.Id(recv).Op("*").Id(inMemoryDedupStoreTypeName)).
+		Id("MarkSeen").
+		Params(
+			jen.Id("_").Qual("context", "Context"),
+			jen.Id("key").String(),
+		).
+		Error().
+		Block(
+			jen.Id(recv).Dot("mu").Dot("Lock").Call(),
+			jen.Defer().Id(recv).Dot("mu").Dot("Unlock").Call(),
+			jen.Line(),
+			jen.Id("now").Op(":=").Qual("time", "Now").Call(),
+			jen.Line(),
+			jen.Comment("Remove expired keys to keep memory usage bounded (without scanning every key each time)"),
+			jen.If(jen.Id("now").Dot("After").Call(jen.Id(recv).Dot("sweepAt"))).Block(
+				jen.For(jen.List(jen.Id("k"), jen.Id("expiresAt")).Op(":=").Range().Id(recv).Dot("keys")).Block(
+					jen.If(jen.Id("now").Dot("After").Call(jen.Id("expiresAt"))).Block(
+						jen.Delete(jen.Id(recv).Dot("keys"), jen.Id("k")),
+					),
+				),
+				jen.Line(),
+				jen.Id(recv).Dot("sweepAt").Op("=").Id("now").Dot("Add").Call(jen.Id(recv).Dot("ttl")),
+			),
+			jen.Line(),
+			jen.Id(recv).Dot("keys").Index(jen.Id("key")).Op("=").Id("now").Dot("Add").Call(jen.Id(recv).Dot("ttl")),
+			jen.Line(),
+			jen.Return(jen.Nil()),
+		).
+		Line()
+
+	code.Type().Id(messageIDContextKeyName).Struct().Line()
+
+	code.Comment("ContextWithMessageID returns a new context carrying the ID of the message an event was received in.")
+	code.Comment("")
+	code.Comment("Idempotent event handlers use the message ID as key for events without a key field.")
+	code.Comment("It is not set automatically: pass it to event handlers, eg. with a Watermill router middleware:")
+	code.Comment("")
+	code.Comment("\tfunc(h message.HandlerFunc) message.HandlerFunc {")
+	code.Comment("\t\treturn func(msg *message.Message) ([]*message.Message, error) {")
+	code.Comment("\t\t\tmsg.SetContext(ContextWithMessageID(msg.Context(), msg.UUID))")
+	code.Comment("")
+	code.Comment("\t\t\treturn h(msg)")
+	code.Comment("\t\t}")
+	code.Comment("\t}")
+	code.Func().
+		Id("ContextWithMessageID").
+		Params(
+			jen.Id("ctx").Qual("context", "Context"),
+			jen.Id("id").String(),
+		).
+		Qual("context", "Context").
+		Block(
+			jen.Return(jen.Qual("context", "WithValue").Call(
+				jen.Id("ctx"),
+				jen.Id(messageIDContextKeyName).Values(),
+				jen.Id("id"),
+			)),
+		).
+		Line()
+
+	code.Comment("MessageIDFromContext returns the ID of the message an event was received in (if any).")
+	code.Func().
+		Id("MessageIDFromContext").
+		Params(jen.Id("ctx").Qual("context", "Context")).
+		Params(jen.String(), jen.Bool()).
+		Block(
+			jen.List(jen.Id("id"), jen.Id("ok")).Op(":=").Id("ctx").Dot("Value").Call(
+				jen.Id(messageIDContextKeyName).Values(),
+			).Assert(jen.String()),
+			jen.Line(),
+			jen.Return(jen.Id("id"), jen.Id("ok").Op("&&").Id("id").Op("!=").Lit("")),
+		).
+		Line()
+}
+
+// generateIdempotentEventHandler generates an event handler decorator handling each event (key) at most once.
+//
+// The decorator wraps an event handler implementing decoratedTypeName.
+func generateIdempotentEventHandler(
+	code *jen.File,
+	eventHandlerTypeName string,
+	decoratedTypeName string,
+	name string,
+	events []gentypes.TypeRef,
+	keyFields map[string]string,
+) {
+	idempotentTypeName := "Idempotent" + eventHandlerTypeName
+
+	const recv = "h"
+
+	code.Commentf("%s handles %s events at most once (per key).", idempotentTypeName, name)
+	code.Comment("")
+	code.Comment("Events are identified by their key field or by the ID of the message they were received in")
+	code.Comment("(which has to be passed in the context with ContextWithMessageID).")
+	code.Comment("")
+	code.Commentf("It wraps a(n) %s or another decorator of it.", eventHandlerTypeName)
+	code.Type().Id(idempotentTypeName).Struct(
+		jen.Id(decoratedTypeName),
+		jen.Line(),
+		jen.Id("store").Id("DedupStore"),
+	).Line()
+
+	code.Commentf("New%s returns a new %s instance.", idempotentTypeName, idempotentTypeName)
+	code.Func().
+		Id("New"+idempotentTypeName).
+		Params(
+			jen.Id("handler").Id(decoratedTypeName),
+			jen.Id("store").Id("DedupStore"),
+		).
+		Id(idempotentTypeName).
+		Block(
+			jen.Return(
+				jen.Id(idempotentTypeName).Values(jen.Dict{
+					jen.Id(decoratedTypeName): jen.Id("handler"),
+					jen.Id("store"):           jen.Id("store"),
+				}),
+			),
+		).
+		Line()
+
+	cases := make([]jen.Code, 0, len(events)+1)
+	bindEvent := false
+
+	for _, event := range events {
+		if keyField, ok := keyFields[event.Name]; ok {
+			bindEvent = true
+
+			cases = append(
+				cases,
+				jen.Case(jen.Op("*").Qual(event.Package.Path, event.Name)).Block(
+					jen.Id("key").Op("=").Lit(event.Name+"/").Op("+").Qual("fmt", "Sprint").Call(jen.Id("e").Dot(keyField)),
+				),
+			)
+
+			continue
+		}
+
+		cases = append(
+			cases,
+			jen.Case(jen.Op("*").Qual(event.Package.Path, event.Name)).Block(
+				jen.List(jen.Id("id"), jen.Id("ok")).Op(":=").Id("MessageIDFromContext").Call(jen.Id("ctx")),
+				jen.If(jen.Op("!").Id("ok")).Block(
+					jen.Return(
+						jen.Qual("emperror.dev/errors", "NewWithDetails").Call(
+							jen.Lit("missing message ID"),
+							jen.Lit("event"),
+							jen.Lit(event.Name),
+						),
+					),
+				),
+				jen.Line(),
+				jen.Id("key").Op("=").Lit(event.Name+"/").Op("+").Id("id"),
+			),
+		)
+	}
+
+	cases = append(cases, unexpectedEventCase())
+
+	// Only bind the event to a variable if a key field is read from it
+	typeSwitch := jen.Switch(jen.Id("event").Assert(jen.Type())).Block(cases...)
+	if bindEvent {
+		typeSwitch = jen.Switch(jen.Id("e").Op(":=").Id("event").Assert(jen.Type())).Block(cases...)
+	}
+
+	code.Comment("Handle handles an event unless it has already been handled.")
+	code.Func().
+		Params(jen.Id(recv).Id(idempotentTypeName)).
+		Id("Handle").
+		Params(
+			jen.Id("ctx").Qual("context", "Context"),
+			jen.Id("event").Interface(),
+		).
+		Error().
+		Block(
+			jen.Var().Id("key").String(),
+			jen.Line(),
+			typeSwitch,
+			jen.Line(),
+			jen.Id("key").Op("=").Id(recv).Dot("HandlerName").Call().Op("+").Lit("/").Op("+").Id("key"),
+			jen.Line(),
+			jen.List(jen.Id("seen"), jen.Err()).Op(":=").Id(recv).Dot("store").Dot("Seen").Call(jen.Id("ctx"), jen.Id("key")),
+			jen.If(jen.Err().Op("!=").Nil()).Block(
+				jen.Return(jen.Qual("emperror.dev/errors", "WithDetails").Call(
+					jen.Qual("emperror.dev/errors", "WithMessage").Call(jen.Err(), jen.Lit("failed to check if event has been handled")),
+					jen.Lit("key"), jen.Id("key"),
+				)),
+			),
+			jen.Line(),
+			jen.If(jen.Id("seen")).Block(
+				jen.Return(jen.Nil()),
+			),
+			jen.Line(),
+			jen.Err().Op("=").Id(recv).Dot(decoratedTypeName).Dot("Handle").Call(jen.Id("ctx"), jen.Id("event")),
+			jen.If(jen.Err().Op("!=").Nil()).Block(
+				jen.Return(jen.Err()),
+			),
+			jen.Line(),
+			jen.Err().Op("=").Id(recv).Dot("store").Dot("MarkSeen").Call(jen.Id("ctx"), jen.Id("key")),
+			jen.If(jen.Err().Op("!=").Nil()).Block(
+				jen.Return(jen.Qual("emperror.dev/errors", "WithDetails").Call(
+					jen.Qual("emperror.dev/errors", "WithMessage").Call(jen.Err(), jen.Lit("failed to record handled event")),
+					jen.Lit("key"), jen.Id("key"),
+				)),
+			),
+			jen.Line(),
+			jen.Return(jen.Nil()),
+		).
+		Line()
+}
+
+func hasIdempotentEventHandlers(file File) bool {
+	for _, eventHandler := range file.EventHandlers {
+		if eventHandler.Idempotent {
+			return true
+		}
+	}
+
+	for _, eventHandlerGroup := range file.EventHandlerGroups {
+		if eventHandlerGroup.Idempotent {
+			return true
+		}
+	}
+
+	return false
+}
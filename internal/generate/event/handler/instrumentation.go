@@ -0,0 +1,247 @@
+package handler
+
+import (
+	"github.com/dave/jennifer/jen"
+
+	"sagikazarmark.dev/mga/internal/generate/event/otelgen"
+	"sagikazarmark.dev/mga/pkg/gentypes"
+)
+
+// generateMetadataContext generates helpers for passing message metadata in a context.
+func generateMetadataContext(code *jen.File) {
+	const metadataContextKeyName = "metadataContextKey"
+
+	code.Type().Id(metadataContextKeyName).Struct().Line()
+
+	code.Comment("ContextWithMetadata returns a new context carrying the metadata (eg. headers) of the message an event was received in.")
+	code.Comment("")
+	code.Comment("Instrumented event handlers extract trace context from the metadata.")
+	code.Func().
+		Id("ContextWithMetadata").
+		Params(
+			jen.Id("ctx").Qual("context", "Context"),
+			jen.Id("metadata").Map(jen.String()).String(),
+		).
+		Qual("context", "Context").
+		Block(
+			jen.Return(jen.Qual("context", "WithValue").Call(
+				jen.Id("ctx"),
+				jen.Id(metadataContextKeyName).Values(),
+				jen.Id("metadata"),
+			)),
+		).
+		Line()
+
+	code.Comment("MetadataFromContext returns the metadata of the message an event was received in (if any).")
+	code.Func().
+		Id("MetadataFromContext").
+		Params(jen.Id("ctx").Qual("context", "Context")).
+		Map(jen.String()).String().
+		Block(
+			jen.List(jen.Id("metadata"), jen.Id("_")).Op(":=").Id("ctx").Dot("Value").Call(
+				jen.Id(metadataContextKeyName).Values(),
+			).Assert(jen.Map(jen.String()).String()),
+			jen.Line(),
+			jen.Return(jen.Id("metadata")),
+		).
+		Line()
+}
+
+// generateInstrumentedEventHandler generates an event handler decorator recording metrics and traces for handled events.
+//
+// The decorator wraps an event handler implementing decoratedTypeName.
+// When events is empty, every handled event is recorded under name.
+// Otherwise events are recorded under the name of their type.
+func generateInstrumentedEventHandler(
+	code *jen.File,
+	instrumentationName string,
+	eventHandlerTypeName string,
+	decoratedTypeName string,
+	name string,
+	events []gentypes.TypeRef,
+) {
+	instrumentedTypeName := "Instrumented" + eventHandlerTypeName
+
+	const recv = "h"
+
+	otelgen.ImportNames(code)
+
+	code.Commentf("%s records metrics and traces for %s events.", instrumentedTypeName, name)
+	code.Comment("")
+	code.Commentf("It wraps a(n) %s or another decorator of it.", eventHandlerTypeName)
+	code.Type().Id(instrumentedTypeName).Struct(
+		jen.Id(decoratedTypeName),
+		jen.Line(),
+		jen.Id("tracer").Qual("go.opentelemetry.io/otel/trace", "Tracer"),
+		jen.Id("propagator").Qual("go.opentelemetry.io/otel/propagation", "TextMapPropagator"),
+		jen.Line(),
+		jen.Id("handled").Qual("go.opentelemetry.io/otel/metric", "Int64Counter"),
+		jen.Id("failures").Qual("go.opentelemetry.io/otel/metric", "Int64Counter"),
+		jen.Id("duration").Qual("go.opentelemetry.io/otel/metric", "Float64Histogram"),
+	).Line()
+
+	code.Commentf("New%s returns a new %s instance.", instrumentedTypeName, instrumentedTypeName)
+	code.Func().
+		Id("New"+instrumentedTypeName).
+		Params(
+			jen.Id("handler").Id(decoratedTypeName),
+			jen.Id("tracerProvider").Qual("go.opentelemetry.io/otel/trace", "TracerProvider"),
+			jen.Id("meterProvider").Qual("go.opentelemetry.io/otel/metric", "MeterProvider"),
+			jen.Id("propagator").Qual("go.opentelemetry.io/otel/propagation", "TextMapPropagator"),
+		).
+		Params(jen.Id(instrumentedTypeName), jen.Error()).
+		Block(
+			jen.Id("meter").Op(":=").Id("meterProvider").Dot("Meter").Call(jen.Lit(instrumentationName)),
+			jen.Line(),
+			otelgen.Instrument("handled", "Int64Counter", "event.handled", "Number of handled events.", ""),
+			otelgen.InstrumentError(instrumentedTypeName),
+			jen.Line(),
+			otelgen.Instrument("failures", "Int64Counter", "event.handle.errors", "Number of events that failed to be handled.", ""),
+			otelgen.InstrumentError(instrumentedTypeName),
+			jen.Line(),
+			otelgen.Instrument("duration", "Float64Histogram", "event.handle.duration", "Duration of handling events.", "s"),
+			otelgen.InstrumentError(instrumentedTypeName),
+			jen.Line(),
+			jen.Return(
+				jen.Id(instrumentedTypeName).Values(jen.Dict{
+					jen.Id(decoratedTypeName): jen.Id("handler"),
+					jen.Id("tracer"):          jen.Id("tracerProvider").Dot("Tracer").Call(jen.Lit(instrumentationName)),
+					jen.Id("propagator"):      jen.Id("propagator"),
+					jen.Id("handled"):         jen.Id("handled"),
+					jen.Id("failures"):        jen.Id("failures"),
+					jen.Id("duration"):        jen.Id("duration"),
+				}),
+				jen.Nil(),
+			),
+		).
+		Line()
+
+	code.Comment("Handle handles an event.")
+	code.Comment("")
+	code.Comment("Trace context is extracted from the metadata passed in the context (see ContextWithMetadata).")
+	code.Func().
+		Params(jen.Id(recv).Id(instrumentedTypeName)).
+		Id("Handle").
+		Params(
+			jen.Id("ctx").Qual("context", "Context"),
+			jen.Id("event").Interface(),
+		).
+		Error().
+		Block(
+			jen.Return(jen.Id(recv).Dot("HandleWithMetadata").Call(
+				jen.Id("ctx"),
+				jen.Id("event"),
+				jen.Id("MetadataFromContext").Call(jen.Id("ctx")),
+			)),
+		).
+		Line()
+
+	var eventName jen.Code = jen.Id("name").Op(":=").Lit(name)
+
+	if len(events) > 0 {
+		cases := make([]jen.Code, 0, len(events)+1)
+
+		for _, event := range events {
+			cases = append(
+				cases,
+				jen.Case(jen.Op("*").Qual(event.Package.Path, event.Name)).Block(
+					jen.Id("name").Op("=").Lit(event.Name),
+				),
+			)
+		}
+
+		cases = append(
+			cases,
+			jen.Default().Block(
+				jen.Id("name").Op("=").Qual("fmt", "Sprintf").Call(jen.Lit("%T"), jen.Id("event")),
+			),
+		)
+
+		eventName = jen.Var().Id("name").String().Line().Line().Switch(jen.Id("event").Assert(jen.Type())).Block(cases...)
+	}
+
+	code.Comment("HandleWithMetadata handles an event with metadata (eg. message headers).")
+	code.Comment("")
+	code.Comment("Trace context is extracted from the metadata (if any).")
+	code.Func().
+		Params(jen.Id(recv).Id(instrumentedTypeName)).
+		Id("HandleWithMetadata").
+		Params(
+			jen.Id("ctx").Qual("context", "Context"),
+			jen.Id("event").Interface(),
+			jen.Id("metadata").Map(jen.String()).String(),
+		).
+		Error().
+		Block(
+			jen.If(jen.Id("metadata").Op("!=").Nil()).Block(
+				jen.Id("ctx").Op("=").Id(recv).Dot("propagator").Dot("Extract").Call(
+					jen.Id("ctx"),
+					jen.Qual("go.opentelemetry.io/otel/propagation", "MapCarrier").Call(jen.Id("metadata")),
+				),
+			),
+			jen.Line(),
+			eventName,
+			jen.Line(),
+			jen.Id("attrs").Op(":=").Qual("go.opentelemetry.io/otel/metric", "WithAttributes").Call(
+				jen.Qual("go.opentelemetry.io/otel/attribute", "String").Call(jen.Lit("event.name"), jen.Id("name")),
+				jen.Qual("go.opentelemetry.io/otel/attribute", "String").Call(
+					jen.Lit("event.handler"),
+					jen.Id(recv).Dot("HandlerName").Call(),
+				),
+			),
+			jen.Line(),
+			jen.List(jen.Id("ctx"), jen.Id("span")).Op(":=").Id(recv).Dot("tracer").Dot("Start").Call(
+				jen.Id("ctx"),
+				jen.Lit("handle ").Op("+").Id("name"),
+				jen.Qual("go.opentelemetry.io/otel/trace", "WithSpanKind").Call(
+					jen.Qual("go.opentelemetry.io/otel/trace", "SpanKindConsumer"),
+				),
+				jen.Qual("go.opentelemetry.io/otel/trace", "WithAttributes").Call(
+					jen.Qual("go.opentelemetry.io/otel/attribute", "String").Call(jen.Lit("event.name"), jen.Id("name")),
+					jen.Qual("go.opentelemetry.io/otel/attribute", "String").Call(
+						jen.Lit("event.handler"),
+						jen.Id(recv).Dot("HandlerName").Call(),
+					),
+				),
+			),
+			jen.Defer().Id("span").Dot("End").Call(),
+			jen.Line(),
+			jen.Id("start").Op(":=").Qual("time", "Now").Call(),
+			jen.Line(),
+			jen.Err().Op(":=").Id(recv).Dot(decoratedTypeName).Dot("Handle").Call(jen.Id("ctx"), jen.Id("event")),
+			jen.Line(),
+			jen.Id(recv).Dot("duration").Dot("Record").Call(
+				jen.Id("ctx"),
+				jen.Qual("time", "Since").Call(jen.Id("start")).Dot("Seconds").Call(),
+				jen.Id("attrs"),
+			),
+			jen.Id(recv).Dot("handled").Dot("Add").Call(jen.Id("ctx"), jen.Lit(1), jen.Id("attrs")),
+			jen.Line(),
+			jen.If(jen.Err().Op("!=").Nil()).Block(
+				jen.Id(recv).Dot("failures").Dot("Add").Call(jen.Id("ctx"), jen.Lit(1), jen.Id("attrs")),
+				jen.Id("span").Dot("RecordError").Call(jen.Err()),
+				jen.Id("span").Dot("SetStatus").Call(
+					jen.Qual("go.opentelemetry.io/otel/codes", "Error"),
+					jen.Err().Dot("Error").Call(),
+				),
+			),
+			jen.Line(),
+			jen.Return(jen.Err()),
+		)
+}
+
+func hasInstrumentedEventHandlers(file File) bool {
+	for _, eventHandler := range file.EventHandlers {
+		if eventHandler.Instrumented {
+			return true
+		}
+	}
+
+	for _, eventHandlerGroup := range file.EventHandlerGroups {
+		if eventHandlerGroup.Instrumented {
+			return true
+		}
+	}
+
+	return false
+}
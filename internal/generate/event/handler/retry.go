@@ -0,0 +1,217 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/dave/jennifer/jen"
+)
+
+// RetryPolicy describes how failed events are retried.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of times an event is handled before giving up.
+	MaxAttempts int
+
+	// Backoff is the delay before the first retry (doubled after each attempt).
+	Backoff time.Duration
+
+	// DeadLetter is the destination where events are sent after the last failed attempt.
+	DeadLetter string
+}
+
+// generateDeadLetterSink generates an interface receiving events that could not be handled.
+func generateDeadLetterSink(code *jen.File) {
+	code.Comment("DeadLetterSink receives events that could not be handled.")
+	code.Type().Id("DeadLetterSink").Interface(
+		jen.Comment("DeadLetter sends an event to a dead-letter destination."),
+		jen.Comment(""),
+		jen.Comment("The error carries details (eg. handler name, number of attempts) that can be retrieved using errors.GetDetails."),
+		jen.Id("DeadLetter").Params(
+			jen.Id("ctx").Qual("context", "Context"),
+			jen.Id("destination").String(),
+			jen.Id("event").Interface(),
+			jen.Id("err").Error(),
+		).Error(),
+	).Line()
+}
+
+// generateRetryingEventHandler generates an event handler decorator enforcing a retry policy.
+//
+// The decorator wraps an event handler implementing decoratedTypeName.
+func generateRetryingEventHandler(
+	code *jen.File,
+	eventHandlerTypeName string,
+	decoratedTypeName string,
+	name string,
+	policy RetryPolicy,
+) {
+	retryingTypeName := "Retrying" + eventHandlerTypeName
+
+	const recv = "h"
+
+	maxAttempts := policy.MaxAttempts
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+
+	fields := []jen.Code{jen.Id(decoratedTypeName)}
+	params := []jen.Code{jen.Id("handler").Id(decoratedTypeName)}
+	values := jen.Dict{jen.Id(decoratedTypeName): jen.Id("handler")}
+
+	if policy.DeadLetter != "" {
+		fields = append(fields, jen.Line(), jen.Id("sink").Id("DeadLetterSink"))
+		params = append(params, jen.Id("sink").Id("DeadLetterSink"))
+		values[jen.Id("sink")] = jen.Id("sink")
+	}
+
+	code.Commentf("%s handles %s events with retries.", retryingTypeName, name)
+	code.Comment("")
+	if policy.Backoff > 0 {
+		code.Commentf(
+			"Events are handled at most %d times, waiting %s before the first retry (doubled after each attempt).",
+			maxAttempts,
+			policy.Backoff,
+		)
+	} else {
+		code.Commentf("Events are handled at most %d times.", maxAttempts)
+	}
+	if policy.DeadLetter != "" {
+		code.Commentf("Events failing every attempt are sent to the %q dead-letter destination.", policy.DeadLetter)
+	}
+	code.Comment("")
+	code.Commentf("It wraps a(n) %s or another decorator of it.", eventHandlerTypeName)
+	code.Type().Id(retryingTypeName).Struct(fields...).Line()
+
+	code.Commentf("New%s returns a new %s instance.", retryingTypeName, retryingTypeName)
+	code.Func().
+		Id("New" + retryingTypeName).
+		Params(params...).
+		Id(retryingTypeName).
+		Block(
+			jen.Return(
+				jen.Id(retryingTypeName).Values(values),
+			),
+		).
+		Line()
+
+	retryBlock := []jen.Code{
+		jen.If(jen.Id("attempt").Op(">").Lit(1)).Block(
+			jen.Select().Block(
+				jen.Case(jen.Op("<-").Id("ctx").Dot("Done").Call()).Block(
+					jen.Return(jen.Qual("emperror.dev/errors", "Combine").Call(jen.Err(), jen.Id("ctx").Dot("Err").Call())),
+				),
+				jen.Case(jen.Op("<-").Qual("time", "After").Call(jen.Id("backoff"))).Block(),
+			),
+			jen.Line(),
+			jen.Id("backoff").Op("*=").Lit(2),
+		),
+		jen.Line(),
+		jen.Err().Op("=").Id(recv).Dot(decoratedTypeName).Dot("Handle").Call(jen.Id("ctx"), jen.Id("event")),
+		jen.If(jen.Err().Op("==").Nil()).Block(
+			jen.Return(jen.Nil()),
+		),
+	}
+
+	body := []jen.Code{
+		jen.Var().Err().Error(),
+		jen.Line(),
+		jen.Id("backoff").Op(":=").Add(durationCode(policy.Backoff)),
+		jen.Line(),
+		jen.For(
+			jen.Id("attempt").Op(":=").Lit(1),
+			jen.Id("attempt").Op("<=").Lit(maxAttempts),
+			jen.Id("attempt").Op("++"),
+		).Block(retryBlock...),
+		jen.Line(),
+		jen.Err().Op("=").Qual("emperror.dev/errors", "WithDetails").Call(
+			jen.Err(),
+			jen.Lit("handler"), jen.Id(recv).Dot("HandlerName").Call(),
+			jen.Lit("attempts"), jen.Lit(maxAttempts),
+		),
+		jen.Line(),
+	}
+
+	if policy.DeadLetter != "" {
+		body = append(
+			body,
+			jen.Id("sinkErr").Op(":=").Id(recv).Dot("sink").Dot("DeadLetter").Call(
+				jen.Id("ctx"),
+				jen.Lit(policy.DeadLetter),
+				jen.Id("event"),
+				jen.Err(),
+			),
+			jen.If(jen.Id("sinkErr").Op("!=").Nil()).Block(
+				jen.Return(jen.Qual("emperror.dev/errors", "Combine").Call(
+					jen.Err(),
+					jen.Qual("emperror.dev/errors", "WithDetails").Call(
+						jen.Qual("emperror.dev/errors", "WithMessage").Call(jen.Id("sinkErr"), jen.Lit("failed to send event to dead-letter destination")),
+						jen.Lit("destination"), jen.Lit(policy.DeadLetter),
+					),
+				)),
+			),
+			jen.Line(),
+			jen.Return(jen.Nil()),
+		)
+	} else {
+		body = append(body, jen.Return(jen.Err()))
+	}
+
+	code.Comment("Handle handles an event according to the retry policy.")
+	code.Func().
+		Params(jen.Id(recv).Id(retryingTypeName)).
+		Id("Handle").
+		Params(
+			jen.Id("ctx").Qual("context", "Context"),
+			jen.Id("event").Interface(),
+		).
+		Error().
+		Block(body...).
+		Line()
+}
+
+// durationCode generates a readable expression for a duration (eg. 100 * time.Millisecond).
+func durationCode(d time.Duration) jen.Code {
+	units := []struct {
+		unit time.Duration
+		name string
+	}{
+		{time.Hour, "Hour"},
+		{time.Minute, "Minute"},
+		{time.Second, "Second"},
+		{time.Millisecond, "Millisecond"},
+		{time.Microsecond, "Microsecond"},
+	}
+
+	if d == 0 {
+		return jen.Qual("time", "Duration").Call(jen.Lit(0))
+	}
+
+	for _, u := range units {
+		if d%u.unit != 0 {
+			continue
+		}
+
+		if d == u.unit {
+			return jen.Qual("time", u.name)
+		}
+
+		return jen.Lit(int(d/u.unit)).Op("*").Qual("time", u.name)
+	}
+
+	return jen.Qual("time", "Duration").Call(jen.Lit(int(d)))
+}
+
+func hasDeadLetters(file File) bool {
+	for _, eventHandler := range file.EventHandlers {
+		if eventHandler.Retry != nil && eventHandler.Retry.DeadLetter != "" {
+			return true
+		}
+	}
+
+	for _, eventHandlerGroup := range file.EventHandlerGroups {
+		if eventHandlerGroup.Retry != nil && eventHandlerGroup.Retry.DeadLetter != "" {
+			return true
+		}
+	}
+
+	return false
+}
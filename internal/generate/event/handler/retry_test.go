@@ -0,0 +1,34 @@
+package handler
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/dave/jennifer/jen"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDurationCode(t *testing.T) {
+	tests := []struct {
+		duration time.Duration
+		expected string
+	}{
+		{0, "time.Duration(0)"},
+		{time.Second, "time.Second"},
+		{100 * time.Millisecond, "100 * time.Millisecond"},
+		{90 * time.Minute, "90 * time.Minute"},
+		{1500 * time.Nanosecond, "time.Duration(1500)"},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.duration.String(), func(t *testing.T) {
+			code := jen.NewFile("pkg")
+			code.Var().Id("d").Op("=").Add(durationCode(test.duration))
+
+			assert.Contains(t, fmt.Sprintf("%#v", code), "var d = "+test.expected)
+		})
+	}
+}
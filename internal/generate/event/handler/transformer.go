@@ -1,5 +1,13 @@
 package handler
 
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"sagikazarmark.dev/mga/pkg/gentypes"
+)
+
 // EventHandlerFromEvent creates an EventHandler from an Event.
 // nolint: golint
 func EventHandlerFromEvent(event Event) EventHandler {
@@ -8,3 +16,62 @@ func EventHandlerFromEvent(event Event) EventHandler {
 		Event: event,
 	}
 }
+
+// EventVersions maps versions of an event to event structs.
+type EventVersions map[int]Event
+
+// EventFamily returns the name shared by every version of an event:
+// the name of the event struct without its version suffix (eg. TodoCreatedV2 becomes TodoCreated).
+func EventFamily(name string, version int) string {
+	return strings.TrimSuffix(name, "V"+strconv.Itoa(version))
+}
+
+// UpcastChain returns the older versions of an event (in ascending order) that need to be upcasted to it.
+//
+// An event without an explicit version is considered to be the latest version of its family.
+// Every version from 1 to the version of the event has to be present in versions,
+// otherwise a step of the upcaster chain would be missing.
+func UpcastChain(event Event, version int, versions EventVersions) ([]gentypes.TypeRef, error) {
+	latest := version
+
+	for v, e := range versions {
+		if e == event {
+			continue
+		}
+
+		if v == version {
+			return nil, fmt.Errorf("events %s and %s share the same version %d", e.Name, event.Name, v)
+		}
+
+		if version != 0 && v > version {
+			return nil, fmt.Errorf(
+				"event %s (version %d) is newer than the handled event %s (version %d)",
+				e.Name, v, event.Name, version,
+			)
+		}
+
+		if version == 0 && v >= latest {
+			latest = v + 1
+		}
+	}
+
+	if latest <= 1 {
+		return nil, nil
+	}
+
+	chain := make([]gentypes.TypeRef, 0, latest-1)
+
+	for v := 1; v < latest; v++ {
+		e, ok := versions[v]
+		if !ok {
+			return nil, fmt.Errorf(
+				"missing version %d of event %s: every version from 1 to %d is required for upcasting",
+				v, event.Name, latest-1,
+			)
+		}
+
+		chain = append(chain, e)
+	}
+
+	return chain, nil
+}
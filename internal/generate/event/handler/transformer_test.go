@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sagikazarmark.dev/mga/pkg/gentypes"
+)
+
+func TestEventFamily(t *testing.T) {
+	assert.Equal(t, "TodoCreated", EventFamily("TodoCreatedV2", 2))
+	assert.Equal(t, "TodoCreated", EventFamily("TodoCreated", 3))
+	assert.Equal(t, "TodoCreatedV1", EventFamily("TodoCreatedV1", 2))
+}
+
+func TestUpcastChain(t *testing.T) {
+	pkg := gentypes.PackageRef{
+		Name: "pkg",
+		Path: "app.dev/pkg",
+	}
+
+	v1 := Event{Name: "TodoCreatedV1", Package: pkg}
+	v2 := Event{Name: "TodoCreatedV2", Package: pkg}
+	latest := Event{Name: "TodoCreated", Package: pkg}
+
+	tests := map[string]struct {
+		event    Event
+		version  int
+		versions EventVersions
+		expected []gentypes.TypeRef
+	}{
+		"explicit version": {
+			event:    latest,
+			version:  3,
+			versions: EventVersions{1: v1, 2: v2, 3: latest},
+			expected: []gentypes.TypeRef{v1, v2},
+		},
+		"implicit latest version": {
+			event:    latest,
+			versions: EventVersions{1: v1, 2: v2},
+			expected: []gentypes.TypeRef{v1, v2},
+		},
+		"no older versions": {
+			event:    v1,
+			version:  1,
+			versions: EventVersions{1: v1},
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+
+		t.Run(name, func(t *testing.T) {
+			chain, err := UpcastChain(test.event, test.version, test.versions)
+			require.NoError(t, err)
+
+			assert.Equal(t, test.expected, chain)
+		})
+	}
+}
+
+func TestUpcastChain_Errors(t *testing.T) {
+	pkg := gentypes.PackageRef{
+		Name: "pkg",
+		Path: "app.dev/pkg",
+	}
+
+	v1 := Event{Name: "TodoCreatedV1", Package: pkg}
+	v2 := Event{Name: "TodoCreatedV2", Package: pkg}
+	latest := Event{Name: "TodoCreated", Package: pkg}
+
+	tests := map[string]struct {
+		event    Event
+		version  int
+		versions EventVersions
+		err      string
+	}{
+		"missing step": {
+			event:    latest,
+			version:  3,
+			versions: EventVersions{1: v1, 3: latest},
+			err:      "missing version 2 of event TodoCreated: every version from 1 to 2 is required for upcasting",
+		},
+		"missing implicit step": {
+			event:    latest,
+			versions: EventVersions{2: v2},
+			err:      "missing version 1 of event TodoCreated: every version from 1 to 2 is required for upcasting",
+		},
+		"newer version": {
+			event:    v1,
+			version:  1,
+			versions: EventVersions{1: v1, 2: v2},
+			err:      "event TodoCreatedV2 (version 2) is newer than the handled event TodoCreatedV1 (version 1)",
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+
+		t.Run(name, func(t *testing.T) {
+			_, err := UpcastChain(test.event, test.version, test.versions)
+
+			assert.EqualError(t, err, test.err)
+		})
+	}
+}
@@ -0,0 +1,36 @@
+// Package otelgen generates code shared by OpenTelemetry instrumented event decorators.
+package otelgen
+
+import (
+	"github.com/dave/jennifer/jen"
+)
+
+// ImportNames registers the names of OpenTelemetry packages used by instrumented decorators.
+func ImportNames(code *jen.File) {
+	code.ImportName("go.opentelemetry.io/otel/attribute", "attribute")
+	code.ImportName("go.opentelemetry.io/otel/codes", "codes")
+	code.ImportName("go.opentelemetry.io/otel/metric", "metric")
+	code.ImportName("go.opentelemetry.io/otel/propagation", "propagation")
+	code.ImportName("go.opentelemetry.io/otel/trace", "trace")
+}
+
+// Instrument generates code creating a metric instrument.
+func Instrument(varName string, kind string, name string, description string, unit string) jen.Code {
+	options := []jen.Code{
+		jen.Lit(name),
+		jen.Qual("go.opentelemetry.io/otel/metric", "WithDescription").Call(jen.Lit(description)),
+	}
+
+	if unit != "" {
+		options = append(options, jen.Qual("go.opentelemetry.io/otel/metric", "WithUnit").Call(jen.Lit(unit)))
+	}
+
+	return jen.List(jen.Id(varName), jen.Err()).Op(":=").Id("meter").Dot(kind).Call(options...)
+}
+
+// InstrumentError generates code returning an error when creating an instrument fails.
+func InstrumentError(typeName string) jen.Code {
+	return jen.If(jen.Err().Op("!=").Nil()).Block(
+		jen.Return(jen.Id(typeName).Values(), jen.Err()),
+	)
+}
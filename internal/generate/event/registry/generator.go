@@ -0,0 +1,142 @@
+package registry
+
+import (
+	"bytes"
+	"go/format"
+
+	"github.com/dave/jennifer/jen"
+
+	"sagikazarmark.dev/mga/pkg/gentypes"
+)
+
+// File provides information for generating an event registry.
+type File struct {
+	gentypes.File
+
+	// Events are the events registered in the registry.
+	Events []Event
+}
+
+// Event is an event registered under a name.
+type Event struct {
+	// Name is the stable name of the event (eg. used in message metadata).
+	Name string
+
+	Event gentypes.TypeRef
+}
+
+// Generate generates an event registry.
+func Generate(file File) ([]byte, error) {
+	code := jen.NewFilePathName(file.Package.Path, file.Package.Name)
+
+	code.HeaderComment("//go:build !ignore_autogenerated\n// +build !ignore_autogenerated\n")
+
+	if file.HeaderText != "" {
+		code.HeaderComment(file.HeaderText)
+	}
+
+	code.HeaderComment("Code generated by mga tool. DO NOT EDIT.")
+
+	code.ImportName("emperror.dev/errors", "errors")
+
+	generateEventRegistry(code, file.Events)
+
+	var buf bytes.Buffer
+
+	err := code.Render(&buf)
+	if err != nil {
+		return nil, err
+	}
+
+	return format.Source(buf.Bytes())
+}
+
+func generateEventRegistry(code *jen.File, events []Event) {
+	const (
+		registryTypeName = "EventRegistry"
+		recv             = "r"
+	)
+
+	for _, event := range events {
+		code.ImportName(event.Event.Package.Path, event.Event.Package.Name)
+	}
+
+	code.Commentf("%s maps event names to event types.", registryTypeName)
+	code.Type().Id(registryTypeName).Struct().Line()
+
+	code.Commentf("New%s returns a new %s instance.", registryTypeName, registryTypeName)
+	code.Func().
+		Id("New" + registryTypeName).
+		Params().
+		Id(registryTypeName).
+		Block(jen.Return(jen.Id(registryTypeName).Values())).
+		Line()
+
+	names := make([]jen.Code, 0, len(events))
+	newCases := make([]jen.Code, 0, len(events)+1)
+	nameCases := make([]jen.Code, 0, len(events)+1)
+
+	for _, event := range events {
+		names = append(names, jen.Lit(event.Name))
+
+		newCases = append(
+			newCases,
+			jen.Case(jen.Lit(event.Name)).Block(
+				jen.Return(jen.Op("&").Qual(event.Event.Package.Path, event.Event.Name).Values(), jen.Nil()),
+			),
+		)
+
+		nameCases = append(
+			nameCases,
+			jen.Case(
+				jen.Qual(event.Event.Package.Path, event.Event.Name),
+				jen.Op("*").Qual(event.Event.Package.Path, event.Event.Name),
+			).Block(
+				jen.Return(jen.Lit(event.Name)),
+			),
+		)
+	}
+
+	code.Comment("Names returns the names of every registered event.")
+	code.Func().
+		Params(jen.Id(recv).Id(registryTypeName)).
+		Id("Names").
+		Params().
+		Params(jen.Index().String()).
+		Block(jen.Return(jen.Index().String().Values(names...))).
+		Line()
+
+	code.Comment("New returns a new, empty event (pointer) registered under a name.")
+	code.Comment("It can be used to unmarshal events read from a generic stream.")
+	code.Func().
+		Params(jen.Id(recv).Id(registryTypeName)).
+		Id("New").
+		Params(jen.Id("name").String()).
+		Params(jen.Interface(), jen.Error()).
+		Block(
+			jen.Switch(jen.Id("name")).Block(newCases...),
+			jen.Line(),
+			jen.Return(
+				jen.Nil(),
+				jen.Qual("emperror.dev/errors", "NewWithDetails").Call(
+					jen.Lit("unknown event"),
+					jen.Lit("name"),
+					jen.Id("name"),
+				),
+			),
+		).
+		Line()
+
+	code.Comment("Name returns the name of a registered event (or pointer to one).")
+	code.Comment("It returns an empty string if the event is not registered.")
+	code.Func().
+		Params(jen.Id(recv).Id(registryTypeName)).
+		Id("Name").
+		Params(jen.Id("event").Interface()).
+		Params(jen.String()).
+		Block(
+			jen.Switch(jen.Id("event").Assert(jen.Type())).Block(nameCases...),
+			jen.Line(),
+			jen.Return(jen.Lit("")),
+		)
+}
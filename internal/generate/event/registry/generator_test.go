@@ -0,0 +1,97 @@
+package registry
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"sagikazarmark.dev/mga/pkg/gentypes"
+)
+
+func TestGenerate(t *testing.T) {
+	file := File{
+		File: gentypes.File{
+			Package: gentypes.PackageRef{
+				Name: "pkggen",
+				Path: "app.dev/pkg/pkggen",
+			},
+			HeaderText: `// Copyright 2020 Acme Inc.
+// All rights reserved.
+//
+// Licensed under "Only for testing purposes" license.
+`,
+		},
+		Events: []Event{
+			{
+				Name: "todo.created",
+				Event: gentypes.TypeRef{
+					Name: "TodoCreated",
+					Package: gentypes.PackageRef{
+						Name: "pkg",
+						Path: "app.dev/pkg",
+					},
+				},
+			},
+		},
+	}
+
+	expected := `//go:build !ignore_autogenerated
+// +build !ignore_autogenerated
+
+// Copyright 2020 Acme Inc.
+// All rights reserved.
+//
+// Licensed under "Only for testing purposes" license.
+
+// Code generated by mga tool. DO NOT EDIT.
+
+package pkggen
+
+import (
+	"app.dev/pkg"
+	"emperror.dev/errors"
+)
+
+// EventRegistry maps event names to event types.
+type EventRegistry struct{}
+
+// NewEventRegistry returns a new EventRegistry instance.
+func NewEventRegistry() EventRegistry {
+	return EventRegistry{}
+}
+
+// Names returns the names of every registered event.
+func (r EventRegistry) Names() []string {
+	return []string{"todo.created"}
+}
+
+// New returns a new, empty event (pointer) registered under a name.
+// It can be used to unmarshal events read from a generic stream.
+func (r EventRegistry) New(name string) (interface{}, error) {
+	switch name {
+	case "todo.created":
+		return &pkg.TodoCreated{}, nil
+	}
+
+	return nil, errors.NewWithDetails("unknown event", "name", name)
+}
+
+// Name returns the name of a registered event (or pointer to one).
+// It returns an empty string if the event is not registered.
+func (r EventRegistry) Name(event interface{}) string {
+	switch event.(type) {
+	case pkg.TodoCreated, *pkg.TodoCreated:
+		return "todo.created"
+	}
+
+	return ""
+}
+`
+
+	actual, err := Generate(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, expected, string(actual), "the generated code does not match the expected one")
+}
@@ -0,0 +1,252 @@
+package registrygen
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+
+	"sigs.k8s.io/controller-tools/pkg/genall"
+	"sigs.k8s.io/controller-tools/pkg/loader"
+	"sigs.k8s.io/controller-tools/pkg/markers"
+
+	"sagikazarmark.dev/mga/internal/generate/event/dispatcher"
+	"sagikazarmark.dev/mga/internal/generate/event/dispatcher/dispatchergen"
+	"sagikazarmark.dev/mga/internal/generate/event/handler"
+	"sagikazarmark.dev/mga/internal/generate/event/handler/handlergen"
+	"sagikazarmark.dev/mga/internal/generate/event/registry"
+	"sagikazarmark.dev/mga/pkg/generator"
+	"sagikazarmark.dev/mga/pkg/gentypes"
+	"sagikazarmark.dev/mga/pkg/genutils"
+)
+
+// nolint: gochecknoglobals
+var (
+	// NameMarker overrides the name an event struct is registered under.
+	NameMarker = markers.Must(markers.MakeDefinition("mga:event:name", markers.DescribesType, ""))
+)
+
+// Generator generates an event registry from event dispatchers and event handlers.
+type Generator struct {
+	generator.Base
+}
+
+func (g Generator) RegisterMarkers(into *markers.Registry) error {
+	if err := (dispatchergen.Generator{}).RegisterMarkers(into); err != nil {
+		return err
+	}
+
+	if err := (handlergen.Generator{}).RegisterMarkers(into); err != nil {
+		return err
+	}
+
+	if err := into.Register(NameMarker); err != nil {
+		return err
+	}
+
+	into.AddHelp(
+		NameMarker,
+		markers.SimpleHelp("Event", "overrides the name an event struct is registered under in the event registry"),
+	)
+
+	return nil
+}
+
+// MarkerKinds restricts markers to the kinds of declarations the generator handles.
+func (Generator) MarkerKinds() map[string]string {
+	kinds := map[string]string{
+		NameMarker.Name: genutils.StructKind,
+	}
+
+	for name, kind := range (dispatchergen.Generator{}).MarkerKinds() {
+		kinds[name] = kind
+	}
+
+	for name, kind := range (handlergen.Generator{}).MarkerKinds() {
+		kinds[name] = kind
+	}
+
+	return kinds
+}
+
+func (Generator) CheckFilter() loader.NodeFilter {
+	return func(node ast.Node) bool {
+		return true
+	}
+}
+
+// OutputFiles returns the names of the files written by the generator.
+func (g Generator) OutputFiles() []string {
+	return g.OutputFilePatterns("event_registry")
+}
+
+func (g Generator) Generate(ctx *genall.GenerationContext) error {
+	headerText, err := g.HeaderText(ctx)
+	if err != nil {
+		return err
+	}
+
+	fileName, err := g.OutputFileName("event_registry", "")
+	if err != nil {
+		return err
+	}
+
+	for _, root := range ctx.Roots {
+		outContents := g.generatePackage(ctx, headerText, root)
+		if outContents == nil {
+			continue
+		}
+
+		generator.WriteFile(ctx, root, fileName, outContents)
+	}
+
+	return nil
+}
+
+func (g Generator) generatePackage(ctx *genall.GenerationContext, headerText string, root *loader.Package) []byte {
+	ctx.Checker.Check(root)
+
+	root.NeedTypesInfo()
+
+	var eventTypes []gentypes.TypeRef
+
+	err := markers.EachType(ctx.Collector, root, func(info *markers.TypeInfo) {
+		isDispatcher := info.Markers.Get(dispatchergen.DispatcherMarker.Name) != nil
+		isHandler := info.Markers.Get(handlergen.HandlerMarker.Name) != nil ||
+			info.Markers.Get(handlergen.VersionMarker.Name) != nil
+
+		if !isDispatcher && !isHandler {
+			return
+		}
+
+		typeInfo := root.TypesInfo.TypeOf(info.RawSpec.Name)
+		if typeInfo == types.Typ[types.Invalid] {
+			root.AddError(loader.ErrFromNode(fmt.Errorf("unknown type %s", info.Name), info.RawSpec))
+
+			return
+		}
+
+		obj := root.TypesInfo.ObjectOf(info.RawSpec.Name)
+
+		if isDispatcher {
+			events, err := dispatcher.ParseEvents(obj)
+			if err != nil {
+				root.AddError(loader.ErrFromNode(err, info.RawSpec))
+
+				return
+			}
+
+			for _, method := range events.Methods {
+				eventTypes = append(eventTypes, method.Event)
+			}
+		}
+
+		if isHandler {
+			event, err := handler.ParseEvent(obj)
+			if err != nil {
+				root.AddError(loader.ErrFromNode(err, info.RawSpec))
+
+				return
+			}
+
+			eventTypes = append(eventTypes, event)
+		}
+	})
+	if err != nil {
+		root.AddError(err)
+
+		return nil
+	}
+
+	if len(eventTypes) == 0 {
+		return nil
+	}
+
+	names, err := eventNames(ctx, root, eventTypes)
+	if err != nil {
+		root.AddError(err)
+
+		return nil
+	}
+
+	events, err := registry.EventsFromTypes(eventTypes, names)
+	if err != nil {
+		root.AddError(err)
+
+		return nil
+	}
+
+	outputPackage := generator.OutputPackage(ctx, root)
+
+	file := registry.File{
+		File: gentypes.File{
+			Package:    outputPackage,
+			HeaderText: headerText,
+		},
+		Events: events,
+	}
+
+	outContents, err := registry.Generate(file)
+	if err != nil {
+		root.AddError(err)
+
+		return nil
+	}
+
+	return outContents
+}
+
+// eventNames collects event name overrides from the packages of the registered events.
+//
+// Packages not parsed while type-checking the root (eg. packages of events handled by the root) are parsed here.
+// Packages are generated in parallel and imported packages are shared by roots, so they are locked while parsing.
+func eventNames(
+	ctx *genall.GenerationContext,
+	root *loader.Package,
+	eventTypes []gentypes.TypeRef,
+) (map[gentypes.TypeRef]string, error) {
+	pkgs := map[string]*loader.Package{root.PkgPath: root}
+	for path, pkg := range root.Imports() {
+		pkgs[path] = pkg
+	}
+
+	names := map[gentypes.TypeRef]string{}
+	visited := map[string]bool{}
+
+	for _, eventType := range eventTypes {
+		pkg, ok := pkgs[eventType.Package.Path]
+		if !ok || visited[pkg.PkgPath] {
+			continue
+		}
+
+		visited[pkg.PkgPath] = true
+
+		pkg.Lock()
+		pkg.NeedSyntax()
+		parsed := pkg.Syntax != nil
+		pkg.Unlock()
+
+		if !parsed {
+			return nil, fmt.Errorf("cannot read event names from package %s: package cannot be parsed", pkg.PkgPath)
+		}
+
+		err := markers.EachType(ctx.Collector, pkg, func(info *markers.TypeInfo) {
+			name, ok := info.Markers.Get(NameMarker.Name).(string)
+			if !ok {
+				return
+			}
+
+			names[gentypes.TypeRef{
+				Name: info.Name,
+				Package: gentypes.PackageRef{
+					Name: pkg.Name,
+					Path: pkg.PkgPath,
+				},
+			}] = name
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return names, nil
+}
@@ -0,0 +1,15 @@
+package registrygen
+
+import (
+	"testing"
+
+	"sagikazarmark.dev/mga/pkg/genutils/gentest"
+)
+
+func TestGenerator_ImportedEvents(t *testing.T) {
+	gentest.RunFile(t, "testdata/imported.txtar", gentest.Test{
+		Generator: Generator{},
+		Patterns:  []string{gentest.ModulePath + "/todo"},
+		Compile:   true,
+	})
+}
@@ -0,0 +1,24 @@
+package test
+
+import (
+	"context"
+)
+
+// +mga:event:name=todo.created
+type TodoCreated struct {
+	ID    string
+	Title string
+}
+
+// +mga:event:handler
+type TodoDone struct {
+	ID string
+}
+
+//go:generate go run sagikazarmark.dev/mga generate event registry
+
+// +mga:event:dispatcher
+type Events interface {
+	TodoCreated(ctx context.Context, event TodoCreated) error
+	TodoDone(ctx context.Context, event TodoDone) error
+}
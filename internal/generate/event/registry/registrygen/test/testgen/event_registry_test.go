@@ -0,0 +1,44 @@
+package testgen
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventRegistry_Names(t *testing.T) {
+	registry := NewEventRegistry()
+
+	assert.Equal(t, []string{"TodoDone", "todo.created"}, registry.Names())
+}
+
+func TestEventRegistry_New(t *testing.T) {
+	registry := NewEventRegistry()
+
+	event, err := registry.New("todo.created")
+	require.NoError(t, err)
+
+	err = json.Unmarshal([]byte(`{"ID":"1234","Title":"Do something"}`), event)
+	require.NoError(t, err)
+
+	assert.Equal(t, &TodoCreated{ID: "1234", Title: "Do something"}, event)
+}
+
+func TestEventRegistry_New_UnknownEvent(t *testing.T) {
+	registry := NewEventRegistry()
+
+	_, err := registry.New("TodoCreated")
+
+	assert.EqualError(t, err, "unknown event")
+}
+
+func TestEventRegistry_Name(t *testing.T) {
+	registry := NewEventRegistry()
+
+	assert.Equal(t, "todo.created", registry.Name(TodoCreated{}))
+	assert.Equal(t, "todo.created", registry.Name(&TodoCreated{}))
+	assert.Equal(t, "TodoDone", registry.Name(TodoDone{}))
+	assert.Equal(t, "", registry.Name("TodoDone"))
+}
@@ -0,0 +1,9 @@
+package testgen
+
+import (
+	"sagikazarmark.dev/mga/internal/generate/event/registry/registrygen/test"
+)
+
+type TodoCreated = test.TodoCreated
+
+type TodoDone = test.TodoDone
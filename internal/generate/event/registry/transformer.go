@@ -0,0 +1,56 @@
+package registry
+
+import (
+	"fmt"
+	"sort"
+
+	"sagikazarmark.dev/mga/pkg/gentypes"
+)
+
+// EventsFromTypes creates registry entries from event types.
+//
+// Events are named after their struct unless a name is provided for them in names.
+// Events are sorted by name, so the order of declarations does not change the generated registry.
+// Duplicate event types are registered only once, but two different event types cannot share the same name.
+// nolint: golint
+func EventsFromTypes(eventTypes []gentypes.TypeRef, names map[gentypes.TypeRef]string) ([]Event, error) {
+	events := make([]Event, 0, len(eventTypes))
+
+	seen := map[gentypes.TypeRef]bool{}
+	registered := map[string]gentypes.TypeRef{}
+
+	for _, eventType := range eventTypes {
+		if seen[eventType] {
+			continue
+		}
+
+		seen[eventType] = true
+
+		name := names[eventType]
+		if name == "" {
+			name = eventType.Name
+		}
+
+		if other, ok := registered[name]; ok {
+			return nil, fmt.Errorf(
+				"duplicate event name %q: %s.%s and %s.%s",
+				name,
+				other.Package.Path, other.Name,
+				eventType.Package.Path, eventType.Name,
+			)
+		}
+
+		registered[name] = eventType
+
+		events = append(events, Event{
+			Name:  name,
+			Event: eventType,
+		})
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].Name < events[j].Name
+	})
+
+	return events, nil
+}
@@ -0,0 +1,61 @@
+package registry
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sagikazarmark.dev/mga/pkg/gentypes"
+)
+
+func TestEventsFromTypes(t *testing.T) {
+	pkg := gentypes.PackageRef{
+		Name: "pkg",
+		Path: "app.dev/pkg",
+	}
+
+	todoCreated := gentypes.TypeRef{Name: "TodoCreated", Package: pkg}
+	todoDone := gentypes.TypeRef{Name: "TodoDone", Package: pkg}
+
+	events, err := EventsFromTypes(
+		[]gentypes.TypeRef{todoDone, todoCreated, todoDone},
+		map[gentypes.TypeRef]string{todoCreated: "todo.created"},
+	)
+	require.NoError(t, err)
+
+	expected := []Event{
+		{
+			Name:  "TodoDone",
+			Event: todoDone,
+		},
+		{
+			Name:  "todo.created",
+			Event: todoCreated,
+		},
+	}
+
+	assert.Equal(t, expected, events)
+}
+
+func TestEventsFromTypes_DuplicateName(t *testing.T) {
+	todoCreated := gentypes.TypeRef{
+		Name: "TodoCreated",
+		Package: gentypes.PackageRef{
+			Name: "pkg",
+			Path: "app.dev/pkg",
+		},
+	}
+
+	otherTodoCreated := gentypes.TypeRef{
+		Name: "TodoCreated",
+		Package: gentypes.PackageRef{
+			Name: "other",
+			Path: "app.dev/other",
+		},
+	}
+
+	_, err := EventsFromTypes([]gentypes.TypeRef{todoCreated, otherTodoCreated}, nil)
+
+	assert.EqualError(t, err, `duplicate event name "TodoCreated": app.dev/pkg.TodoCreated and app.dev/other.TodoCreated`)
+}
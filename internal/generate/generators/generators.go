@@ -0,0 +1,242 @@
+// Package generators lists every generator available in mga.
+package generators
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"sigs.k8s.io/controller-tools/pkg/genall"
+	"sigs.k8s.io/controller-tools/pkg/markers"
+
+	"sagikazarmark.dev/mga/internal/config"
+	"sagikazarmark.dev/mga/internal/generate/command/bus/busgen"
+	commandhandlergen "sagikazarmark.dev/mga/internal/generate/command/handler/handlergen"
+	"sagikazarmark.dev/mga/internal/generate/event/asyncapi/asyncapigen"
+	"sagikazarmark.dev/mga/internal/generate/event/dispatcher/dispatchergen"
+	eventhandlergen "sagikazarmark.dev/mga/internal/generate/event/handler/handlergen"
+	"sagikazarmark.dev/mga/internal/generate/event/registry/registrygen"
+	"sagikazarmark.dev/mga/internal/generate/kit/endpoint/endpointgen"
+	"sagikazarmark.dev/mga/internal/generate/plugin"
+	"sagikazarmark.dev/mga/internal/generate/template/templategen"
+	"sagikazarmark.dev/mga/internal/generate/testify/mock/mockgen"
+	"sagikazarmark.dev/mga/pkg/generator"
+	"sagikazarmark.dev/mga/pkg/genutils"
+)
+
+// nolint: gochecknoinits
+func init() {
+	for _, factory := range All() {
+		config.RegisterGenerator(factory.Name, factory.Output)
+	}
+}
+
+// Options are common generator options.
+type Options struct {
+	// HeaderFile specifies the header text (e.g. license) to prepend to generated files.
+	HeaderFile string
+
+	// Year specifies the year to substitute for " YEAR" in the header file.
+	Year string
+
+	// TemplateDir specifies the directory user-supplied templates are read from.
+	TemplateDir string
+
+	// FileName specifies the template of generated file names.
+	FileName string
+
+	// PerType writes a separate file for every marked type (if the generator supports it).
+	PerType bool
+}
+
+// Validate checks the options shared by generators (eg. the file name template).
+func (o Options) Validate() error {
+	return o.base().ValidateFileName()
+}
+
+func (o Options) base() generator.Base {
+	return generator.Base{
+		HeaderFile: o.HeaderFile,
+		Year:       o.Year,
+		FileName:   o.FileName,
+		PerType:    o.PerType,
+	}
+}
+
+// Factory creates a generator.
+type Factory struct {
+	// Name identifies the generator (eg. kit:endpoint).
+	Name string
+
+	// Output is the default output rule of the generator (eg. subpkg:suffix=driver).
+	Output string
+
+	// New creates a new generator.
+	New func(options Options) genall.Generator
+}
+
+// All returns every available generator (ordered by name).
+func All() []Factory {
+	return []Factory{
+		{
+			Name:   "command:bus",
+			Output: "pkg",
+			New: func(options Options) genall.Generator {
+				return busgen.Generator{Base: options.base()}
+			},
+		},
+		{
+			Name:   "command:handler",
+			Output: "pkg",
+			New: func(options Options) genall.Generator {
+				return commandhandlergen.Generator{Base: options.base()}
+			},
+		},
+		{
+			Name:   "event:asyncapi",
+			Output: "pkg",
+			New: func(_ Options) genall.Generator {
+				return asyncapigen.Generator{}
+			},
+		},
+		{
+			Name:   "event:dispatcher",
+			Output: "pkg",
+			New: func(options Options) genall.Generator {
+				return dispatchergen.Generator{Base: options.base()}
+			},
+		},
+		{
+			Name:   "event:handler",
+			Output: "pkg",
+			New: func(options Options) genall.Generator {
+				return eventhandlergen.Generator{Base: options.base()}
+			},
+		},
+		{
+			Name:   "event:registry",
+			Output: "pkg",
+			New: func(options Options) genall.Generator {
+				return registrygen.Generator{Base: options.base()}
+			},
+		},
+		{
+			Name:   "kit:endpoint",
+			Output: "subpkg:suffix=driver",
+			New: func(options Options) genall.Generator {
+				return endpointgen.Generator{Base: options.base()}
+			},
+		},
+		{
+			Name:   "template",
+			Output: "pkg",
+			New: func(options Options) genall.Generator {
+				return templategen.Generator{Base: options.base(), TemplateDir: options.TemplateDir}
+			},
+		},
+		{
+			Name:   "testify:mock",
+			Output: "pkg",
+			New: func(options Options) genall.Generator {
+				return mockgen.Generator{Base: options.base()}
+			},
+		},
+	}
+}
+
+// Plugins returns a generator for every plugin (mga-gen-<name> executable) found in PATH (ordered by name).
+//
+// Broken plugins are skipped: the returned errors describe why (and are meant to be reported as warnings).
+func Plugins() ([]Factory, []error) {
+	plugins, errs := plugin.Discover()
+
+	factories := make([]Factory, 0, len(plugins))
+
+	for _, p := range plugins {
+		factories = append(factories, Factory{
+			Name:   p.GeneratorName(),
+			Output: config.PluginOutput,
+			New: func(options Options) genall.Generator {
+				return plugin.Generator{Plugin: p, Base: options.base()}
+			},
+		})
+	}
+
+	return factories, errs
+}
+
+// WithPlugins returns every available generator (see All) followed by every plugin (see Plugins).
+//
+// Broken plugins are skipped with a warning written to w.
+func WithPlugins(w io.Writer) []Factory {
+	plugins, errs := Plugins()
+
+	for _, err := range errs {
+		fmt.Fprintf(w, "warning: skipping plugin: %v\n", err)
+	}
+
+	return append(All(), plugins...)
+}
+
+// Registry returns a registry with the markers of generators.
+//
+// The returned map lists the generators using each marker (by marker name).
+// Generators can share markers (eg. the event registry generator uses event handler markers),
+// but different markers with the same name (eg. a plugin marker named after a builtin one) are rejected.
+func Registry(factories []Factory) (*markers.Registry, map[string][]string, error) {
+	registry := &markers.Registry{}
+	usedBy := make(map[string][]string)
+	defs := make(map[string]*markers.Definition)
+
+	for _, factory := range factories {
+		generatorRegistry := &markers.Registry{}
+
+		if err := factory.New(Options{}).RegisterMarkers(generatorRegistry); err != nil {
+			return nil, nil, err
+		}
+
+		for _, def := range generatorRegistry.AllDefinitions() {
+			if other, ok := defs[def.Name]; ok && other != def {
+				return nil, nil, fmt.Errorf(
+					"generator %s: marker %q is already registered by generator %s",
+					factory.Name,
+					def.Name,
+					strings.Join(usedBy[def.Name], ", "),
+				)
+			}
+
+			defs[def.Name] = def
+			usedBy[def.Name] = append(usedBy[def.Name], factory.Name)
+
+			if err := registry.Register(def); err != nil {
+				return nil, nil, err
+			}
+
+			if help := generatorRegistry.HelpFor(def); help != nil {
+				registry.AddHelp(def, help)
+			}
+		}
+	}
+
+	return registry, usedBy, nil
+}
+
+// MarkerKinds returns the kinds of declarations markers of generators are restricted to (by marker name).
+//
+// See genutils.MarkerKinder.
+func MarkerKinds(factories []Factory) map[string]string {
+	kinds := make(map[string]string)
+
+	for _, factory := range factories {
+		kinder, ok := factory.New(Options{}).(genutils.MarkerKinder)
+		if !ok {
+			continue
+		}
+
+		for name, kind := range kinder.MarkerKinds() {
+			kinds[name] = kind
+		}
+	}
+
+	return kinds
+}
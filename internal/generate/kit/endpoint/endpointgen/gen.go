@@ -4,14 +4,13 @@ import (
 	"fmt"
 	"go/ast"
 	"go/types"
-	"io"
-	"strings"
 
 	"sigs.k8s.io/controller-tools/pkg/genall"
 	"sigs.k8s.io/controller-tools/pkg/loader"
 	"sigs.k8s.io/controller-tools/pkg/markers"
 
 	"sagikazarmark.dev/mga/internal/generate/kit/endpoint"
+	"sagikazarmark.dev/mga/pkg/generator"
 	"sagikazarmark.dev/mga/pkg/gentypes"
 	"sagikazarmark.dev/mga/pkg/genutils"
 )
@@ -23,7 +22,7 @@ var (
 
 // +controllertools:marker:generateHelp:category=Kit
 
-// Marker enables generating an endpoint for a service and provides information to the generator.
+// Marker enables generating Go kit endpoints for a service interface.
 type Marker struct {
 	// BaseName specifies a base name for the service (other than the one automatically generated).
 	//
@@ -44,11 +43,7 @@ type Marker struct {
 
 // Generator generates a Go kit Endpoint for a service.
 type Generator struct {
-	// HeaderFile specifies the header text (e.g. license) to prepend to generated files.
-	HeaderFile string `marker:",optional"`
-
-	// Year specifies the year to substitute for " YEAR" in the header file.
-	Year string `marker:",optional"`
+	generator.Base
 }
 
 func (g Generator) RegisterMarkers(into *markers.Registry) error {
@@ -56,14 +51,18 @@ func (g Generator) RegisterMarkers(into *markers.Registry) error {
 		return err
 	}
 
-	into.AddHelp(
-		endpointMarker,
-		markers.SimpleHelp("Kit", "enables endpoint generation for a service interface"),
-	)
+	into.AddHelp(endpointMarker, Marker{}.Help())
 
 	return nil
 }
 
+// MarkerKinds restricts markers to the kinds of declarations the generator handles.
+func (Generator) MarkerKinds() map[string]string {
+	return map[string]string{
+		endpointMarker.Name: genutils.InterfaceKind,
+	}
+}
+
 func (Generator) CheckFilter() loader.NodeFilter {
 	return func(node ast.Node) bool {
 		// ignore non-interfaces
@@ -73,33 +72,25 @@ func (Generator) CheckFilter() loader.NodeFilter {
 	}
 }
 
-func (g Generator) Generate(ctx *genall.GenerationContext) error {
-	var headerText string
-
-	if g.HeaderFile != "" {
-		headerBytes, err := ctx.ReadFile(g.HeaderFile)
-		if err != nil {
-			return err
-		}
+// OutputFiles returns the names of the files written by the generator.
+func (g Generator) OutputFiles() []string {
+	return g.OutputFilePatterns("endpoint")
+}
 
-		headerText = string(headerBytes)
+func (g Generator) Generate(ctx *genall.GenerationContext) error {
+	headerText, err := g.HeaderText(ctx)
+	if err != nil {
+		return err
 	}
 
-	headerText = strings.ReplaceAll(headerText, " YEAR", " "+g.Year)
-
 	for _, root := range ctx.Roots {
-		outContents := g.generatePackage(ctx, headerText, root)
-		if outContents == nil {
-			continue
-		}
-
-		writeOut(ctx, root, outContents)
+		g.generatePackage(ctx, headerText, root)
 	}
 
 	return nil
 }
 
-func (g Generator) generatePackage(ctx *genall.GenerationContext, headerText string, root *loader.Package) []byte {
+func (g Generator) generatePackage(ctx *genall.GenerationContext, headerText string, root *loader.Package) {
 	ctx.Checker.Check(root)
 
 	root.NeedTypesInfo()
@@ -148,55 +139,70 @@ func (g Generator) generatePackage(ctx *genall.GenerationContext, headerText str
 	if err != nil {
 		root.AddError(err)
 
-		return nil
+		return
 	}
 
 	if len(endpointSets) == 0 {
-		return nil
+		return
 	}
 
-	packageName, packagePath := root.Name, root.PkgPath
-	if pkgrefer, ok := ctx.OutputRule.(genutils.PackageRefer); ok {
-		packageName, packagePath = pkgrefer.PackageRef(root)
-	}
+	outputPackage := generator.OutputPackage(ctx, root)
 
 	file := endpoint.File{
 		File: gentypes.File{
-			Package: gentypes.PackageRef{
-				Name: packageName,
-				Path: packagePath,
-			},
+			Package:    outputPackage,
 			HeaderText: headerText,
 		},
 		EndpointSets: endpointSets,
 	}
 
-	outContents, err := endpoint.Generate(file)
-	if err != nil {
-		root.AddError(err)
+	files := generator.FileSet{}
+
+	if !g.PerType {
+		g.writeFile(ctx, root, files, "", file)
 
-		return nil
+		return
 	}
 
-	return outContents
+	// error interfaces shared by endpoint sets are written to a separate file
+	g.writeFile(ctx, root, files, "", endpoint.File{File: file.File})
+
+	for _, set := range endpointSets {
+		g.writeFile(ctx, root, files, set.Service.Object.Name(), endpoint.File{
+			File:           file.File,
+			EndpointSets:   []endpoint.EndpointSet{set},
+			OmitErrorTypes: true,
+		})
+	}
 }
 
-// writeOut outputs the given code.
-func writeOut(ctx *genall.GenerationContext, root *loader.Package, outBytes []byte) {
-	outputFile, err := ctx.Open(root, "zz_generated.endpoint.go")
+// writeFile generates endpoints into the file of a type (or the file of the package if the type name is empty).
+func (g Generator) writeFile(
+	ctx *genall.GenerationContext,
+	root *loader.Package,
+	files generator.FileSet,
+	typeName string,
+	file endpoint.File,
+) {
+	fileName, err := g.OutputFileName("endpoint", typeName)
 	if err != nil {
 		root.AddError(err)
 
 		return
 	}
-	defer outputFile.Close()
-	n, err := outputFile.Write(outBytes)
-	if err != nil {
+
+	if err := files.Add(fileName, typeName); err != nil {
 		root.AddError(err)
 
 		return
 	}
-	if n < len(outBytes) {
-		root.AddError(io.ErrShortWrite)
+
+	outContents, err := endpoint.Generate(file)
+	if err != nil {
+		root.AddError(err)
+
+		return
 	}
+
+	generator.WriteFile(ctx, root, fileName, outContents)
 }
@@ -0,0 +1,21 @@
+package endpointgen
+
+import (
+	"testing"
+
+	"sagikazarmark.dev/mga/pkg/generator"
+	"sagikazarmark.dev/mga/pkg/genutils/gentest"
+)
+
+func TestGenerator_PerType(t *testing.T) {
+	gentest.RunFile(t, "testdata/per_type.txtar", gentest.Test{
+		Generator: Generator{Base: generator.Base{PerType: true}},
+		Compile:   true,
+	})
+}
+
+func TestGenerator_PerType_Errors(t *testing.T) {
+	gentest.RunFile(t, "testdata/per_type_errors.txtar", gentest.Test{
+		Generator: Generator{Base: generator.Base{PerType: true}},
+	})
+}
@@ -0,0 +1,39 @@
+//go:build !ignore_autogenerated
+
+// Marker documentation shown by the markers command.
+
+// Code generated by helpgen. DO NOT EDIT.
+
+package endpointgen
+
+import (
+	"sigs.k8s.io/controller-tools/pkg/markers"
+)
+
+func (Marker) Help() *markers.DefinitionHelp {
+	return &markers.DefinitionHelp{
+		Category: "Kit",
+		DetailedHelp: markers.DetailedHelp{
+			Summary: "enables generating Go kit endpoints for a service interface.",
+			Details: "",
+		},
+		FieldHelp: map[string]markers.DetailedHelp{
+			"BaseName": {
+				Summary: "specifies a base name for the service (other than the one automatically generated).",
+				Details: "When not specified falls back to base name created from the service name.",
+			},
+			"ModuleName": {
+				Summary: "can be used instead of the package name in an operation name to uniquely identify a service call.",
+				Details: "Falls back to the package name.",
+			},
+			"WithOpenCensus": {
+				Summary: "enables generating a TraceEndpoint middleware.",
+				Details: "",
+			},
+			"ErrorStrategy": {
+				Summary: "decides whether returned errors are checked for being endpoint or service errors.",
+				Details: "",
+			},
+		},
+	}
+}
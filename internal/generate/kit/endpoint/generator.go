@@ -19,6 +19,10 @@ type File struct {
 
 	// EndpointSets represents endpoints to be generated for each service in the package.
 	EndpointSets []EndpointSet
+
+	// OmitErrorTypes omits the error interfaces shared by endpoint sets
+	// (when they are generated into a separate file).
+	OmitErrorTypes bool
 }
 
 // EndpointSet represents a set of endpoints for a single service.
@@ -59,15 +63,17 @@ func Generate(file File) ([]byte, error) {
 	code.ImportName("github.com/go-kit/kit/endpoint", "endpoint")
 	code.ImportAlias("github.com/sagikazarmark/kitx/endpoint", "kitxendpoint")
 
-	code.Comment("endpointError identifies an error that should be returned as an endpoint error.")
-	code.Type().Id("endpointError").Interface(
-		jen.Id("EndpointError").Params().Bool(),
-	)
+	if !file.OmitErrorTypes {
+		code.Comment("endpointError identifies an error that should be returned as an endpoint error.")
+		code.Type().Id("endpointError").Interface(
+			jen.Id("EndpointError").Params().Bool(),
+		)
 
-	code.Comment("serviceError identifies an error that should be returned as a service error.")
-	code.Type().Id("serviceError").Interface(
-		jen.Id("ServiceError").Params().Bool(),
-	)
+		code.Comment("serviceError identifies an error that should be returned as a service error.")
+		code.Type().Id("serviceError").Interface(
+			jen.Id("ServiceError").Params().Bool(),
+		)
+	}
 
 	for _, set := range file.EndpointSets {
 		generateEndpointSet(code, set)
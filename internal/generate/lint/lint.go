@@ -0,0 +1,372 @@
+// Package lint finds mistakes in mga markers (that generators would silently ignore).
+package lint
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"os"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/controller-tools/pkg/loader"
+	"sigs.k8s.io/controller-tools/pkg/markers"
+
+	"sagikazarmark.dev/mga/internal/generate/diagnostics"
+)
+
+// source identifies diagnostics reported by the linter.
+const source = "lint"
+
+// Linter checks markers in packages.
+type Linter struct {
+	registry   *markers.Registry
+	kinds      map[string]string
+	namespaces []string
+}
+
+// NewLinter returns a new Linter checking markers against the definitions in a registry.
+//
+// Kinds restrict markers to specific kinds of declarations beyond their target (see genutils.MarkerKinder).
+func NewLinter(registry *markers.Registry, kinds map[string]string) *Linter {
+	seen := make(map[string]bool)
+
+	var namespaces []string
+
+	for _, def := range registry.AllDefinitions() {
+		namespace, _, _ := strings.Cut(def.Name, ":")
+
+		if !seen[namespace] {
+			seen[namespace] = true
+			namespaces = append(namespaces, namespace)
+		}
+	}
+
+	sort.Strings(namespaces)
+
+	return &Linter{
+		registry:   registry,
+		kinds:      kinds,
+		namespaces: namespaces,
+	}
+}
+
+// Lint checks markers in the root packages.
+func (l *Linter) Lint(roots []*loader.Package) []diagnostics.Diagnostic {
+	var diags []diagnostics.Diagnostic
+
+	for _, root := range roots {
+		root.NeedSyntax()
+
+		// files are parsed in the same order as listed in CompiledGoFiles
+		for i, file := range root.Syntax {
+			diags = append(diags, l.lintFile(root, root.CompiledGoFiles[i], file)...)
+		}
+	}
+
+	diagnostics.Sort(diags)
+
+	return diags
+}
+
+func (l *Linter) lintFile(pkg *loader.Package, filename string, file *ast.File) []diagnostics.Diagnostic {
+	var diags []diagnostics.Diagnostic
+
+	attachments := attachComments(file)
+
+	var positions *token.File
+
+	for _, group := range file.Comments {
+		for _, comment := range group.List {
+			text, offset, ok := markerText(comment.Text)
+			if !ok || !l.looksLikeMarker(text) {
+				continue
+			}
+
+			severity, message := l.check(text, attachments[group])
+			if message == "" {
+				continue
+			}
+
+			if positions == nil {
+				positions = newPositions(filename, file)
+			}
+
+			diags = append(diags, diagnostics.Diagnostic{
+				Severity: severity,
+				Source:   source,
+				Package:  pkg.PkgPath,
+				Position: positions.Position(positions.Pos(int(comment.Pos()-file.FileStart) + offset)),
+				Message:  message,
+			})
+		}
+	}
+
+	return diags
+}
+
+// newPositions returns position information for a parsed file.
+//
+// The file set used for parsing is not available until the package is type checked,
+// so positions are calculated from the file content.
+func newPositions(filename string, file *ast.File) *token.File {
+	positions := token.NewFileSet().AddFile(filename, -1, int(file.FileEnd-file.FileStart))
+
+	if src, err := os.ReadFile(filename); err == nil {
+		positions.SetLinesForContent(src)
+	}
+
+	return positions
+}
+
+// markerText returns the marker (starting with +) in a comment and its offset in the comment.
+func markerText(comment string) (string, int, bool) {
+	if !strings.HasPrefix(comment, "//") {
+		return "", 0, false
+	}
+
+	stripped := strings.TrimLeft(comment[2:], " \t")
+	if !strings.HasPrefix(stripped, "+") {
+		return "", 0, false
+	}
+
+	return strings.TrimSpace(stripped), len(comment) - len(stripped), true
+}
+
+// looksLikeMarker reports whether a marker (probably) belongs to mga
+// (its namespace is the same as or close to a known marker namespace).
+func (l *Linter) looksLikeMarker(text string) bool {
+	name, _, _ := strings.Cut(text[1:], "=")
+
+	namespace, _, ok := strings.Cut(name, ":")
+	if !ok {
+		return false
+	}
+
+	namespace = strings.ToLower(namespace)
+
+	for _, known := range l.namespaces {
+		maxDistance := 2
+		if len(known) <= 3 {
+			maxDistance = 1
+		}
+
+		if distance(namespace, known) <= maxDistance {
+			return true
+		}
+	}
+
+	return false
+}
+
+// check returns the problem with a marker (if any).
+func (l *Linter) check(text string, attached attachment) (diagnostics.Severity, string) {
+	def := l.lookup(text)
+	if def == nil {
+		return diagnostics.SeverityError, l.unknownMarker(text)
+	}
+
+	if attached.node == nil {
+		if attached.scope != nil && attached.scope.kind == "function" {
+			return diagnostics.SeverityWarning, fmt.Sprintf("marker %q in a function body is ignored", "+"+def.Name)
+		}
+
+		if attached.scope != nil || def.Target != markers.DescribesPackage {
+			return diagnostics.SeverityWarning, fmt.Sprintf("marker %q is not attached to any declaration and is ignored", "+"+def.Name)
+		}
+	}
+
+	target := markers.DescribesPackage
+	kind := "package"
+
+	if attached.node != nil {
+		target = attached.node.target
+		kind = attached.node.kind
+	}
+
+	// the same marker might be defined for another target as well
+	if targetDef := l.registry.Lookup(text, target); targetDef != nil {
+		def = targetDef
+	}
+
+	expected, restricted := l.kinds[def.Name]
+	if !restricted {
+		expected = def.Target.String()
+	}
+
+	if def.Target != target || (restricted && expected != kind) {
+		return diagnostics.SeverityError, fmt.Sprintf(
+			"marker %q cannot be used on %s (expected %s)",
+			"+"+def.Name,
+			withArticle(kind),
+			withArticle(expected),
+		)
+	}
+
+	if def.Empty() && text != "+"+def.Name {
+		return diagnostics.SeverityError, fmt.Sprintf("marker %q does not accept arguments", "+"+def.Name)
+	}
+
+	if _, err := def.Parse(text); err != nil {
+		return diagnostics.SeverityError, l.invalidMarker(def, text, err)
+	}
+
+	return "", ""
+}
+
+// lookup finds the definition of a marker (for any target).
+func (l *Linter) lookup(text string) *markers.Definition {
+	for _, target := range []markers.TargetType{markers.DescribesType, markers.DescribesField, markers.DescribesPackage} {
+		if def := l.registry.Lookup(text, target); def != nil {
+			return def
+		}
+	}
+
+	return nil
+}
+
+// unknownMarker describes an unknown marker with suggestions.
+func (l *Linter) unknownMarker(text string) string {
+	name, _, _ := strings.Cut(text[1:], "=")
+
+	defs := l.registry.AllDefinitions()
+
+	sort.Slice(defs, func(i, j int) bool {
+		return defs[i].Name < defs[j].Name
+	})
+
+	// a known marker followed by an argument name without a value (eg. +testify:mock:testOnly)
+	for _, def := range defs {
+		argName, ok := strings.CutPrefix(name, def.Name+":")
+		if !ok || strings.Contains(argName, ":") {
+			continue
+		}
+
+		if _, ok := def.Fields[argName]; ok && argName != "" {
+			return fmt.Sprintf(
+				"argument %q of marker %q requires a value (eg. +%s=%s)",
+				argName,
+				"+"+def.Name,
+				name,
+				exampleValue(def.Fields[argName]),
+			)
+		}
+
+		if def.Empty() {
+			return fmt.Sprintf("marker %q does not accept arguments", "+"+def.Name)
+		}
+
+		if def.AnonymousField() {
+			return fmt.Sprintf("marker %q does not accept named arguments", "+"+def.Name)
+		}
+
+		return unknownArgument(def, argName)
+	}
+
+	message := fmt.Sprintf("unknown marker %q", "+"+name)
+
+	names := make([]string, 0, len(defs))
+	for _, def := range defs {
+		names = append(names, def.Name)
+	}
+
+	// compare known names to the same number of segments in the marker (the rest might be arguments)
+	segments := strings.Split(name, ":")
+
+	suggestion := closest(names, func(known string) string {
+		n := strings.Count(known, ":") + 1
+		if n > len(segments) {
+			return name
+		}
+
+		return strings.Join(segments[:n], ":")
+	})
+
+	if suggestion != "" {
+		message += fmt.Sprintf(" (did you mean %q?)", "+"+suggestion)
+	}
+
+	return message
+}
+
+// invalidMarker describes a marker parsing error.
+func (l *Linter) invalidMarker(def *markers.Definition, text string, err error) string {
+	var messages []string
+
+	for _, err := range flattenErrors(err) {
+		var scannerErr *markers.ScannerError
+
+		if errors.As(err, &scannerErr) {
+			if argName, ok := unknownArgumentName(scannerErr.Msg); ok {
+				return unknownArgument(def, argName)
+			}
+
+			messages = append(messages, scannerErr.Msg)
+
+			continue
+		}
+
+		messages = append(messages, err.Error())
+	}
+
+	return fmt.Sprintf("invalid marker %q: %s", text, strings.Join(messages, "; "))
+}
+
+func flattenErrors(err error) []error {
+	var errList loader.ErrList
+	if errors.As(err, &errList) {
+		return errList
+	}
+
+	return []error{err}
+}
+
+func unknownArgumentName(message string) (string, bool) {
+	var argName string
+
+	if _, err := fmt.Sscanf(message, "unknown argument %q", &argName); err != nil {
+		return "", false
+	}
+
+	return argName, true
+}
+
+// unknownArgument describes an unknown marker argument with suggestions.
+func unknownArgument(def *markers.Definition, argName string) string {
+	message := fmt.Sprintf("unknown argument %q for marker %q", argName, "+"+def.Name)
+
+	var argNames []string
+	for name := range def.Fields {
+		argNames = append(argNames, name)
+	}
+
+	sort.Strings(argNames)
+
+	if suggestion := closest(argNames, func(string) string { return argName }); suggestion != "" {
+		return message + fmt.Sprintf(" (did you mean %q?)", suggestion)
+	}
+
+	return message + fmt.Sprintf(" (valid arguments: %s)", strings.Join(argNames, ", "))
+}
+
+func exampleValue(arg markers.Argument) string {
+	switch arg.Type {
+	case markers.BoolType:
+		return "true"
+
+	case markers.IntType:
+		return "1"
+
+	default:
+		return `"value"`
+	}
+}
+
+func withArticle(kind string) string {
+	if strings.ContainsAny(kind[:1], "aeiou") {
+		return "an " + kind
+	}
+
+	return "a " + kind
+}
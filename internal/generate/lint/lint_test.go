@@ -0,0 +1,57 @@
+package lint
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/controller-tools/pkg/loader"
+
+	"sagikazarmark.dev/mga/internal/generate/generators"
+)
+
+func TestLinter_Lint(t *testing.T) {
+	registry, _, err := generators.Registry(generators.All())
+	require.NoError(t, err)
+
+	roots, err := loader.LoadRoots("./testdata/markers")
+	require.NoError(t, err)
+
+	diags := NewLinter(registry, generators.MarkerKinds(generators.All())).Lint(roots)
+
+	actual := make([]string, 0, len(diags))
+	for _, diag := range diags {
+		assert.Equal(t, "lint", diag.Source)
+		assert.Equal(t, "sagikazarmark.dev/mga/internal/generate/lint/testdata/markers", diag.Package)
+
+		actual = append(actual, fmt.Sprintf(
+			"%s:%d:%d: %s: %s",
+			filepath.Base(diag.Position.Filename),
+			diag.Position.Line,
+			diag.Position.Column,
+			diag.Severity,
+			diag.Message,
+		))
+	}
+
+	expected := []string{
+		`markers.go:9:5: error: marker "+mga:event:key" cannot be used on an interface method (expected a field)`,
+		`markers.go:13:4: error: unknown marker "+kit:endpont" (did you mean "+kit:endpoint"?)`,
+		`markers.go:16:4: error: unknown argument "testonly" for marker "+testify:mock" (did you mean "testOnly"?)`,
+		`markers.go:19:4: error: argument "testOnly" of marker "+testify:mock" requires a value (eg. +testify:mock:testOnly=true)`,
+		`markers.go:22:4: error: invalid marker "+testify:mock:testOnly=yes": expected true or false, got "yes"`,
+		`markers.go:25:4: error: marker "+kit:endpoint" cannot be used on a struct (expected an interface)`,
+		`markers.go:32:5: error: marker "+mga:event:handler" cannot be used on a field (expected a struct)`,
+		`markers.go:37:4: error: unknown argument "grup" for marker "+mga:event:handler" (did you mean "group"?)`,
+		`markers.go:40:4: error: marker "+mga:command:bus" does not accept arguments`,
+		`markers.go:47:4: error: marker "+mga:event:dispatcher" cannot be used on a function (expected an interface)`,
+		`markers.go:49:5: warning: marker "+testify:mock" in a function body is ignored`,
+		`markers.go:52:4: error: unknown marker "+tesitfy:mock" (did you mean "+testify:mock"?)`,
+		`markers.go:55:4: error: unknown marker "+kit:transport"`,
+		`markers.go:59:5: warning: marker "+mga:event:key" is not attached to any declaration and is ignored`,
+	}
+
+	assert.Equal(t, expected, actual)
+}
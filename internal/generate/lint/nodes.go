@@ -0,0 +1,249 @@
+package lint
+
+import (
+	"go/ast"
+	"go/token"
+	"sort"
+
+	"sigs.k8s.io/controller-tools/pkg/markers"
+)
+
+// node is a declaration markers can be attached to.
+type node struct {
+	// kind is a human readable description of the node (eg. interface, struct, field).
+	kind string
+
+	// target is the marker target the generators see the node as.
+	target markers.TargetType
+
+	// pos is the start of the node (including its doc comment).
+	pos token.Pos
+
+	doc   *ast.CommentGroup
+	scope *scope
+}
+
+// scope is a range of source code with its own declarations (eg. a struct type or a function body).
+type scope struct {
+	kind     string
+	pos, end token.Pos
+}
+
+// attachment tells which node a comment group is attached to.
+type attachment struct {
+	// node is nil if the comment group is not attached to any node.
+	node *node
+
+	// scope is the innermost scope containing the comment group (nil at top level).
+	scope *scope
+}
+
+// attachComments associates comment groups of a file with the declarations they describe
+// (following the rules of the marker collector):
+//
+//   - the doc comment of a declaration belongs to the declaration
+//   - the last comment group before a declaration (or its doc comment) belongs to the declaration
+//   - other top level comment groups belong to the package
+func attachComments(file *ast.File) map[*ast.CommentGroup]attachment {
+	nodes, scopes := collectNodes(file)
+
+	sort.SliceStable(nodes, func(i, j int) bool {
+		return nodes[i].pos < nodes[j].pos
+	})
+
+	byDoc := make(map[*ast.CommentGroup]*node)
+
+	for _, n := range nodes {
+		if n.doc != nil {
+			byDoc[n.doc] = n
+		}
+	}
+
+	attachments := make(map[*ast.CommentGroup]attachment, len(file.Comments))
+
+	for i, group := range file.Comments {
+		if n, ok := byDoc[group]; ok {
+			attachments[group] = attachment{node: n, scope: n.scope}
+
+			continue
+		}
+
+		groupScope := innermostScope(scopes, group.Pos())
+
+		// the next comment group (if any) is closer to the following declaration
+		nextComment := token.NoPos
+		if i+1 < len(file.Comments) {
+			nextComment = file.Comments[i+1].Pos()
+		}
+
+		var attached *node
+
+		for _, n := range nodes {
+			if n.pos < group.End() || n.scope != groupScope {
+				continue
+			}
+
+			if nextComment.IsValid() && nextComment < n.pos && byDoc[file.Comments[i+1]] != n {
+				break
+			}
+
+			attached = n
+
+			break
+		}
+
+		attachments[group] = attachment{node: attached, scope: groupScope}
+	}
+
+	return attachments
+}
+
+func innermostScope(scopes []*scope, pos token.Pos) *scope {
+	var innermost *scope
+
+	for _, s := range scopes {
+		if s.pos <= pos && pos < s.end && (innermost == nil || s.pos >= innermost.pos) {
+			innermost = s
+		}
+	}
+
+	return innermost
+}
+
+func collectNodes(file *ast.File) ([]*node, []*scope) {
+	c := &collector{}
+
+	c.add(&node{
+		kind:   "package",
+		target: markers.DescribesPackage,
+		pos:    file.Package,
+		doc:    file.Doc,
+	})
+
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.GenDecl:
+			c.genDecl(decl)
+
+		case *ast.FuncDecl:
+			kind := "function"
+			if decl.Recv != nil {
+				kind = "method"
+			}
+
+			c.add(&node{
+				kind:   kind,
+				target: markers.DescribesPackage,
+				pos:    decl.Pos(),
+				doc:    decl.Doc,
+			})
+
+			c.scopes = append(c.scopes, &scope{kind: "function", pos: decl.Pos(), end: decl.End()})
+		}
+	}
+
+	return c.nodes, c.scopes
+}
+
+type collector struct {
+	nodes  []*node
+	scopes []*scope
+}
+
+func (c *collector) add(n *node) {
+	if n.doc != nil {
+		n.pos = n.doc.Pos()
+	}
+
+	c.nodes = append(c.nodes, n)
+}
+
+func (c *collector) genDecl(decl *ast.GenDecl) {
+	switch decl.Tok {
+	case token.TYPE:
+		if decl.Lparen.IsValid() {
+			// markers on grouped type declarations describe the package
+			c.add(&node{
+				kind:   "type declaration group",
+				target: markers.DescribesPackage,
+				pos:    decl.Pos(),
+				doc:    decl.Doc,
+			})
+		}
+
+		for _, spec := range decl.Specs {
+			typeSpec := spec.(*ast.TypeSpec)
+
+			n := &node{
+				kind:   typeKind(typeSpec.Type),
+				target: markers.DescribesType,
+				pos:    typeSpec.Pos(),
+				doc:    typeSpec.Doc,
+			}
+
+			if !decl.Lparen.IsValid() {
+				n.pos = decl.Pos()
+
+				if decl.Doc != nil {
+					n.doc = decl.Doc
+				}
+			}
+
+			c.add(n)
+			c.typeExpr(typeSpec.Type)
+		}
+
+	case token.VAR:
+		c.add(&node{kind: "variable", target: markers.DescribesPackage, pos: decl.Pos(), doc: decl.Doc})
+
+	case token.CONST:
+		c.add(&node{kind: "constant", target: markers.DescribesPackage, pos: decl.Pos(), doc: decl.Doc})
+
+	case token.IMPORT:
+		c.add(&node{kind: "import", target: markers.DescribesPackage, pos: decl.Pos(), doc: decl.Doc})
+	}
+}
+
+// typeExpr collects fields (and interface methods) of struct and interface types.
+func (c *collector) typeExpr(expr ast.Expr) {
+	switch expr := expr.(type) {
+	case *ast.StructType:
+		s := &scope{kind: "struct", pos: expr.Pos(), end: expr.End()}
+		c.scopes = append(c.scopes, s)
+
+		for _, field := range expr.Fields.List {
+			c.add(&node{kind: "field", target: markers.DescribesField, pos: field.Pos(), doc: field.Doc, scope: s})
+			c.typeExpr(field.Type)
+		}
+
+	case *ast.InterfaceType:
+		s := &scope{kind: "interface", pos: expr.Pos(), end: expr.End()}
+		c.scopes = append(c.scopes, s)
+
+		for _, method := range expr.Methods.List {
+			c.add(&node{kind: "interface method", target: markers.DescribesField, pos: method.Pos(), doc: method.Doc, scope: s})
+		}
+
+	case *ast.StarExpr:
+		c.typeExpr(expr.X)
+
+	case *ast.ArrayType:
+		c.typeExpr(expr.Elt)
+
+	case *ast.MapType:
+		c.typeExpr(expr.Value)
+	}
+}
+
+func typeKind(expr ast.Expr) string {
+	switch expr.(type) {
+	case *ast.InterfaceType:
+		return "interface"
+
+	case *ast.StructType:
+		return "struct"
+
+	default:
+		return "type"
+	}
+}
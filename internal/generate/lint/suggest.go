@@ -0,0 +1,73 @@
+package lint
+
+import (
+	"strings"
+)
+
+// closest returns the candidate closest to the (candidate specific) input if it is close enough.
+func closest(candidates []string, input func(candidate string) string) string {
+	var (
+		best         string
+		bestDistance int
+	)
+
+	for _, candidate := range candidates {
+		in := input(candidate)
+
+		// case differences are the most likely typos
+		if strings.EqualFold(in, candidate) {
+			return candidate
+		}
+
+		d := distance(strings.ToLower(in), strings.ToLower(candidate))
+
+		if d > maxDistance(candidate) {
+			continue
+		}
+
+		if best == "" || d < bestDistance {
+			best = candidate
+			bestDistance = d
+		}
+	}
+
+	return best
+}
+
+// maxDistance returns the maximum edit distance to consider a string a typo of a candidate.
+func maxDistance(candidate string) int {
+	if d := len(candidate) / 3; d > 1 {
+		return d
+	}
+
+	return 1
+}
+
+// distance returns the Levenshtein distance between two strings.
+func distance(a string, b string) int {
+	ar, br := []rune(a), []rune(b)
+
+	prev := make([]int, len(br)+1)
+	curr := make([]int, len(br)+1)
+
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ar); i++ {
+		curr[0] = i
+
+		for j := 1; j <= len(br); j++ {
+			cost := 1
+			if ar[i-1] == br[j-1] {
+				cost = 0
+			}
+
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+
+		prev, curr = curr, prev
+	}
+
+	return prev[len(br)]
+}
@@ -0,0 +1,38 @@
+package lint
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDistance(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"", "abc", 3},
+		{"endpoint", "endpoint", 0},
+		{"endpont", "endpoint", 1},
+		{"tesitfy", "testify", 2},
+		{"kitten", "sitting", 3},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, distance(test.a, test.b), "%s -> %s", test.a, test.b)
+	}
+}
+
+func TestClosest(t *testing.T) {
+	candidates := []string{"external", "testOnly"}
+
+	same := func(input string) func(string) string {
+		return func(string) string { return input }
+	}
+
+	assert.Equal(t, "testOnly", closest(candidates, same("testonly")))
+	assert.Equal(t, "external", closest(candidates, same("extrenal")))
+	assert.Equal(t, "", closest(candidates, same("group")))
+}
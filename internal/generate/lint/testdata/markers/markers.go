@@ -0,0 +1,60 @@
+package markers
+
+import (
+	"context"
+)
+
+// +kit:endpoint
+type Service interface {
+	// +mga:event:key
+	Do(ctx context.Context) error
+}
+
+// +kit:endpont
+type Typo interface{}
+
+// +testify:mock:testonly=true
+type WrongCase interface{}
+
+// +testify:mock:testOnly
+type MissingValue interface{}
+
+// +testify:mock:testOnly=yes
+type InvalidValue interface{}
+
+// +kit:endpoint
+
+// Struct is not an interface.
+type Struct struct {
+	// +mga:event:key
+	ID string
+
+	// +mga:event:handler
+	Name string
+}
+
+// +mga:event:version=1
+// +mga:event:handler:grup=Todo
+type TodoCreatedV1 struct{}
+
+// +mga:command:bus:foo=bar
+type Bus interface{}
+
+// +kubebuilder:object:root=true
+// +k8s:deepcopy-gen=true
+type Other struct{}
+
+// +mga:event:dispatcher
+func NewService() {
+	// +testify:mock
+}
+
+// +tesitfy:mock
+var v = 1
+
+// +kit:transport
+type Transport interface{}
+
+type Trailing struct {
+	// +mga:event:key
+}
@@ -0,0 +1,348 @@
+// Package markerdoc documents the markers supported by generators.
+package markerdoc
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode"
+
+	"sigs.k8s.io/controller-tools/pkg/markers"
+
+	"sagikazarmark.dev/mga/internal/generate/generators"
+	"sagikazarmark.dev/mga/pkg/genutils"
+)
+
+// Documentation describes every marker and output rule.
+type Documentation struct {
+	// Markers are the markers recognized by generators in source code.
+	Markers []Marker `json:"markers"`
+
+	// OutputRules are the values accepted by the --output flag (and the output option in mga.yaml).
+	OutputRules []Marker `json:"outputRules"`
+}
+
+// Marker describes a marker.
+type Marker struct {
+	// Name of the marker (without the + prefix).
+	Name string `json:"name"`
+
+	// Target is the kind of node the marker is placed on (package, type or field).
+	Target string `json:"target"`
+
+	// Category of the marker.
+	Category string `json:"category,omitempty"`
+
+	// Generators using the marker.
+	Generators []string `json:"generators,omitempty"`
+
+	// Summary is a short description of the marker.
+	Summary string `json:"summary"`
+
+	// Details is a longer description of the marker.
+	Details string `json:"details,omitempty"`
+
+	// Arguments of the marker.
+	Arguments []Argument `json:"arguments,omitempty"`
+}
+
+// Argument describes a marker argument.
+type Argument struct {
+	// Name of the argument. Empty if the marker takes a single value (eg. +mga:event:version=2).
+	Name string `json:"name"`
+
+	// Type of the argument.
+	Type string `json:"type"`
+
+	// Optional tells if the argument can be omitted.
+	Optional bool `json:"optional"`
+
+	// Summary is a short description of the argument.
+	Summary string `json:"summary,omitempty"`
+
+	// Details is a longer description of the argument.
+	Details string `json:"details,omitempty"`
+}
+
+// Describe returns the documentation of every marker of generators and every output rule.
+func Describe(factories []generators.Factory) (Documentation, error) {
+	registry, usedBy, err := generators.Registry(factories)
+	if err != nil {
+		return Documentation{}, err
+	}
+
+	outputRegistry := &markers.Registry{}
+
+	if err := genutils.RegisterOutputRules(outputRegistry); err != nil {
+		return Documentation{}, err
+	}
+
+	doc := Documentation{
+		Markers:     describeRegistry(registry, usedBy),
+		OutputRules: describeRegistry(outputRegistry, nil),
+	}
+
+	for i, rule := range doc.OutputRules {
+		doc.OutputRules[i].Name = strings.TrimPrefix(rule.Name, "output:")
+		doc.OutputRules[i].Target = ""
+	}
+
+	return doc, nil
+}
+
+func describeRegistry(registry *markers.Registry, usedBy map[string][]string) []Marker {
+	defs := registry.AllDefinitions()
+
+	sort.Slice(defs, func(i, j int) bool {
+		return defs[i].Name < defs[j].Name
+	})
+
+	descriptions := make([]Marker, 0, len(defs))
+
+	for _, def := range defs {
+		descriptions = append(descriptions, describe(def, registry.HelpFor(def), usedBy[def.Name]))
+	}
+
+	return descriptions
+}
+
+func describe(def *markers.Definition, help *markers.DefinitionHelp, usedBy []string) Marker {
+	if help == nil {
+		help = &markers.DefinitionHelp{}
+	}
+
+	marker := Marker{
+		Name:       def.Name,
+		Target:     def.Target.String(),
+		Category:   help.Category,
+		Generators: usedBy,
+		Summary:    help.Summary,
+		Details:    help.Details,
+	}
+
+	fieldsHelp := help.FieldsHelp(def)
+
+	argNames := make([]string, 0, len(def.Fields))
+	for argName := range def.Fields {
+		argNames = append(argNames, argName)
+	}
+
+	sort.Strings(argNames)
+
+	for _, argName := range argNames {
+		arg := def.Fields[argName]
+
+		marker.Arguments = append(marker.Arguments, Argument{
+			Name:     argName,
+			Type:     arg.TypeString(),
+			Optional: arg.Optional,
+			Summary:  fieldsHelp[argName].Summary,
+			Details:  fieldsHelp[argName].Details,
+		})
+	}
+
+	return marker
+}
+
+// WriteText writes the documentation as plain text.
+func WriteText(w io.Writer, doc Documentation) error {
+	var b strings.Builder
+
+	b.WriteString("Markers:\n")
+
+	for _, marker := range doc.Markers {
+		b.WriteString("\n")
+		writeTextMarker(&b, "+"+marker.Name, marker)
+	}
+
+	b.WriteString("\nOutput rules:\n")
+
+	for _, rule := range doc.OutputRules {
+		b.WriteString("\n")
+		writeTextMarker(&b, rule.Name, rule)
+	}
+
+	_, err := io.WriteString(w, b.String())
+
+	return err
+}
+
+func writeTextMarker(b *strings.Builder, name string, marker Marker) {
+	b.WriteString(name)
+
+	if marker.Target != "" {
+		fmt.Fprintf(b, " (%s)", marker.Target)
+	}
+
+	b.WriteString("\n")
+
+	if marker.Summary != "" {
+		fmt.Fprintf(b, "\t%s\n", marker.Summary)
+	}
+
+	if marker.Details != "" {
+		b.WriteString(indent(marker.Details, "\t"))
+	}
+
+	if len(marker.Generators) > 0 {
+		fmt.Fprintf(b, "\tgenerators: %s\n", strings.Join(marker.Generators, ", "))
+	}
+
+	for _, arg := range marker.Arguments {
+		b.WriteString("\n")
+
+		name := arg.Name
+		if name == "" {
+			name = "=<value>"
+		}
+
+		fmt.Fprintf(b, "\t%s %s", name, arg.Type)
+
+		if arg.Optional {
+			b.WriteString(" (optional)")
+		}
+
+		b.WriteString("\n")
+
+		if arg.Summary != "" {
+			fmt.Fprintf(b, "\t\t%s\n", arg.Summary)
+		}
+
+		if arg.Details != "" {
+			b.WriteString(indent(arg.Details, "\t\t"))
+		}
+	}
+}
+
+func indent(s string, prefix string) string {
+	var b strings.Builder
+
+	for _, line := range strings.Split(strings.TrimSpace(s), "\n") {
+		if line == "" {
+			b.WriteString("\n")
+
+			continue
+		}
+
+		b.WriteString(prefix + line + "\n")
+	}
+
+	return b.String()
+}
+
+// WriteMarkdown writes the documentation as Markdown.
+func WriteMarkdown(w io.Writer, doc Documentation) error {
+	var b strings.Builder
+
+	b.WriteString("# Markers\n")
+
+	for _, marker := range doc.Markers {
+		b.WriteString("\n")
+		writeMarkdownMarker(&b, "+"+marker.Name, marker)
+	}
+
+	b.WriteString("\n# Output rules\n")
+
+	for _, rule := range doc.OutputRules {
+		b.WriteString("\n")
+		writeMarkdownMarker(&b, rule.Name, rule)
+	}
+
+	_, err := io.WriteString(w, b.String())
+
+	return err
+}
+
+func writeMarkdownMarker(b *strings.Builder, name string, marker Marker) {
+	sections := []string{fmt.Sprintf("## `%s`", name)}
+
+	summary, details := marker.Summary, strings.TrimSpace(marker.Details)
+
+	// godoc based summaries are cut at the end of the first line: continue the sentence from the details
+	if details != "" && unicode.IsLower([]rune(details)[0]) {
+		first, rest, _ := strings.Cut(details, "\n\n")
+
+		summary += " " + first
+		details = rest
+	}
+
+	if summary != "" {
+		sections = append(sections, paragraph(summary))
+	}
+
+	if details != "" {
+		sections = append(sections, details)
+	}
+
+	var list strings.Builder
+
+	if marker.Target != "" {
+		fmt.Fprintf(&list, "- Target: %s\n", marker.Target)
+	}
+
+	if len(marker.Generators) > 0 {
+		fmt.Fprintf(&list, "- Generators: `%s`\n", strings.Join(marker.Generators, "`, `"))
+	}
+
+	if list.Len() > 0 {
+		sections = append(sections, strings.TrimSuffix(list.String(), "\n"))
+	}
+
+	if len(marker.Arguments) > 0 {
+		var table strings.Builder
+
+		table.WriteString("| Argument | Type | Optional | Description |\n")
+		table.WriteString("| -------- | ---- | -------- | ----------- |")
+
+		for _, arg := range marker.Arguments {
+			name := "`" + arg.Name + "`"
+			if arg.Name == "" {
+				name = "*value*"
+			}
+
+			optional := "no"
+			if arg.Optional {
+				optional = "yes"
+			}
+
+			description := paragraph(arg.Summary + " " + arg.Details)
+
+			fmt.Fprintf(
+				&table,
+				"\n| %s | `%s` | %s | %s |",
+				name,
+				arg.Type,
+				optional,
+				strings.ReplaceAll(description, "|", "\\|"),
+			)
+		}
+
+		sections = append(sections, table.String())
+	}
+
+	b.WriteString(strings.Join(sections, "\n\n") + "\n")
+}
+
+// paragraph turns a (godoc based) description into a single capitalized sentence.
+func paragraph(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if s == "" {
+		return s
+	}
+
+	if !strings.HasSuffix(s, ".") {
+		s += "."
+	}
+
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// WriteJSON writes the documentation as JSON.
+func WriteJSON(w io.Writer, doc Documentation) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(doc)
+}
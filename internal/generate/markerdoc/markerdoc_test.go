@@ -0,0 +1,199 @@
+package markerdoc
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sagikazarmark.dev/mga/internal/generate/generators"
+)
+
+func findMarker(t *testing.T, markers []Marker, name string) Marker {
+	t.Helper()
+
+	for _, marker := range markers {
+		if marker.Name == name {
+			return marker
+		}
+	}
+
+	t.Fatalf("marker %q not found", name)
+
+	return Marker{}
+}
+
+func TestDescribe(t *testing.T) {
+	doc, err := Describe(generators.All())
+	require.NoError(t, err)
+
+	mock := findMarker(t, doc.Markers, "testify:mock")
+
+	assert.Equal(t, "type", mock.Target)
+	assert.Equal(t, []string{"testify:mock"}, mock.Generators)
+	assert.Contains(t, mock.Summary, "mock")
+	assert.Equal(
+		t,
+		[]Argument{
+			{
+				Name:     "external",
+				Type:     "bool",
+				Optional: true,
+				Summary:  "tells the generator to write the generated mock in an \"external\" test package",
+				Details:  "(package name suffixed with _test).\nExternal also implies TestOnly, but setting both values will generate the mock twice:\nonce in the same package in a test file, once in an external package.",
+			},
+			{
+				Name:     "testOnly",
+				Type:     "bool",
+				Optional: true,
+				Summary:  "tells the generator to write the generated mock in a test file.",
+			},
+		},
+		mock.Arguments,
+	)
+
+	for _, marker := range doc.Markers {
+		assert.NotEmpty(t, marker.Summary, marker.Name)
+		assert.NotEmpty(t, marker.Generators, marker.Name)
+	}
+
+	handler := findMarker(t, doc.Markers, "mga:event:handler")
+	assert.Equal(t, []string{"event:asyncapi", "event:handler", "event:registry"}, handler.Generators)
+
+	version := findMarker(t, doc.Markers, "mga:event:version")
+	assert.Equal(t, []Argument{{Type: "int"}}, version.Arguments)
+
+	key := findMarker(t, doc.Markers, "mga:event:key")
+	assert.Equal(t, "field", key.Target)
+	assert.Empty(t, key.Arguments)
+
+	subpkg := findMarker(t, doc.OutputRules, "subpkg")
+	assert.Empty(t, subpkg.Target)
+	assert.Len(t, subpkg.Arguments, 3)
+}
+
+// nolint: gochecknoglobals
+var testDoc = Documentation{
+	Markers: []Marker{
+		{
+			Name:       "mga:event:handler",
+			Target:     "type",
+			Generators: []string{"event:handler", "event:registry"},
+			Summary:    "enables generating an event handler for an event struct.",
+			Arguments: []Argument{
+				{
+					Name:     "group",
+					Type:     "string",
+					Optional: true,
+					Summary:  "generates a single handler for every event in the same group",
+					Details:  "(instead of one handler per event).\n\nThe group name is used as a prefix.",
+				},
+			},
+		},
+		{
+			Name:       "mga:event:version",
+			Target:     "type",
+			Generators: []string{"event:handler"},
+			Summary:    "marks an event struct as a version of an event",
+			Arguments:  []Argument{{Type: "int"}},
+		},
+	},
+	OutputRules: []Marker{
+		{
+			Name:    "stdout",
+			Summary: "outputs everything to standard-out, with no separation",
+			Details: "Generally useful for single-artifact outputs.",
+		},
+	},
+}
+
+func TestWriteText(t *testing.T) {
+	var buf bytes.Buffer
+
+	err := WriteText(&buf, testDoc)
+	require.NoError(t, err)
+
+	expected := `Markers:
+
++mga:event:handler (type)
+	enables generating an event handler for an event struct.
+	generators: event:handler, event:registry
+
+	group string (optional)
+		generates a single handler for every event in the same group
+		(instead of one handler per event).
+
+		The group name is used as a prefix.
+
++mga:event:version (type)
+	marks an event struct as a version of an event
+	generators: event:handler
+
+	=<value> int
+
+Output rules:
+
+stdout
+	outputs everything to standard-out, with no separation
+	Generally useful for single-artifact outputs.
+`
+
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	var buf bytes.Buffer
+
+	err := WriteMarkdown(&buf, testDoc)
+	require.NoError(t, err)
+
+	expected := "# Markers\n" +
+		"\n" +
+		"## `+mga:event:handler`\n" +
+		"\n" +
+		"Enables generating an event handler for an event struct.\n" +
+		"\n" +
+		"- Target: type\n" +
+		"- Generators: `event:handler`, `event:registry`\n" +
+		"\n" +
+		"| Argument | Type | Optional | Description |\n" +
+		"| -------- | ---- | -------- | ----------- |\n" +
+		"| `group` | `string` | yes | Generates a single handler for every event in the same group (instead of one handler per event). The group name is used as a prefix. |\n" +
+		"\n" +
+		"## `+mga:event:version`\n" +
+		"\n" +
+		"Marks an event struct as a version of an event.\n" +
+		"\n" +
+		"- Target: type\n" +
+		"- Generators: `event:handler`\n" +
+		"\n" +
+		"| Argument | Type | Optional | Description |\n" +
+		"| -------- | ---- | -------- | ----------- |\n" +
+		"| *value* | `int` | no |  |\n" +
+		"\n" +
+		"# Output rules\n" +
+		"\n" +
+		"## `stdout`\n" +
+		"\n" +
+		"Outputs everything to standard-out, with no separation.\n" +
+		"\n" +
+		"Generally useful for single-artifact outputs.\n"
+
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestWriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+
+	err := WriteJSON(&buf, testDoc)
+	require.NoError(t, err)
+
+	var doc Documentation
+
+	err = json.Unmarshal(buf.Bytes(), &doc)
+	require.NoError(t, err)
+
+	assert.Equal(t, testDoc, doc)
+}
@@ -0,0 +1,211 @@
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/types"
+	"path/filepath"
+
+	"sigs.k8s.io/controller-tools/pkg/genall"
+	"sigs.k8s.io/controller-tools/pkg/loader"
+	"sigs.k8s.io/controller-tools/pkg/markers"
+
+	"sagikazarmark.dev/mga/pkg/generator"
+	"sagikazarmark.dev/mga/pkg/genplugin"
+	"sagikazarmark.dev/mga/pkg/gentypes"
+	"sagikazarmark.dev/mga/pkg/genutils"
+)
+
+// Generator runs a plugin for packages containing its markers.
+type Generator struct {
+	generator.Base
+
+	// Plugin generating the code.
+	Plugin Plugin
+}
+
+func (g Generator) RegisterMarkers(into *markers.Registry) error {
+	for _, m := range g.Plugin.markers {
+		if err := into.Register(m.def); err != nil {
+			return fmt.Errorf("plugin %s: %w", g.Plugin.Name, err)
+		}
+
+		into.AddHelp(m.def, m.help)
+	}
+
+	return nil
+}
+
+// MarkerKinds restricts markers to the kinds of declarations declared by the plugin.
+func (g Generator) MarkerKinds() map[string]string {
+	kinds := make(map[string]string)
+
+	for _, m := range g.Plugin.markers {
+		if m.kind != "" {
+			kinds[m.def.Name] = m.kind
+		}
+	}
+
+	return kinds
+}
+
+func (Generator) CheckFilter() loader.NodeFilter {
+	return func(node ast.Node) bool {
+		return true
+	}
+}
+
+// OutputFiles returns the names of the files written by the plugin.
+func (g Generator) OutputFiles() []string {
+	return g.Plugin.Description.OutputFiles
+}
+
+func (g Generator) Generate(ctx *genall.GenerationContext) error {
+	headerText, err := g.HeaderText(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, root := range ctx.Roots {
+		g.generatePackage(ctx, headerText, root)
+	}
+
+	return nil
+}
+
+func (g Generator) generatePackage(ctx *genall.GenerationContext, headerText string, root *loader.Package) {
+	ctx.Checker.Check(root)
+
+	root.NeedTypesInfo()
+
+	packageMarkers, err := markers.PackageMarkers(ctx.Collector, root)
+	if err != nil {
+		root.AddError(err)
+
+		return
+	}
+
+	req := genplugin.Request{
+		Package: gentypes.PackageRef{
+			Name: root.Name,
+			Path: root.PkgPath,
+		},
+		HeaderText: headerText,
+	}
+
+	req.Markers, err = g.Plugin.markerValues(packageMarkers)
+	if err != nil {
+		root.AddError(err)
+
+		return
+	}
+
+	specs := make(map[string]*ast.TypeSpec)
+
+	err = markers.EachType(ctx.Collector, root, func(info *markers.TypeInfo) {
+		typeDecl, ok, err := g.describeType(root, info)
+		if err != nil {
+			root.AddError(loader.ErrFromNode(err, info.RawSpec))
+
+			return
+		}
+
+		if ok {
+			req.Types = append(req.Types, typeDecl)
+			specs[info.Name] = info.RawSpec
+		}
+	})
+	if err != nil {
+		root.AddError(err)
+
+		return
+	}
+
+	if len(req.Markers) == 0 && len(req.Types) == 0 {
+		return
+	}
+
+	req.Output = req.Package
+	if pkgrefer, ok := ctx.OutputRule.(genutils.PackageRefer); ok {
+		req.Output.Name, req.Output.Path = pkgrefer.PackageRef(root)
+	}
+
+	resp, err := g.Plugin.Generate(req)
+	if err != nil {
+		root.AddError(err)
+
+		return
+	}
+
+	for _, respErr := range resp.Errors {
+		err := errors.New(respErr.Message)
+
+		if spec, ok := specs[respErr.Type]; ok {
+			root.AddError(loader.ErrFromNode(err, spec))
+
+			continue
+		}
+
+		root.AddError(err)
+	}
+
+	for _, file := range resp.Files {
+		if file.Name == "" || filepath.Base(file.Name) != file.Name {
+			root.AddError(fmt.Errorf("plugin %s: invalid file name %q", g.Plugin.Name, file.Name))
+
+			continue
+		}
+
+		generator.WriteFile(ctx, root, file.Name, []byte(file.Content))
+	}
+}
+
+// describeType describes a type if the type or any of its fields has markers of the plugin.
+func (g Generator) describeType(root *loader.Package, info *markers.TypeInfo) (gentypes.TypeDecl, bool, error) {
+	typeMarkers, err := g.Plugin.markerValues(info.Markers)
+	if err != nil {
+		return gentypes.TypeDecl{}, false, err
+	}
+
+	fieldMarkers := make([]map[string][]interface{}, len(info.Fields))
+	hasFieldMarkers := false
+
+	for i, field := range info.Fields {
+		fieldMarkers[i], err = g.Plugin.markerValues(field.Markers)
+		if err != nil {
+			return gentypes.TypeDecl{}, false, err
+		}
+
+		hasFieldMarkers = hasFieldMarkers || len(fieldMarkers[i]) > 0
+	}
+
+	if len(typeMarkers) == 0 && !hasFieldMarkers {
+		return gentypes.TypeDecl{}, false, nil
+	}
+
+	obj, ok := root.Types.Scope().Lookup(info.Name).(*types.TypeName)
+	if !ok {
+		return gentypes.TypeDecl{}, false, fmt.Errorf("unknown type %s", info.Name)
+	}
+
+	typeDecl := gentypes.DescribeType(obj, info.RawDecl, info.RawSpec)
+	typeDecl.Markers = typeMarkers
+
+	// fields are listed in the same order (one entry per name, eg. for A, B int)
+	// by the marker collector and the type checker
+	if len(typeDecl.Fields) != len(info.Fields) {
+		return gentypes.TypeDecl{}, false, fmt.Errorf("cannot match the fields of type %s with their markers", info.Name)
+	}
+
+	for i, field := range info.Fields {
+		// embedded fields have no name in the marker collector
+		if field.Name != "" && field.Name != typeDecl.Fields[i].Name {
+			return gentypes.TypeDecl{}, false, fmt.Errorf("cannot match field %s of type %s with its markers", field.Name, info.Name)
+		}
+
+		typeDecl.Fields[i].Markers = fieldMarkers[i]
+	}
+
+	return typeDecl, true, nil
+}
@@ -0,0 +1,117 @@
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"sigs.k8s.io/controller-tools/pkg/markers"
+
+	"sagikazarmark.dev/mga/pkg/genplugin"
+)
+
+// nolint: gochecknoglobals
+var (
+	targets = map[string]markers.TargetType{
+		genplugin.PackageTarget: markers.DescribesPackage,
+		genplugin.TypeTarget:    markers.DescribesType,
+		genplugin.FieldTarget:   markers.DescribesField,
+	}
+
+	argumentTypes = map[string]reflect.Type{
+		genplugin.StringArgument:     reflect.TypeOf(""),
+		genplugin.IntArgument:        reflect.TypeOf(0),
+		genplugin.BoolArgument:       reflect.TypeOf(false),
+		genplugin.StringListArgument: reflect.TypeOf([]string{}),
+		genplugin.IntListArgument:    reflect.TypeOf([]int{}),
+	}
+)
+
+// newDefinition creates a marker definition from a marker description.
+//
+// Marker values are parsed into a struct type built from the arguments,
+// so markers of plugins are validated the same way as markers of builtin generators.
+func newDefinition(m genplugin.Marker) (*markers.Definition, *markers.DefinitionHelp, error) {
+	target, ok := targets[m.Target]
+	if !ok {
+		return nil, nil, fmt.Errorf("marker %q: unknown target %q (expected package, type or field)", m.Name, m.Target)
+	}
+
+	if m.Kind != "" && (target != markers.DescribesType || (m.Kind != genplugin.InterfaceKind && m.Kind != genplugin.StructKind)) {
+		return nil, nil, fmt.Errorf("marker %q: unknown kind %q (expected interface or struct for type markers)", m.Name, m.Kind)
+	}
+
+	help := &markers.DefinitionHelp{
+		Category:     "Plugins",
+		DetailedHelp: markers.DetailedHelp{Summary: m.Help},
+		FieldHelp:    make(map[string]markers.DetailedHelp, len(m.Arguments)),
+	}
+
+	fields := make([]reflect.StructField, 0, len(m.Arguments))
+
+	for i, arg := range m.Arguments {
+		if arg.Name == "" {
+			return nil, nil, fmt.Errorf("marker %q: argument %d has no name", m.Name, i)
+		}
+
+		typ, ok := argumentTypes[arg.Type]
+		if !ok {
+			return nil, nil, fmt.Errorf(
+				"marker %q: unknown type %q of argument %q (expected string, int, bool, []string or []int)",
+				m.Name,
+				arg.Type,
+				arg.Name,
+			)
+		}
+
+		markerTag := arg.Name
+		if arg.Optional {
+			markerTag += ",optional"
+		}
+
+		fieldName := fmt.Sprintf("Arg%d", i)
+
+		fields = append(fields, reflect.StructField{
+			Name: fieldName,
+			Type: typ,
+			Tag:  reflect.StructTag(fmt.Sprintf("marker:%q json:%q", markerTag, arg.Name)),
+		})
+
+		help.FieldHelp[fieldName] = markers.DetailedHelp{Summary: arg.Help}
+	}
+
+	def, err := markers.MakeDefinition(m.Name, target, reflect.New(reflect.StructOf(fields)).Elem().Interface())
+	if err != nil {
+		return nil, nil, fmt.Errorf("marker %q: %w", m.Name, err)
+	}
+
+	return def, help, nil
+}
+
+// markerValues returns the values of markers of a plugin (converted to JSON compatible values).
+func (p Plugin) markerValues(values markers.MarkerValues) (map[string][]interface{}, error) {
+	var result map[string][]interface{}
+
+	for _, m := range p.markers {
+		for _, value := range values[m.def.Name] {
+			raw, err := json.Marshal(value)
+			if err != nil {
+				return nil, err
+			}
+
+			var converted interface{}
+
+			if err := json.Unmarshal(raw, &converted); err != nil {
+				return nil, err
+			}
+
+			if result == nil {
+				result = make(map[string][]interface{})
+			}
+
+			result[m.def.Name] = append(result[m.def.Name], converted)
+		}
+	}
+
+	return result, nil
+}
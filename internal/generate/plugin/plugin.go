@@ -0,0 +1,192 @@
+// Package plugin runs external generator plugins (mga-gen-<name> executables) as generators.
+//
+// See the genplugin package for the protocol between mga and plugins.
+package plugin
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/controller-tools/pkg/markers"
+
+	"sagikazarmark.dev/mga/pkg/genplugin"
+)
+
+// Prefix is the prefix of plugin executable names.
+const Prefix = "mga-gen-"
+
+// Plugin is an external generator.
+type Plugin struct {
+	// Name of the plugin (the executable name without the mga-gen- prefix).
+	Name string
+
+	// Path to the plugin executable.
+	Path string
+
+	// Description returned by the plugin.
+	Description genplugin.Description
+
+	markers []marker
+}
+
+type marker struct {
+	def  *markers.Definition
+	help *markers.DefinitionHelp
+
+	// kind restricts a type marker to interfaces or structs (if any)
+	kind string
+}
+
+// GeneratorName returns the name of the generator running the plugin (eg. plugin:authz).
+func (p Plugin) GeneratorName() string {
+	return "plugin:" + p.Name
+}
+
+// Discover finds plugins in the directories listed in PATH.
+//
+// When more than one directory contains the same plugin, the first one wins.
+// Plugins failing to describe themselves are skipped: the returned errors describe why.
+func Discover() ([]Plugin, []error) {
+	return discover(filepath.SplitList(os.Getenv("PATH")))
+}
+
+func discover(dirs []string) ([]Plugin, []error) {
+	seen := make(map[string]bool)
+
+	var (
+		plugins []Plugin
+		errs    []error
+	)
+
+	for _, dir := range dirs {
+		if dir == "" {
+			continue
+		}
+
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+
+		for _, entry := range entries {
+			name, ok := strings.CutPrefix(entry.Name(), Prefix)
+			if runtime.GOOS == "windows" {
+				name = strings.TrimSuffix(name, ".exe")
+			}
+
+			if !ok || name == "" || seen[name] {
+				continue
+			}
+
+			path := filepath.Join(dir, entry.Name())
+
+			if !isExecutable(path) {
+				continue
+			}
+
+			seen[name] = true
+
+			plugin, err := Load(name, path)
+			if err != nil {
+				errs = append(errs, err)
+
+				continue
+			}
+
+			plugins = append(plugins, plugin)
+		}
+	}
+
+	sort.Slice(plugins, func(i, j int) bool {
+		return plugins[i].Name < plugins[j].Name
+	})
+
+	return plugins, errs
+}
+
+func isExecutable(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return false
+	}
+
+	return runtime.GOOS == "windows" || info.Mode().Perm()&0o111 != 0
+}
+
+// Load asks a plugin executable to describe itself.
+func Load(name string, path string) (Plugin, error) {
+	plugin := Plugin{
+		Name: name,
+		Path: path,
+	}
+
+	if err := plugin.run("describe", nil, &plugin.Description); err != nil {
+		return plugin, err
+	}
+
+	if v := plugin.Description.ProtocolVersion; v != genplugin.ProtocolVersion {
+		return plugin, fmt.Errorf(
+			"plugin %s: unsupported protocol version %d (supported version: %d)",
+			name,
+			v,
+			genplugin.ProtocolVersion,
+		)
+	}
+
+	for _, m := range plugin.Description.Markers {
+		def, help, err := newDefinition(m)
+		if err != nil {
+			return plugin, fmt.Errorf("plugin %s: %w", name, err)
+		}
+
+		plugin.markers = append(plugin.markers, marker{def: def, help: help, kind: m.Kind})
+	}
+
+	return plugin, nil
+}
+
+// Generate asks the plugin to generate code.
+func (p Plugin) Generate(req genplugin.Request) (genplugin.Response, error) {
+	var resp genplugin.Response
+
+	req.ProtocolVersion = genplugin.ProtocolVersion
+
+	input, err := json.Marshal(req)
+	if err != nil {
+		return resp, err
+	}
+
+	err = p.run("generate", input, &resp)
+
+	return resp, err
+}
+
+func (p Plugin) run(command string, input []byte, output interface{}) error {
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.Command(p.Path, command) // nolint: gosec
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("plugin %s: %s: %w: %s", p.Name, command, err, msg)
+		}
+
+		return fmt.Errorf("plugin %s: %s: %w", p.Name, command, err)
+	}
+
+	if err := json.Unmarshal(stdout.Bytes(), output); err != nil {
+		return fmt.Errorf("plugin %s: %s: invalid output: %w", p.Name, command, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,250 @@
+package plugin
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/controller-tools/pkg/loader"
+
+	"sagikazarmark.dev/mga/internal/generate/runner"
+	"sagikazarmark.dev/mga/pkg/genplugin"
+	"sagikazarmark.dev/mga/pkg/gentypes"
+)
+
+// buildPlugin builds the test plugin into a directory and returns the directory.
+func buildPlugin(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	cmd := exec.Command("go", "build", "-o", filepath.Join(dir, Prefix+"test"), "./testdata/plugin")
+	cmd.Stderr = os.Stderr
+
+	require.NoError(t, cmd.Run())
+
+	// not executable files are ignored
+	require.NoError(t, os.WriteFile(filepath.Join(dir, Prefix+"readme"), []byte("not a plugin"), 0o644))
+
+	return dir
+}
+
+type bufferOutput struct {
+	mu    sync.Mutex
+	files map[string]*bytes.Buffer
+}
+
+func (o *bufferOutput) Open(_ *loader.Package, itemPath string) (io.WriteCloser, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	buf := &bytes.Buffer{}
+	o.files[itemPath] = buf
+
+	return nopCloser{buf}, nil
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
+
+func TestDiscover(t *testing.T) {
+	dir := buildPlugin(t)
+
+	plugins, errs := discover([]string{"", filepath.Join(dir, "missing"), dir})
+	require.Empty(t, errs)
+	require.Len(t, plugins, 1)
+
+	plugin := plugins[0]
+
+	assert.Equal(t, "test", plugin.Name)
+	assert.Equal(t, "plugin:test", plugin.GeneratorName())
+	assert.Equal(t, filepath.Join(dir, Prefix+"test"), plugin.Path)
+	assert.Equal(t, genplugin.ProtocolVersion, plugin.Description.ProtocolVersion)
+	assert.Equal(t, []string{"request.json"}, Generator{Plugin: plugin}.OutputFiles())
+
+	require.Len(t, plugin.markers, 3)
+
+	table := plugin.markers[1]
+
+	assert.Equal(t, "test:table", table.def.Name)
+	assert.Equal(t, "maps a type to a table.", table.help.Summary)
+	assert.Equal(t, "name of the table.", table.help.FieldsHelp(table.def)["name"].Summary)
+
+	value, err := table.def.Parse("+test:table:name=todos")
+	require.NoError(t, err)
+
+	values, err := plugin.markerValues(map[string][]interface{}{"test:table": {value}})
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string][]interface{}{"test:table": {map[string]interface{}{"name": "todos", "fail": false}}}, values)
+
+	_, err = table.def.Parse("+test:table:fail=true")
+	require.Error(t, err, "name is required")
+}
+
+func TestDiscover_Broken(t *testing.T) {
+	dir := buildPlugin(t)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, Prefix+"broken"), []byte("#!/bin/sh\nexit 1\n"), 0o755)) // nolint: gosec
+
+	plugins, errs := discover([]string{dir})
+
+	require.Len(t, plugins, 1, "broken plugins should be skipped")
+	assert.Equal(t, "test", plugins[0].Name)
+
+	require.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), "broken")
+}
+
+func TestNewDefinition_Invalid(t *testing.T) {
+	_, _, err := newDefinition(genplugin.Marker{Name: "test:table", Target: "struct"})
+	require.EqualError(t, err, `marker "test:table": unknown target "struct" (expected package, type or field)`)
+
+	_, _, err = newDefinition(genplugin.Marker{
+		Name:      "test:table",
+		Target:    genplugin.TypeTarget,
+		Arguments: []genplugin.Argument{{Name: "name", Type: "float"}},
+	})
+	require.EqualError(t, err, `marker "test:table": unknown type "float" of argument "name" (expected string, int, bool, []string or []int)`)
+
+	_, _, err = newDefinition(genplugin.Marker{Name: "test:table", Target: genplugin.FieldTarget, Kind: genplugin.StructKind})
+	require.EqualError(t, err, `marker "test:table": unknown kind "struct" (expected interface or struct for type markers)`)
+}
+
+func TestGenerator_MarkerKinds(t *testing.T) {
+	def, help, err := newDefinition(genplugin.Marker{Name: "test:table", Target: genplugin.TypeTarget, Kind: genplugin.StructKind})
+	require.NoError(t, err)
+
+	plugin := Plugin{markers: []marker{{def: def, help: help, kind: genplugin.StructKind}}}
+
+	assert.Equal(t, map[string]string{"test:table": "struct"}, Generator{Plugin: plugin}.MarkerKinds())
+}
+
+func TestGenerator(t *testing.T) {
+	plugins, discoverErrs := discover([]string{buildPlugin(t)})
+	require.Empty(t, discoverErrs)
+	require.Len(t, plugins, 1)
+
+	output := &bufferOutput{files: make(map[string]*bytes.Buffer)}
+
+	runtime, err := runner.ForRoots(
+		[]runner.Generator{
+			{Name: "plugin:test", Generator: Generator{Plugin: plugins[0]}, OutputRule: output},
+		},
+		"./testdata/source",
+	)
+	require.NoError(t, err)
+
+	var errs bytes.Buffer
+
+	runtime.ErrorWriter = &errs
+
+	hadErrs := runtime.Run()
+	require.True(t, hadErrs)
+
+	assert.Regexp(t, `testdata/source/source\.go:30:6: cannot map type\n$`, errs.String())
+
+	require.Contains(t, output.files, "request.json")
+
+	var req genplugin.Request
+
+	err = json.Unmarshal(output.files["request.json"].Bytes(), &req)
+	require.NoError(t, err)
+
+	pkg := gentypes.PackageRef{Name: "source", Path: "sagikazarmark.dev/mga/internal/generate/plugin/testdata/source"}
+
+	assert.Equal(t, genplugin.ProtocolVersion, req.ProtocolVersion)
+	assert.Equal(t, pkg, req.Package)
+	assert.Equal(t, pkg, req.Output)
+	assert.Equal(t, map[string][]interface{}{"test:module": {map[string]interface{}{"name": "todo"}}}, req.Markers)
+
+	stringType := gentypes.Type{Kind: gentypes.BasicKind, Name: "string", Expr: "string"}
+	int64Type := gentypes.Type{Kind: gentypes.BasicKind, Name: "int64", Expr: "int64"}
+	todoType := gentypes.Type{Kind: gentypes.NamedKind, Name: "Todo", Package: &pkg, Expr: "source.Todo"}
+
+	expected := []gentypes.TypeDecl{
+		{
+			TypeRef: gentypes.TypeRef{Name: "Todo", Package: pkg},
+			Kind:    gentypes.StructKind,
+			Doc:     "Todo is a todo item.",
+			Fields: []gentypes.Field{
+				{
+					Name:    "ID",
+					Type:    stringType,
+					Tag:     `json:"id"`,
+					Doc:     "ID identifies the todo.",
+					Markers: map[string][]interface{}{"test:column": {map[string]interface{}{}}},
+				},
+				{
+					Name: "Title",
+					Type: stringType,
+				},
+				{
+					Name:    "CreatedAt",
+					Type:    int64Type,
+					Markers: map[string][]interface{}{"test:column": {map[string]interface{}{}}},
+				},
+				{
+					Name:    "UpdatedAt",
+					Type:    int64Type,
+					Markers: map[string][]interface{}{"test:column": {map[string]interface{}{}}},
+				},
+			},
+			Markers: map[string][]interface{}{"test:table": {map[string]interface{}{"name": "todos", "fail": false}}},
+		},
+		{
+			TypeRef: gentypes.TypeRef{Name: "Service", Package: pkg},
+			Kind:    gentypes.InterfaceKind,
+			Doc:     "Service manages todos.",
+			Methods: []gentypes.Method{
+				{
+					Name: "Create",
+					Doc:  "Create creates a todo.",
+					Params: []gentypes.Param{
+						{
+							Name: "ctx",
+							Type: gentypes.Type{
+								Kind:    gentypes.NamedKind,
+								Name:    "Context",
+								Package: &gentypes.PackageRef{Name: "context", Path: "context"},
+								Expr:    "context.Context",
+							},
+						},
+						{Name: "title", Type: stringType},
+						{Name: "tags", Type: gentypes.Type{Kind: gentypes.SliceKind, Elem: &stringType, Expr: "[]string"}},
+					},
+					Results: []gentypes.Param{
+						{Type: todoType},
+						{Type: gentypes.Type{Kind: gentypes.NamedKind, Name: "error", Expr: "error"}},
+					},
+					Variadic: true,
+				},
+			},
+			Markers: map[string][]interface{}{"test:table": {map[string]interface{}{"name": "services", "fail": false}}},
+		},
+		{
+			TypeRef: gentypes.TypeRef{Name: "Broken", Package: pkg},
+			Kind:    gentypes.SliceKind,
+			Underlying: &gentypes.Type{
+				Kind: gentypes.SliceKind,
+				Elem: &gentypes.Type{Kind: gentypes.PointerKind, Elem: &todoType, Expr: "*source.Todo"},
+				Expr: "[]*source.Todo",
+			},
+			Markers: map[string][]interface{}{"test:table": {map[string]interface{}{"name": "broken", "fail": true}}},
+		},
+	}
+
+	assert.Equal(t, expected, req.Types)
+}
@@ -0,0 +1,66 @@
+// Command plugin is a generator plugin used by plugin tests.
+//
+// It returns the request it receives as a file.
+package main
+
+import (
+	"encoding/json"
+
+	"sagikazarmark.dev/mga/pkg/genplugin"
+)
+
+type plugin struct{}
+
+func (plugin) Describe() genplugin.Description {
+	return genplugin.Description{
+		Markers: []genplugin.Marker{
+			{
+				Name:   "test:module",
+				Target: genplugin.PackageTarget,
+				Help:   "names the module of a package.",
+				Arguments: []genplugin.Argument{
+					{Name: "name", Type: genplugin.StringArgument},
+				},
+			},
+			{
+				Name:   "test:table",
+				Target: genplugin.TypeTarget,
+				Help:   "maps a type to a table.",
+				Arguments: []genplugin.Argument{
+					{Name: "name", Type: genplugin.StringArgument, Help: "name of the table."},
+					{Name: "fail", Type: genplugin.BoolArgument, Optional: true},
+				},
+			},
+			{
+				Name:   "test:column",
+				Target: genplugin.FieldTarget,
+			},
+		},
+		OutputFiles: []string{"request.json"},
+	}
+}
+
+func (plugin) Generate(req genplugin.Request) (genplugin.Response, error) {
+	var resp genplugin.Response
+
+	for _, typ := range req.Types {
+		for _, table := range typ.Markers["test:table"] {
+			if fail, _ := table.(map[string]interface{})["fail"].(bool); fail {
+				resp.Errors = append(resp.Errors, genplugin.Error{Message: "cannot map type", Type: typ.Name})
+			}
+		}
+	}
+
+	content, err := json.MarshalIndent(req, "", "  ")
+	if err != nil {
+		return resp, err
+	}
+
+	resp.Files = append(resp.Files, genplugin.File{Name: "request.json", Content: string(content)})
+
+	return resp, nil
+}
+
+func main() {
+	genplugin.Serve(plugin{})
+}
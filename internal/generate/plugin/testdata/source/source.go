@@ -0,0 +1,33 @@
+// +test:module:name=todo
+
+package source
+
+import (
+	"context"
+)
+
+// Todo is a todo item.
+// +test:table:name=todos
+type Todo struct {
+	// ID identifies the todo.
+	// +test:column
+	ID string `json:"id"`
+
+	Title string
+
+	// +test:column
+	CreatedAt, UpdatedAt int64
+}
+
+// Service manages todos.
+// +test:table:name=services
+type Service interface {
+	// Create creates a todo.
+	Create(ctx context.Context, title string, tags ...string) (Todo, error)
+}
+
+// +test:table:name=broken,fail=true
+type Broken []*Todo
+
+// Unmarked is not described.
+type Unmarked struct{}
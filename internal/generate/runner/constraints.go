@@ -0,0 +1,113 @@
+package runner
+
+import (
+	"fmt"
+	"go/ast"
+	"go/build/constraint"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"sigs.k8s.io/controller-tools/pkg/loader"
+	"sigs.k8s.io/controller-tools/pkg/markers"
+
+	"sagikazarmark.dev/mga/internal/generate/diagnostics"
+)
+
+// excludedFiles warns about files excluded from a package by build constraints that contain markers of generators.
+//
+// Code is not generated for these files with the current build configuration,
+// which is easy to miss otherwise (eg. interfaces in files behind an integration build tag).
+func (r *Runtime) excludedFiles(root *loader.Package) []diagnostics.Diagnostic {
+	var diags []diagnostics.Diagnostic
+
+	for _, path := range root.IgnoredFiles {
+		if filepath.Ext(path) != ".go" {
+			continue
+		}
+
+		content, err := os.ReadFile(path) // nolint: gosec
+		if err != nil {
+			continue
+		}
+
+		fset := token.NewFileSet()
+
+		// files might not even compile with the current build configuration, parsing errors are ignored
+		file, _ := parser.ParseFile(fset, path, content, parser.ParseComments)
+		if file == nil {
+			continue
+		}
+
+		pos, ok := r.firstMarker(file)
+		if !ok {
+			continue
+		}
+
+		diags = append(diags, diagnostics.Diagnostic{
+			Severity: diagnostics.SeverityWarning,
+			Package:  root.PkgPath,
+			Position: fset.Position(pos),
+			Message: fmt.Sprintf(
+				"file is excluded by %s: its markers are ignored (set --tags, --goos or --goarch to include it)",
+				describeConstraint(file),
+			),
+		})
+	}
+
+	return diags
+}
+
+// firstMarker returns the position of the first marker of any generator in a file.
+func (r *Runtime) firstMarker(file *ast.File) (token.Pos, bool) {
+	if r.Collector == nil || r.Collector.Registry == nil {
+		return token.NoPos, false
+	}
+
+	targets := []markers.TargetType{markers.DescribesPackage, markers.DescribesType, markers.DescribesField}
+
+	for _, group := range file.Comments {
+		for _, comment := range group.List {
+			text := strings.TrimSpace(strings.TrimPrefix(comment.Text, "//"))
+			if !strings.HasPrefix(comment.Text, "//") || !strings.HasPrefix(text, "+") {
+				continue
+			}
+
+			for _, target := range targets {
+				if r.Collector.Registry.Lookup(text, target) != nil {
+					return comment.Pos(), true
+				}
+			}
+		}
+	}
+
+	return token.NoPos, false
+}
+
+// describeConstraint describes the build constraint of a file.
+//
+// Files without a //go:build line are excluded by their names (eg. _windows.go or _arm64.go suffixes).
+func describeConstraint(file *ast.File) string {
+	for _, group := range file.Comments {
+		if group.Pos() > file.Package {
+			break
+		}
+
+		for _, comment := range group.List {
+			if !constraint.IsGoBuild(comment.Text) {
+				continue
+			}
+
+			expr, err := constraint.Parse(comment.Text)
+			if err != nil {
+				continue
+			}
+
+			return fmt.Sprintf("build constraint %q", expr.String())
+		}
+	}
+
+	return "its name (GOOS or GOARCH suffix)"
+}
@@ -0,0 +1,65 @@
+package runner
+
+import (
+	"fmt"
+	"io"
+	"sync"
+
+	"sigs.k8s.io/controller-tools/pkg/genall"
+	"sigs.k8s.io/controller-tools/pkg/loader"
+
+	"sagikazarmark.dev/mga/pkg/genutils"
+)
+
+// outputClaims keeps track of the generators writing each file during a run.
+type outputClaims struct {
+	mu    sync.Mutex
+	paths map[string]string
+}
+
+// claim records that a generator writes a file and returns the generator that claimed it first (if it is another one).
+func (c *outputClaims) claim(path string, generator string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if other, ok := c.paths[path]; ok && other != generator {
+		return other
+	}
+
+	c.paths[path] = generator
+
+	return ""
+}
+
+// claimingOutput is an output rule rejecting files already written by another generator
+// (otherwise the last generator writing the file would silently win).
+type claimingOutput struct {
+	rule      genall.OutputRule
+	generator string
+	claims    *outputClaims
+}
+
+func (o claimingOutput) Open(pkg *loader.Package, itemPath string) (io.WriteCloser, error) {
+	// output rules that don't write files (eg. to stdout) can't clash
+	if path, err := genutils.OutputPath(o.rule, pkg, itemPath); err == nil {
+		if other := o.claims.claim(path, o.generator); other != "" {
+			return nil, fmt.Errorf("file %s is already written by generator %s", path, other)
+		}
+	}
+
+	return o.rule.Open(pkg, itemPath)
+}
+
+// PackageRef returns package reference (name and path) based on the wrapped output rule.
+func (o claimingOutput) PackageRef(pkg *loader.Package) (string, string) {
+	if pkgrefer, ok := o.rule.(genutils.PackageRefer); ok {
+		return pkgrefer.PackageRef(pkg)
+	}
+
+	return pkg.Name, pkg.PkgPath
+}
+
+// OutputPath returns the path where the wrapped output rule writes an artifact.
+func (o claimingOutput) OutputPath(pkg *loader.Package, itemPath string) (string, error) {
+	return genutils.OutputPath(o.rule, pkg, itemPath)
+}
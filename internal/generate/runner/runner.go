@@ -0,0 +1,353 @@
+// Package runner runs several generators over a set of packages loaded only once.
+package runner
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+
+	"golang.org/x/tools/go/packages"
+	"sigs.k8s.io/controller-tools/pkg/genall"
+	"sigs.k8s.io/controller-tools/pkg/loader"
+	"sigs.k8s.io/controller-tools/pkg/markers"
+
+	"sagikazarmark.dev/mga/internal/generate/diagnostics"
+	"sagikazarmark.dev/mga/pkg/genutils"
+)
+
+// Generator is a named generator with its own output rule.
+type Generator struct {
+	// Name identifies the generator (eg. in output rule overrides).
+	Name string
+
+	// Generator generates code.
+	Generator genall.Generator
+
+	// OutputRule decides where the generated code is written.
+	OutputRule genall.OutputRule
+}
+
+// LoadRoots loads root packages (and their dependencies) for generation
+// with the default build configuration.
+func LoadRoots(rootPaths ...string) ([]*loader.Package, error) {
+	return BuildOptions{}.Load(rootPaths...)
+}
+
+// BuildOptions select the files of packages loaded for generation (according to build constraints).
+type BuildOptions struct {
+	// Tags are additional build tags (eg. integration).
+	Tags []string
+
+	// GOOS is the target operating system. Defaults to the one of the go command.
+	GOOS string
+
+	// GOARCH is the target architecture. Defaults to the one of the go command.
+	GOARCH string
+}
+
+// Load loads root packages (and their dependencies) for generation.
+//
+// The package loader configuration is required for supporting various types
+// (basic type aliases, imports from other packages).
+func (o BuildOptions) Load(rootPaths ...string) ([]*loader.Package, error) {
+	cfg := &packages.Config{
+		Mode: packages.NeedDeps | packages.NeedTypes | packages.NeedModule,
+	}
+
+	if len(o.Tags) > 0 {
+		cfg.BuildFlags = []string{"-tags=" + strings.Join(o.Tags, ",")}
+	}
+
+	if o.GOOS != "" || o.GOARCH != "" {
+		cfg.Env = os.Environ()
+
+		if o.GOOS != "" {
+			cfg.Env = append(cfg.Env, "GOOS="+o.GOOS)
+		}
+
+		if o.GOARCH != "" {
+			cfg.Env = append(cfg.Env, "GOARCH="+o.GOARCH)
+		}
+	}
+
+	return loader.LoadRootsWithConfig(cfg, rootPaths...)
+}
+
+// Runtime runs generators over root packages.
+type Runtime struct {
+	// Generators are run for every root package (in order).
+	Generators []Generator
+
+	// Roots are the packages to generate code for.
+	Roots []*loader.Package
+
+	// Collector collects markers from packages.
+	Collector *markers.Collector
+
+	// Parallelism is the number of packages processed at the same time.
+	// Defaults to the number of available CPUs.
+	Parallelism int
+
+	// ErrorWriter receives generator errors. Defaults to os.Stderr.
+	ErrorWriter io.Writer
+
+	// DiagnosticsFormat is the format errors are written in. Defaults to text.
+	DiagnosticsFormat diagnostics.Format
+
+	// InputRule reads files (eg. header files) for generators. Defaults to reading from the file system.
+	InputRule genall.InputRule
+}
+
+// ForRoots loads root packages and registers markers of every generator.
+func ForRoots(generators []Generator, rootPaths ...string) (*Runtime, error) {
+	roots, err := LoadRoots(rootPaths...)
+	if err != nil {
+		return nil, err
+	}
+
+	return New(generators, roots)
+}
+
+// New returns a runtime for already loaded root packages and registers markers of every generator.
+func New(generators []Generator, roots []*loader.Package) (*Runtime, error) {
+	rt := &Runtime{
+		Generators: generators,
+		Roots:      roots,
+		Collector: &markers.Collector{
+			Registry: &markers.Registry{},
+		},
+	}
+
+	// generators can share markers (eg. the event registry generator uses event handler markers),
+	// but a different marker with the same name would silently replace the one registered first
+	registeredBy := make(map[string]string)
+	defs := make(map[string]*markers.Definition)
+
+	for _, generator := range generators {
+		registry := &markers.Registry{}
+
+		if err := generator.Generator.RegisterMarkers(registry); err != nil {
+			return nil, err
+		}
+
+		for _, def := range registry.AllDefinitions() {
+			if other, ok := defs[def.Name]; ok && other != def {
+				return nil, fmt.Errorf(
+					"generator %s: marker %q is already registered by generator %s",
+					generator.Name,
+					def.Name,
+					registeredBy[def.Name],
+				)
+			}
+
+			if _, ok := defs[def.Name]; !ok {
+				defs[def.Name] = def
+				registeredBy[def.Name] = generator.Name
+			}
+
+			if err := rt.Collector.Registry.Register(def); err != nil {
+				return nil, err
+			}
+
+			if help := registry.HelpFor(def); help != nil {
+				rt.Collector.Registry.AddHelp(def, help)
+			}
+		}
+	}
+
+	return rt, nil
+}
+
+// Run runs every generator for every root package and reports whether any errors occurred.
+//
+// Packages are processed in parallel, generators run sequentially for each package.
+// Errors are written to ErrorWriter once every package is processed.
+func (r *Runtime) Run() bool {
+	if r.ErrorWriter == nil {
+		r.ErrorWriter = os.Stderr
+	}
+
+	if len(r.Generators) == 0 {
+		fmt.Fprintln(r.ErrorWriter, "no generators to run")
+
+		return true
+	}
+
+	diags := r.run()
+
+	if err := diagnostics.Write(r.ErrorWriter, r.DiagnosticsFormat, diags); err != nil {
+		fmt.Fprintln(r.ErrorWriter, err)
+
+		return true
+	}
+
+	return diagnostics.HasErrors(diags)
+}
+
+// OutputFiles returns the names (or glob patterns) of the files written by generators (see genutils.OutputFiler).
+func (r *Runtime) OutputFiles() []string {
+	var fileNames []string
+
+	for _, generator := range r.Generators {
+		if filer, ok := generator.Generator.(genutils.OutputFiler); ok {
+			fileNames = append(fileNames, filer.OutputFiles()...)
+		}
+	}
+
+	return fileNames
+}
+
+func (r *Runtime) run() []diagnostics.Diagnostic {
+	parallelism := r.Parallelism
+	if parallelism < 1 {
+		parallelism = runtime.GOMAXPROCS(0)
+	}
+
+	// errors added to root packages while generating code are reported by the generators
+	loaded := make(map[*packages.Package]int, len(r.Roots))
+
+	for _, root := range r.Roots {
+		loaded[root.Package] = len(root.Errors)
+	}
+
+	var (
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+		diags []diagnostics.Diagnostic
+	)
+
+	checker := r.typeCheck()
+
+	claims := &outputClaims{paths: make(map[string]string)}
+
+	sem := make(chan struct{}, parallelism)
+
+	for _, root := range r.Roots {
+		wg.Add(1)
+		sem <- struct{}{}
+
+		go func(root *loader.Package) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			pkgDiags := r.runPackage(root, checker, claims)
+
+			mu.Lock()
+			diags = append(diags, pkgDiags...)
+			mu.Unlock()
+		}(root)
+	}
+
+	wg.Wait()
+
+	pkgs := make([]*packages.Package, 0, len(r.Roots))
+	for _, root := range r.Roots {
+		pkgs = append(pkgs, root.Package)
+	}
+
+	packages.Visit(pkgs, nil, func(pkg *packages.Package) {
+		for i, err := range pkg.Errors {
+			// skip TypeErrors -- they're probably just from partial typechecking
+			if err.Kind == packages.TypeError {
+				continue
+			}
+
+			if n, ok := loaded[pkg]; ok && i >= n && err.Kind == packages.UnknownError {
+				continue
+			}
+
+			diags = append(diags, diagnostics.FromPackageError("", pkg.PkgPath, err))
+		}
+	})
+
+	diagnostics.Sort(diags)
+
+	return diags
+}
+
+// typeCheck parses and type-checks every root package (and the dependencies referenced by them) sequentially.
+//
+// Type checking lazily populates packages shared by several roots, which is not safe to do concurrently.
+// Once every root is checked, generators only read the packages and checking a root again is a no-op.
+func (r *Runtime) typeCheck() *loader.TypeChecker {
+	var filters []loader.NodeFilter
+
+	for _, generator := range r.Generators {
+		if withFilter, needsChecking := generator.Generator.(genall.NeedsTypeChecking); needsChecking {
+			filters = append(filters, withFilter.CheckFilter())
+		}
+	}
+
+	checker := &loader.TypeChecker{NodeFilters: filters}
+
+	for _, root := range r.Roots {
+		root.NeedSyntax()
+		root.Imports()
+
+		if len(filters) > 0 {
+			checker.Check(root)
+		}
+	}
+
+	return checker
+}
+
+func (r *Runtime) runPackage(root *loader.Package, checker *loader.TypeChecker, claims *outputClaims) []diagnostics.Diagnostic {
+	diags := r.excludedFiles(root)
+
+	// errors reported by more than one generator are not attributed to any of them
+	seen := make(map[packages.Error]int)
+
+	for _, generator := range r.Generators {
+		ctx := &genall.GenerationContext{
+			Collector:  r.Collector,
+			Roots:      []*loader.Package{root},
+			Checker:    checker,
+			OutputRule: generator.OutputRule,
+			InputRule:  r.InputRule,
+		}
+
+		if ctx.InputRule == nil {
+			ctx.InputRule = genall.InputFromFileSystem
+		}
+
+		// don't pass a type checker to generators that don't provide a filter
+		if _, needsChecking := generator.Generator.(genall.NeedsTypeChecking); !needsChecking {
+			ctx.Checker = nil
+		}
+
+		if ctx.OutputRule == nil {
+			ctx.OutputRule = genall.OutputToNothing
+		} else {
+			ctx.OutputRule = claimingOutput{rule: ctx.OutputRule, generator: generator.Name, claims: claims}
+		}
+
+		before := len(root.Errors)
+
+		if err := generator.Generator.Generate(ctx); err != nil {
+			diags = append(diags, diagnostics.FromError(generator.Name, root.PkgPath, err)...)
+		}
+
+		// parse and type errors are reported with other package errors
+		for _, err := range root.Errors[before:] {
+			if err.Kind != packages.UnknownError {
+				continue
+			}
+
+			// every generator collecting markers runs into the same invalid markers
+			if i, ok := seen[err]; ok {
+				diags[i].Source = ""
+
+				continue
+			}
+
+			seen[err] = len(diags)
+			diags = append(diags, diagnostics.FromPackageError(generator.Name, root.PkgPath, err))
+		}
+	}
+
+	return diags
+}
@@ -0,0 +1,388 @@
+package runner
+
+import (
+	"bytes"
+	"errors"
+	"go/ast"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/controller-tools/pkg/genall"
+	"sigs.k8s.io/controller-tools/pkg/loader"
+	"sigs.k8s.io/controller-tools/pkg/markers"
+
+	"sagikazarmark.dev/mga/internal/generate/diagnostics"
+)
+
+type generatorStub struct {
+	mu      sync.Mutex
+	roots   []string
+	outputs []genall.OutputRule
+
+	err error
+}
+
+func (g *generatorStub) RegisterMarkers(_ *markers.Registry) error {
+	return nil
+}
+
+func (g *generatorStub) Generate(ctx *genall.GenerationContext) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for _, root := range ctx.Roots {
+		g.roots = append(g.roots, root.Name)
+	}
+
+	rule := ctx.OutputRule
+	if claiming, ok := rule.(claimingOutput); ok {
+		rule = claiming.rule
+	}
+
+	g.outputs = append(g.outputs, rule)
+
+	return g.err
+}
+
+type definitionGeneratorStub struct {
+	generatorStub
+
+	def *markers.Definition
+}
+
+func (g *definitionGeneratorStub) RegisterMarkers(into *markers.Registry) error {
+	return into.Register(g.def)
+}
+
+func TestNew_MarkerConflict(t *testing.T) {
+	def := markers.Must(markers.MakeDefinition("test:marker", markers.DescribesType, struct{}{}))
+	other := markers.Must(markers.MakeDefinition("test:marker", markers.DescribesType, ""))
+
+	_, err := New(
+		[]Generator{
+			{Name: "first", Generator: &definitionGeneratorStub{def: def}},
+			{Name: "shared", Generator: &definitionGeneratorStub{def: def}},
+		},
+		nil,
+	)
+	require.NoError(t, err, "generators should be able to share markers")
+
+	_, err = New(
+		[]Generator{
+			{Name: "first", Generator: &definitionGeneratorStub{def: def}},
+			{Name: "plugin:second", Generator: &definitionGeneratorStub{def: other}},
+		},
+		nil,
+	)
+	require.EqualError(t, err, `generator plugin:second: marker "test:marker" is already registered by generator first`)
+}
+
+func TestRuntime_Run(t *testing.T) {
+	gen1 := &generatorStub{}
+	gen2 := &generatorStub{}
+
+	runtime, err := ForRoots(
+		[]Generator{
+			{Name: "gen1", Generator: gen1, OutputRule: genall.OutputToStdout},
+			{Name: "gen2", Generator: gen2},
+		},
+		"./testdata/...",
+	)
+	require.NoError(t, err)
+
+	hadErrs := runtime.Run()
+	require.False(t, hadErrs)
+
+	sort.Strings(gen1.roots)
+	sort.Strings(gen2.roots)
+
+	assert.Equal(t, []string{"bar", "foo"}, gen1.roots)
+	assert.Equal(t, []string{"bar", "foo"}, gen2.roots)
+
+	assert.Equal(t, []genall.OutputRule{genall.OutputToStdout, genall.OutputToStdout}, gen1.outputs)
+	assert.Equal(t, []genall.OutputRule{genall.OutputToNothing, genall.OutputToNothing}, gen2.outputs)
+}
+
+type filerGeneratorStub struct {
+	generatorStub
+
+	fileNames []string
+}
+
+func (g *filerGeneratorStub) OutputFiles() []string {
+	return g.fileNames
+}
+
+func TestRuntime_OutputFiles(t *testing.T) {
+	runtime := &Runtime{
+		Generators: []Generator{
+			{Name: "first", Generator: &filerGeneratorStub{fileNames: []string{"zz_generated.first.go"}}},
+			{Name: "unknown", Generator: &generatorStub{}},
+			{Name: "second", Generator: &filerGeneratorStub{fileNames: []string{"*.second.go"}}},
+		},
+	}
+
+	assert.Equal(t, []string{"zz_generated.first.go", "*.second.go"}, runtime.OutputFiles())
+}
+
+func TestRuntime_Run_Error(t *testing.T) {
+	var buf bytes.Buffer
+
+	runtime, err := ForRoots(
+		[]Generator{
+			{Name: "gen", Generator: &generatorStub{err: errors.New("error")}},
+		},
+		"./testdata/foo",
+	)
+	require.NoError(t, err)
+
+	runtime.ErrorWriter = &buf
+
+	hadErrs := runtime.Run()
+	require.True(t, hadErrs)
+
+	assert.Equal(t, "gen: error\n", buf.String())
+}
+
+type writingGeneratorStub struct {
+	fileName string
+}
+
+func (g writingGeneratorStub) RegisterMarkers(_ *markers.Registry) error {
+	return nil
+}
+
+func (g writingGeneratorStub) Generate(ctx *genall.GenerationContext) error {
+	for _, root := range ctx.Roots {
+		w, err := ctx.Open(root, g.fileName)
+		if err != nil {
+			root.AddError(err)
+
+			continue
+		}
+
+		_ = w.Close()
+	}
+
+	return nil
+}
+
+func TestRuntime_Run_OutputClash(t *testing.T) {
+	var buf bytes.Buffer
+
+	dir := t.TempDir()
+
+	runtime, err := ForRoots(
+		[]Generator{
+			{Name: "first", Generator: writingGeneratorStub{fileName: "zz_generated.go"}, OutputRule: genall.OutputToDirectory(dir)},
+			{Name: "second", Generator: writingGeneratorStub{fileName: "zz_generated.go"}, OutputRule: genall.OutputToDirectory(dir)},
+		},
+		"./testdata/foo",
+	)
+	require.NoError(t, err)
+
+	runtime.ErrorWriter = &buf
+
+	hadErrs := runtime.Run()
+	require.True(t, hadErrs)
+
+	path := filepath.Join(dir, "zz_generated.go")
+
+	assert.Equal(t, "second: file "+path+" is already written by generator first\n", buf.String())
+}
+
+type errorAddingGeneratorStub struct{}
+
+func (g errorAddingGeneratorStub) RegisterMarkers(_ *markers.Registry) error {
+	return nil
+}
+
+func (g errorAddingGeneratorStub) Generate(ctx *genall.GenerationContext) error {
+	for _, root := range ctx.Roots {
+		root.AddError(errors.New("invalid marker"))
+	}
+
+	return nil
+}
+
+func TestRuntime_Run_Diagnostics(t *testing.T) {
+	var buf bytes.Buffer
+
+	runtime, err := ForRoots(
+		[]Generator{
+			{Name: "gen", Generator: &generatorStub{err: errors.New("error")}},
+			{Name: "marker", Generator: errorAddingGeneratorStub{}},
+		},
+		"./testdata/foo",
+	)
+	require.NoError(t, err)
+
+	runtime.ErrorWriter = &buf
+	runtime.DiagnosticsFormat = diagnostics.FormatJSON
+
+	hadErrs := runtime.Run()
+	require.True(t, hadErrs)
+
+	expected := `[
+  {
+    "severity": "error",
+    "generator": "gen",
+    "package": "sagikazarmark.dev/mga/internal/generate/runner/testdata/foo",
+    "message": "error"
+  },
+  {
+    "severity": "error",
+    "generator": "marker",
+    "package": "sagikazarmark.dev/mga/internal/generate/runner/testdata/foo",
+    "message": "invalid marker"
+  }
+]
+`
+
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestRuntime_Run_SharedErrors(t *testing.T) {
+	var buf bytes.Buffer
+
+	runtime, err := ForRoots(
+		[]Generator{
+			{Name: "gen1", Generator: errorAddingGeneratorStub{}},
+			{Name: "gen2", Generator: errorAddingGeneratorStub{}},
+		},
+		"./testdata/foo",
+	)
+	require.NoError(t, err)
+
+	runtime.ErrorWriter = &buf
+
+	hadErrs := runtime.Run()
+	require.True(t, hadErrs)
+
+	assert.Equal(t, "sagikazarmark.dev/mga/internal/generate/runner/testdata/foo: invalid marker\n", buf.String())
+}
+
+type checkingGeneratorStub struct {
+	mu    sync.Mutex
+	types map[string][]string
+}
+
+func (g *checkingGeneratorStub) RegisterMarkers(_ *markers.Registry) error {
+	return nil
+}
+
+func (g *checkingGeneratorStub) CheckFilter() loader.NodeFilter {
+	return func(node ast.Node) bool {
+		return true
+	}
+}
+
+func (g *checkingGeneratorStub) Generate(ctx *genall.GenerationContext) error {
+	for _, root := range ctx.Roots {
+		ctx.Checker.Check(root)
+
+		root.NeedTypesInfo()
+
+		var names []string
+
+		for _, pkg := range root.Imports() {
+			if pkg.Types != nil {
+				names = append(names, pkg.Types.Scope().Names()...)
+			}
+		}
+
+		names = append(names, root.Types.Scope().Names()...)
+
+		g.mu.Lock()
+		g.types[root.Name] = names
+		g.mu.Unlock()
+	}
+
+	return nil
+}
+
+func TestRuntime_Run_SharedDependencies(t *testing.T) {
+	gen := &checkingGeneratorStub{types: map[string][]string{}}
+
+	runtime, err := ForRoots(
+		[]Generator{
+			{Name: "gen1", Generator: gen},
+			{Name: "gen2", Generator: &generatorStub{}},
+		},
+		"./testdata/...",
+	)
+	require.NoError(t, err)
+
+	runtime.Parallelism = 2
+
+	hadErrs := runtime.Run()
+	require.False(t, hadErrs)
+
+	assert.Equal(t, map[string][]string{"bar": {"Bar"}, "foo": {"Bar", "Foo"}}, gen.types)
+}
+
+type markerGeneratorStub struct {
+	generatorStub
+}
+
+func (g *markerGeneratorStub) RegisterMarkers(into *markers.Registry) error {
+	return into.Register(markers.Must(markers.MakeDefinition("runner:test", markers.DescribesType, false)))
+}
+
+func TestRuntime_Run_ExcludedFiles(t *testing.T) {
+	tests := []struct {
+		name     string
+		build    BuildOptions
+		files    []string
+		expected string
+	}{
+		{
+			name:     "excluded",
+			files:    []string{"foo.go"},
+			expected: "testdata/foo/foo_integration.go:5:1: warning: file is excluded by build constraint \"integration\": its markers are ignored (set --tags, --goos or --goarch to include it)\n",
+		},
+		{
+			name:  "included",
+			build: BuildOptions{Tags: []string{"integration"}},
+			files: []string{"foo.go", "foo_integration.go"},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			roots, err := test.build.Load("./testdata/foo")
+			require.NoError(t, err)
+			require.Len(t, roots, 1)
+
+			var files []string
+			for _, file := range roots[0].GoFiles {
+				files = append(files, filepath.Base(file))
+			}
+
+			assert.Equal(t, test.files, files)
+
+			runtime, err := New([]Generator{{Name: "gen", Generator: &markerGeneratorStub{}}}, roots)
+			require.NoError(t, err)
+
+			runtime.ErrorWriter = &buf
+
+			hadErrs := runtime.Run()
+			require.False(t, hadErrs, "excluded files should be reported as warnings")
+
+			wd, err := os.Getwd()
+			require.NoError(t, err)
+
+			assert.Equal(t, test.expected, strings.ReplaceAll(buf.String(), wd+string(filepath.Separator), ""))
+		})
+	}
+}
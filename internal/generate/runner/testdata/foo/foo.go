@@ -0,0 +1,9 @@
+package foo
+
+import (
+	"sagikazarmark.dev/mga/internal/generate/runner/testdata/bar"
+)
+
+type Foo struct {
+	Bar bar.Bar
+}
@@ -0,0 +1,6 @@
+//go:build integration
+
+package foo
+
+// +runner:test
+type Integration struct{}
@@ -0,0 +1,117 @@
+package template
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// words splits an identifier into words.
+//
+// Words are separated by non-alphanumeric characters and case changes.
+// Sequences of upper case letters are kept together (eg. HTTPServer becomes HTTP and Server).
+func words(s string) []string {
+	var words []string
+
+	runes := []rune(s)
+	start := -1
+
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			if start >= 0 {
+				words = append(words, string(runes[start:i]))
+				start = -1
+			}
+
+			continue
+		}
+
+		if start < 0 {
+			start = i
+
+			continue
+		}
+
+		prev := runes[i-1]
+
+		// fooBar, foo1Bar
+		boundary := unicode.IsUpper(r) && (unicode.IsLower(prev) || unicode.IsDigit(prev))
+
+		// HTTPServer
+		boundary = boundary || (unicode.IsUpper(r) && unicode.IsUpper(prev) && i+1 < len(runes) && unicode.IsLower(runes[i+1]))
+
+		if boundary {
+			words = append(words, string(runes[start:i]))
+			start = i
+		}
+	}
+
+	if start >= 0 {
+		words = append(words, string(runes[start:]))
+	}
+
+	return words
+}
+
+// camelCase converts an identifier to camelCase.
+func camelCase(s string) string {
+	return lowerFirst(pascalCase(s))
+}
+
+// pascalCase converts an identifier to PascalCase.
+func pascalCase(s string) string {
+	var b strings.Builder
+
+	for _, word := range words(s) {
+		// keep initialisms (eg. ID, HTTP)
+		if strings.ToUpper(word) == word {
+			b.WriteString(word)
+
+			continue
+		}
+
+		b.WriteString(upperFirst(strings.ToLower(word)))
+	}
+
+	return b.String()
+}
+
+// snakeCase converts an identifier to snake_case.
+func snakeCase(s string) string {
+	return strings.ToLower(strings.Join(words(s), "_"))
+}
+
+// kebabCase converts an identifier to kebab-case.
+func kebabCase(s string) string {
+	return strings.ToLower(strings.Join(words(s), "-"))
+}
+
+// lowerFirst converts the first letter of a string to lower case.
+//
+// Leading initialisms are converted as a whole (eg. HTTPServer becomes httpServer).
+func lowerFirst(s string) string {
+	runes := []rune(s)
+
+	i := 0
+	for i < len(runes) && unicode.IsUpper(runes[i]) {
+		// keep the first letter of the next word
+		if i > 0 && i+1 < len(runes) && unicode.IsLower(runes[i+1]) {
+			break
+		}
+
+		runes[i] = unicode.ToLower(runes[i])
+		i++
+	}
+
+	return string(runes)
+}
+
+// upperFirst converts the first letter of a string to upper case.
+func upperFirst(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	if r == utf8.RuneError {
+		return s
+	}
+
+	return string(unicode.ToUpper(r)) + s[size:]
+}
@@ -0,0 +1,38 @@
+package template
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCase(t *testing.T) {
+	tests := []struct {
+		input  string
+		camel  string
+		pascal string
+		snake  string
+		kebab  string
+	}{
+		{"CreateTodo", "createTodo", "CreateTodo", "create_todo", "create-todo"},
+		{"createTodo", "createTodo", "CreateTodo", "create_todo", "create-todo"},
+		{"create_todo", "createTodo", "CreateTodo", "create_todo", "create-todo"},
+		{"create-todo item", "createTodoItem", "CreateTodoItem", "create_todo_item", "create-todo-item"},
+		{"HTTPServer", "httpServer", "HTTPServer", "http_server", "http-server"},
+		{"TodoID", "todoID", "TodoID", "todo_id", "todo-id"},
+		{"ID", "id", "ID", "id", "id"},
+		{"Todo2Item", "todo2Item", "Todo2Item", "todo2_item", "todo2-item"},
+		{"", "", "", "", ""},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.input, func(t *testing.T) {
+			assert.Equal(t, test.camel, camelCase(test.input), "camel")
+			assert.Equal(t, test.pascal, pascalCase(test.input), "pascal")
+			assert.Equal(t, test.snake, snakeCase(test.input), "snake")
+			assert.Equal(t, test.kebab, kebabCase(test.input), "kebab")
+		})
+	}
+}
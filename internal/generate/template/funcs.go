@@ -0,0 +1,209 @@
+package template
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"text/template"
+
+	"sagikazarmark.dev/mga/pkg/gentypes"
+)
+
+// funcMap returns the helper functions available in templates.
+//
+// Functions referring to packages (eg. import, type) add them to the imports of the generated file.
+func funcMap(imports *imports) template.FuncMap {
+	return template.FuncMap{
+		// case conversion
+		"camel":      camelCase,
+		"pascal":     pascalCase,
+		"snake":      snakeCase,
+		"kebab":      kebabCase,
+		"lower":      strings.ToLower,
+		"upper":      strings.ToUpper,
+		"lowerFirst": lowerFirst,
+		"upperFirst": upperFirst,
+
+		// strings
+		"hasPrefix":  func(prefix string, s string) bool { return strings.HasPrefix(s, prefix) },
+		"hasSuffix":  func(suffix string, s string) bool { return strings.HasSuffix(s, suffix) },
+		"trimPrefix": func(prefix string, s string) string { return strings.TrimPrefix(s, prefix) },
+		"trimSuffix": func(suffix string, s string) string { return strings.TrimSuffix(s, suffix) },
+		"comment":    comment,
+		"tag":        func(key string, tag string) string { return reflect.StructTag(tag).Get(key) },
+
+		// imports and types
+		"import": func(pkgPath string) string { return imports.add(pkgPath, "") },
+		"qualify": func(ref gentypes.TypeRef) string {
+			return imports.qualify(ref.Package.Path, ref.Package.Name, ref.Name)
+		},
+		"type":      func(typ gentypes.Type) string { return renderType(imports, typ) },
+		"params":    func(method gentypes.Method) string { return renderParams(imports, method.Params, method.Variadic) },
+		"args":      func(method gentypes.Method) string { return renderArgs(method.Params, method.Variadic) },
+		"results":   func(method gentypes.Method) string { return renderResults(imports, method.Results) },
+		"signature": func(method gentypes.Method) string { return renderSignature(imports, method) },
+	}
+}
+
+// renderType renders a type expression qualified relative to the generated file.
+func renderType(imports *imports, typ gentypes.Type) string {
+	switch typ.Kind {
+	case gentypes.NamedKind:
+		name := typ.Name
+		if typ.Package != nil {
+			name = imports.qualify(typ.Package.Path, typ.Package.Name, typ.Name)
+		}
+
+		if len(typ.TypeArgs) == 0 {
+			return name
+		}
+
+		args := make([]string, 0, len(typ.TypeArgs))
+		for _, arg := range typ.TypeArgs {
+			args = append(args, renderType(imports, arg))
+		}
+
+		return name + "[" + strings.Join(args, ", ") + "]"
+
+	case gentypes.BasicKind, gentypes.TypeParamKind:
+		return typ.Name
+
+	case gentypes.PointerKind:
+		return "*" + renderType(imports, *typ.Elem)
+
+	case gentypes.SliceKind:
+		return "[]" + renderType(imports, *typ.Elem)
+
+	case gentypes.ArrayKind:
+		return fmt.Sprintf("[%d]%s", typ.Len, renderType(imports, *typ.Elem))
+
+	case gentypes.MapKind:
+		return "map[" + renderType(imports, *typ.Key) + "]" + renderType(imports, *typ.Elem)
+
+	case gentypes.ChanKind:
+		switch typ.Dir {
+		case "send":
+			return "chan<- " + renderType(imports, *typ.Elem)
+
+		case "recv":
+			return "<-chan " + renderType(imports, *typ.Elem)
+		}
+
+		return "chan " + renderType(imports, *typ.Elem)
+
+	case gentypes.FuncKind:
+		return "func(" + renderFuncParams(imports, typ.Params, typ.Variadic) + ")" + withSpace(renderResults(imports, typ.Results))
+	}
+
+	// anonymous structs and interfaces are only described by their expression
+	return typ.Expr
+}
+
+// paramName returns the name of a parameter (or a generated one for unnamed parameters).
+func paramName(param gentypes.Param, i int) string {
+	if param.Name == "" || param.Name == "_" {
+		return fmt.Sprintf("arg%d", i)
+	}
+
+	return param.Name
+}
+
+func renderParams(imports *imports, params []gentypes.Param, variadic bool) string {
+	rendered := make([]string, 0, len(params))
+
+	for i, param := range params {
+		typ := renderType(imports, param.Type)
+
+		if variadic && i == len(params)-1 && param.Type.Elem != nil {
+			typ = "..." + renderType(imports, *param.Type.Elem)
+		}
+
+		rendered = append(rendered, paramName(param, i)+" "+typ)
+	}
+
+	return strings.Join(rendered, ", ")
+}
+
+// renderFuncParams renders the parameters of a function type (names are omitted unless every parameter is named).
+func renderFuncParams(imports *imports, params []gentypes.Param, variadic bool) string {
+	for _, param := range params {
+		if param.Name == "" {
+			return renderParamTypes(imports, params, variadic)
+		}
+	}
+
+	return renderParams(imports, params, variadic)
+}
+
+func renderParamTypes(imports *imports, params []gentypes.Param, variadic bool) string {
+	rendered := make([]string, 0, len(params))
+
+	for i, param := range params {
+		if variadic && i == len(params)-1 && param.Type.Elem != nil {
+			rendered = append(rendered, "..."+renderType(imports, *param.Type.Elem))
+
+			continue
+		}
+
+		rendered = append(rendered, renderType(imports, param.Type))
+	}
+
+	return strings.Join(rendered, ", ")
+}
+
+func renderArgs(params []gentypes.Param, variadic bool) string {
+	args := make([]string, 0, len(params))
+
+	for i, param := range params {
+		arg := paramName(param, i)
+
+		if variadic && i == len(params)-1 {
+			arg += "..."
+		}
+
+		args = append(args, arg)
+	}
+
+	return strings.Join(args, ", ")
+}
+
+func renderResults(imports *imports, results []gentypes.Param) string {
+	if len(results) == 0 {
+		return ""
+	}
+
+	named := true
+	for _, result := range results {
+		named = named && result.Name != ""
+	}
+
+	rendered := make([]string, 0, len(results))
+
+	for _, result := range results {
+		if named {
+			rendered = append(rendered, result.Name+" "+renderType(imports, result.Type))
+
+			continue
+		}
+
+		rendered = append(rendered, renderType(imports, result.Type))
+	}
+
+	if len(rendered) == 1 && !named {
+		return rendered[0]
+	}
+
+	return "(" + strings.Join(rendered, ", ") + ")"
+}
+
+func renderSignature(imports *imports, method gentypes.Method) string {
+	return method.Name + "(" + renderParams(imports, method.Params, method.Variadic) + ")" + withSpace(renderResults(imports, method.Results))
+}
+
+func withSpace(s string) string {
+	if s == "" {
+		return ""
+	}
+
+	return " " + s
+}
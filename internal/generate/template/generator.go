@@ -0,0 +1,94 @@
+package template
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"strings"
+	"text/template"
+
+	"sagikazarmark.dev/mga/pkg/gentypes"
+)
+
+// File represents one or more types rendered with a user-supplied template.
+type File struct {
+	gentypes.File
+
+	// Source is the package where the types are declared.
+	Source gentypes.PackageRef
+
+	// Types marked with the template.
+	Types []gentypes.TypeDecl
+}
+
+// Parse parses a template.
+//
+// Helper functions are available during parsing, but they are bound to the generated file by Generate.
+func Parse(name string, text string) (*template.Template, error) {
+	return template.New(name).Funcs(funcMap(newImports(""))).Parse(text)
+}
+
+// Generate renders a template for a file.
+//
+// The template renders declarations: the package clause and imports (collected by the helper functions)
+// are added by Generate.
+func Generate(file File, tmpl *template.Template) ([]byte, error) {
+	imports := newImports(file.Package.Path)
+
+	tmpl, err := tmpl.Clone()
+	if err != nil {
+		return nil, err
+	}
+
+	var body bytes.Buffer
+
+	if err := tmpl.Funcs(funcMap(imports)).Execute(&body, file); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+
+	buf.WriteString("//go:build !ignore_autogenerated\n// +build !ignore_autogenerated\n\n")
+
+	if file.HeaderText != "" {
+		buf.WriteString(comment(file.HeaderText))
+		buf.WriteString("\n\n")
+	}
+
+	buf.WriteString("// Code generated by mga tool. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "package %s\n\n", file.Package.Name)
+
+	imports.writeTo(&buf)
+
+	buf.Write(body.Bytes())
+
+	out, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("template: %s: invalid generated code: %w", tmpl.Name(), err)
+	}
+
+	return out, nil
+}
+
+// comment turns text into a Go comment (unless it is a comment already).
+func comment(text string) string {
+	text = strings.TrimRight(text, "\n")
+
+	if text == "" || strings.HasPrefix(text, "//") || strings.HasPrefix(text, "/*") {
+		return text
+	}
+
+	lines := strings.Split(text, "\n")
+
+	for i, line := range lines {
+		if line == "" {
+			lines[i] = "//"
+
+			continue
+		}
+
+		lines[i] = "// " + line
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,215 @@
+package template
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sagikazarmark.dev/mga/pkg/gentypes"
+)
+
+func TestGenerate(t *testing.T) {
+	contextType := gentypes.Type{
+		Kind:    gentypes.NamedKind,
+		Name:    "Context",
+		Package: &gentypes.PackageRef{Name: "context", Path: "context"},
+	}
+
+	todoType := gentypes.Type{
+		Kind:    gentypes.NamedKind,
+		Name:    "Todo",
+		Package: &gentypes.PackageRef{Name: "todo", Path: "app.dev/todo"},
+	}
+
+	file := File{
+		File: gentypes.File{
+			Package: gentypes.PackageRef{
+				Name: "todogen",
+				Path: "app.dev/todo/todogen",
+			},
+			HeaderText: `// Copyright 2020 Acme Inc.
+// All rights reserved.
+//
+// Licensed under "Only for testing purposes" license.
+`,
+		},
+		Source: gentypes.PackageRef{
+			Name: "todo",
+			Path: "app.dev/todo",
+		},
+		Types: []gentypes.TypeDecl{
+			{
+				TypeRef: gentypes.TypeRef{
+					Name:    "Service",
+					Package: gentypes.PackageRef{Name: "todo", Path: "app.dev/todo"},
+				},
+				Kind: gentypes.InterfaceKind,
+				Methods: []gentypes.Method{
+					{
+						Name: "CreateTodo",
+						Doc:  "CreateTodo creates a new todo.",
+						Params: []gentypes.Param{
+							{Name: "ctx", Type: contextType},
+							{Name: "tags", Type: gentypes.Type{Kind: gentypes.SliceKind, Elem: &gentypes.Type{Kind: gentypes.BasicKind, Name: "string"}}},
+						},
+						Results: []gentypes.Param{
+							{Type: gentypes.Type{Kind: gentypes.PointerKind, Elem: &todoType}},
+							{Type: gentypes.Type{Kind: gentypes.NamedKind, Name: "error"}},
+						},
+						Variadic: true,
+					},
+					{
+						Name: "ListTodos",
+						Params: []gentypes.Param{
+							{Type: contextType},
+							{Type: gentypes.Type{Kind: gentypes.MapKind, Key: &gentypes.Type{Kind: gentypes.BasicKind, Name: "string"}, Elem: &todoType}},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	tmpl, err := Parse("logging", `
+{{- range .Types }}
+// Logging{{ .Name }} logs calls to {{ .Name }}.
+type Logging{{ .Name }} struct {
+	next {{ qualify .TypeRef }}
+	logger {{ import "example.com/log/v2" }}.Logger
+}
+{{ $t := . }}
+{{- range .Methods }}
+{{- with .Doc }}
+{{ comment . }}
+{{- end }}
+func (s Logging{{ $t.Name }}) {{ signature . }} {
+	s.logger.Log("{{ snake .Name }}")
+
+	{{ if .Results }}return {{ end }}s.next.{{ .Name }}({{ args . }})
+}
+{{ end }}
+{{- end }}
+`)
+	require.NoError(t, err)
+
+	expected := `//go:build !ignore_autogenerated
+// +build !ignore_autogenerated
+
+// Copyright 2020 Acme Inc.
+// All rights reserved.
+//
+// Licensed under "Only for testing purposes" license.
+
+// Code generated by mga tool. DO NOT EDIT.
+
+package todogen
+
+import (
+	"app.dev/todo"
+	"context"
+	"example.com/log/v2"
+)
+
+// LoggingService logs calls to Service.
+type LoggingService struct {
+	next   todo.Service
+	logger log.Logger
+}
+
+// CreateTodo creates a new todo.
+func (s LoggingService) CreateTodo(ctx context.Context, tags ...string) (*todo.Todo, error) {
+	s.logger.Log("create_todo")
+
+	return s.next.CreateTodo(ctx, tags...)
+}
+
+func (s LoggingService) ListTodos(arg0 context.Context, arg1 map[string]todo.Todo) {
+	s.logger.Log("list_todos")
+
+	s.next.ListTodos(arg0, arg1)
+}
+`
+
+	actual, err := Generate(file, tmpl)
+	require.NoError(t, err)
+
+	assert.Equal(t, expected, string(actual), "the generated code does not match the expected one")
+}
+
+func TestGenerate_SamePackage(t *testing.T) {
+	file := File{
+		File: gentypes.File{
+			Package: gentypes.PackageRef{Name: "todo", Path: "app.dev/todo"},
+		},
+		Types: []gentypes.TypeDecl{
+			{
+				TypeRef: gentypes.TypeRef{
+					Name:    "Todo",
+					Package: gentypes.PackageRef{Name: "todo", Path: "app.dev/todo"},
+				},
+				Kind: gentypes.StructKind,
+				Fields: []gentypes.Field{
+					{Name: "ID", Type: gentypes.Type{Kind: gentypes.BasicKind, Name: "string"}, Tag: `json:"id"`},
+					{Name: "Done", Type: gentypes.Type{Kind: gentypes.BasicKind, Name: "bool"}, Tag: `json:"done,omitempty"`},
+				},
+			},
+		},
+	}
+
+	tmpl, err := Parse("fields", `
+{{- range .Types }}
+var {{ camel .Name }}Fields = map[string]{{ qualify .TypeRef }}{
+{{- range .Fields }}
+	"{{ .Tag | tag "json" }}": {},
+{{- end }}
+}
+{{- end }}
+`)
+	require.NoError(t, err)
+
+	expected := `//go:build !ignore_autogenerated
+// +build !ignore_autogenerated
+
+// Code generated by mga tool. DO NOT EDIT.
+
+package todo
+
+var todoFields = map[string]Todo{
+	"id":             {},
+	"done,omitempty": {},
+}
+`
+
+	actual, err := Generate(file, tmpl)
+	require.NoError(t, err)
+
+	assert.Equal(t, expected, string(actual), "the generated code does not match the expected one")
+}
+
+func TestGenerate_InvalidCode(t *testing.T) {
+	file := File{
+		File: gentypes.File{
+			Package: gentypes.PackageRef{Name: "todo", Path: "app.dev/todo"},
+		},
+	}
+
+	tmpl, err := Parse("invalid", "func {")
+	require.NoError(t, err)
+
+	_, err = Generate(file, tmpl)
+	require.Error(t, err)
+
+	assert.Contains(t, err.Error(), "template: invalid: invalid generated code")
+}
+
+func TestImports(t *testing.T) {
+	imports := newImports("app.dev/todo")
+
+	assert.Equal(t, "", imports.add("app.dev/todo", "todo"))
+	assert.Equal(t, "log", imports.add("example.com/log/v2", ""))
+	assert.Equal(t, "yaml", imports.add("gopkg.in/yaml.v3", ""))
+	assert.Equal(t, "log2", imports.add("log", "log"))
+	assert.Equal(t, "log", imports.add("example.com/log/v2", ""), "packages should be imported once")
+	assert.Equal(t, "pkg1", guessName("example.com/1"))
+}
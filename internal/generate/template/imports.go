@@ -0,0 +1,121 @@
+package template
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// imports keeps track of packages imported by a generated file.
+type imports struct {
+	// path of the package the file belongs to
+	path string
+
+	names map[string]string // by package path
+	paths map[string]string // by package name
+}
+
+func newImports(pkgPath string) *imports {
+	return &imports{
+		path:  pkgPath,
+		names: make(map[string]string),
+		paths: make(map[string]string),
+	}
+}
+
+// add imports a package and returns the name it can be referred to by.
+//
+// An empty name is returned for the package the file belongs to.
+// When name is empty, it is guessed from the import path.
+func (i *imports) add(pkgPath string, name string) string {
+	if pkgPath == i.path {
+		return ""
+	}
+
+	if name, ok := i.names[pkgPath]; ok {
+		return name
+	}
+
+	if name == "" {
+		name = guessName(pkgPath)
+	}
+
+	unique := name
+	for n := 2; i.paths[unique] != ""; n++ {
+		unique = name + strconv.Itoa(n)
+	}
+
+	i.names[pkgPath] = unique
+	i.paths[unique] = pkgPath
+
+	return unique
+}
+
+// qualify returns a qualified identifier from a package.
+func (i *imports) qualify(pkgPath string, name string, ident string) string {
+	if qualifier := i.add(pkgPath, name); qualifier != "" {
+		return qualifier + "." + ident
+	}
+
+	return ident
+}
+
+func (i *imports) writeTo(w io.Writer) {
+	if len(i.names) == 0 {
+		return
+	}
+
+	paths := make([]string, 0, len(i.names))
+	for pkgPath := range i.names {
+		paths = append(paths, pkgPath)
+	}
+
+	sort.Strings(paths)
+
+	fmt.Fprintln(w, "import (")
+
+	for _, pkgPath := range paths {
+		if name := i.names[pkgPath]; name != guessName(pkgPath) {
+			fmt.Fprintf(w, "\t%s %q\n", name, pkgPath)
+
+			continue
+		}
+
+		fmt.Fprintf(w, "\t%q\n", pkgPath)
+	}
+
+	fmt.Fprint(w, ")\n\n")
+}
+
+// guessName guesses the name of a package from its import path.
+func guessName(pkgPath string) string {
+	name := path.Base(pkgPath)
+
+	// major version suffix (eg. example.com/pkg/v2)
+	if len(name) > 1 && name[0] == 'v' && strings.Trim(name[1:], "0123456789") == "" && path.Dir(pkgPath) != "." {
+		name = path.Base(path.Dir(pkgPath))
+	}
+
+	// gopkg.in style version suffix (eg. gopkg.in/yaml.v3)
+	if i := strings.Index(name, ".v"); i > 0 {
+		name = name[:i]
+	}
+
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' {
+			return r
+		}
+
+		return -1
+	}, name)
+
+	if name == "" || unicode.IsDigit(rune(name[0])) {
+		name = "pkg" + name
+	}
+
+	return name
+}
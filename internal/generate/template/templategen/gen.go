@@ -0,0 +1,244 @@
+package templategen
+
+import (
+	"fmt"
+	"go/ast"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+
+	"sigs.k8s.io/controller-tools/pkg/genall"
+	"sigs.k8s.io/controller-tools/pkg/loader"
+	"sigs.k8s.io/controller-tools/pkg/markers"
+
+	"sagikazarmark.dev/mga/internal/generate/template"
+	"sagikazarmark.dev/mga/pkg/generator"
+	"sagikazarmark.dev/mga/pkg/gentypes"
+)
+
+// nolint: gochecknoglobals
+var (
+	templateMarker = markers.Must(markers.MakeDefinition("mga:template", markers.DescribesType, Marker{}))
+)
+
+// +controllertools:marker:generateHelp:category=Templates
+
+// Marker enables rendering a user-supplied template for a type.
+//
+// The template is read from <name>.tmpl in the template directory
+// and its output is written to zz_generated.<name>.go.
+// Every type marked with the same template in a package is rendered into the same file
+// (unless files are generated per type).
+type Marker struct {
+	// Name is the name of the template (relative to the template directory, without the .tmpl extension).
+	Name string `marker:"name"`
+}
+
+// DefaultTemplateDir is the default directory templates are read from.
+const DefaultTemplateDir = "templates"
+
+// Generator renders user-supplied templates for types.
+type Generator struct {
+	generator.Base
+
+	// TemplateDir specifies the directory templates are read from.
+	TemplateDir string `marker:",optional"`
+}
+
+func (g Generator) RegisterMarkers(into *markers.Registry) error {
+	return generator.RegisterMarker(into, templateMarker, Marker{}.Help())
+}
+
+func (Generator) CheckFilter() loader.NodeFilter {
+	return func(node ast.Node) bool {
+		return true
+	}
+}
+
+// OutputFiles returns the names of the files generated from templates in the template directory.
+func (g Generator) OutputFiles() []string {
+	dir := g.templateDir()
+
+	var names []string
+
+	_ = filepath.WalkDir(dir, func(p string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || filepath.Ext(p) != ".tmpl" {
+			return nil // nolint: nilerr
+		}
+
+		name, err := filepath.Rel(dir, strings.TrimSuffix(p, ".tmpl"))
+		if err != nil {
+			return nil // nolint: nilerr
+		}
+
+		names = append(names, fileKind(filepath.ToSlash(name)))
+
+		return nil
+	})
+
+	return g.OutputFilePatterns(names...)
+}
+
+func (g Generator) Generate(ctx *genall.GenerationContext) error {
+	headerText, err := g.HeaderText(ctx)
+	if err != nil {
+		return err
+	}
+
+	templates := make(map[string]*texttemplate.Template)
+
+	for _, root := range ctx.Roots {
+		g.generatePackage(ctx, headerText, templates, root)
+	}
+
+	return nil
+}
+
+func (g Generator) generatePackage(
+	ctx *genall.GenerationContext,
+	headerText string,
+	templates map[string]*texttemplate.Template,
+	root *loader.Package,
+) {
+	ctx.Checker.Check(root)
+
+	root.NeedTypesInfo()
+
+	typesByTemplate := make(map[string][]gentypes.TypeDecl)
+
+	err := markers.EachType(ctx.Collector, root, func(info *markers.TypeInfo) {
+		for _, value := range info.Markers[templateMarker.Name] {
+			marker, ok := value.(Marker)
+			if !ok {
+				continue
+			}
+
+			if _, ok := templates[marker.Name]; !ok {
+				tmpl, err := g.readTemplate(ctx, marker.Name)
+				if err != nil {
+					root.AddError(loader.ErrFromNode(err, info.RawSpec))
+
+					continue
+				}
+
+				templates[marker.Name] = tmpl
+			}
+
+			obj, err := generator.LookupType(root, info)
+			if err != nil {
+				root.AddError(err)
+
+				return
+			}
+
+			typesByTemplate[marker.Name] = append(typesByTemplate[marker.Name], gentypes.DescribeType(obj, info.RawDecl, info.RawSpec))
+		}
+	})
+	if err != nil {
+		root.AddError(err)
+
+		return
+	}
+
+	outputPackage := generator.OutputPackage(ctx, root)
+
+	names := make([]string, 0, len(typesByTemplate))
+	for name := range typesByTemplate {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	files := generator.FileSet{}
+
+	for _, name := range names {
+		file := template.File{
+			File: gentypes.File{
+				Package:    outputPackage,
+				HeaderText: headerText,
+			},
+			Source: gentypes.PackageRef{
+				Name: root.Name,
+				Path: root.PkgPath,
+			},
+			Types: typesByTemplate[name],
+		}
+
+		if !g.PerType {
+			g.writeFile(ctx, root, files, templates[name], name, "", file)
+
+			continue
+		}
+
+		for _, typeDecl := range typesByTemplate[name] {
+			file.Types = []gentypes.TypeDecl{typeDecl}
+
+			g.writeFile(ctx, root, files, templates[name], name, typeDecl.Name, file)
+		}
+	}
+}
+
+// writeFile renders a template into the file of a type (or the file of the package if the type name is empty).
+func (g Generator) writeFile(
+	ctx *genall.GenerationContext,
+	root *loader.Package,
+	files generator.FileSet,
+	tmpl *texttemplate.Template,
+	name string,
+	typeName string,
+	file template.File,
+) {
+	fileName, err := g.OutputFileName(fileKind(name), typeName)
+	if err != nil {
+		root.AddError(err)
+
+		return
+	}
+
+	// templates in subdirectories may be rendered into the file of another template's type
+	if err := files.Add(fileName, typeName); err != nil {
+		root.AddError(err)
+
+		return
+	}
+
+	// errors returned by Generate refer to the template
+	outContents, err := template.Generate(file, tmpl)
+	if err != nil {
+		root.AddError(err)
+
+		return
+	}
+
+	generator.WriteFile(ctx, root, fileName, outContents)
+}
+
+// readTemplate reads and parses a template from the template directory.
+func (g Generator) readTemplate(ctx *genall.GenerationContext, name string) (*texttemplate.Template, error) {
+	if name == "" || !filepath.IsLocal(name) {
+		return nil, fmt.Errorf("invalid template name %q", name)
+	}
+
+	text, err := ctx.ReadFile(filepath.Join(g.templateDir(), filepath.FromSlash(name)+".tmpl"))
+	if err != nil {
+		return nil, fmt.Errorf("template %s: %w", name, err)
+	}
+
+	return template.Parse(name, string(text))
+}
+
+func (g Generator) templateDir() string {
+	if g.TemplateDir == "" {
+		return DefaultTemplateDir
+	}
+
+	return g.TemplateDir
+}
+
+// fileKind returns the kind of the file generated from a template (see generator.Base.OutputFileName).
+func fileKind(name string) string {
+	return strings.ReplaceAll(path.Clean(name), "/", ".")
+}
@@ -0,0 +1,49 @@
+package templategen
+
+import (
+	"testing"
+
+	"sigs.k8s.io/controller-tools/pkg/genall"
+
+	"sagikazarmark.dev/mga/pkg/generator"
+	"sagikazarmark.dev/mga/pkg/genutils"
+	"sagikazarmark.dev/mga/pkg/genutils/gentest"
+)
+
+func TestGenerator(t *testing.T) {
+	tests := []struct {
+		name       string
+		generator  Generator
+		outputRule genall.OutputRule
+	}{
+		{
+			name: "package",
+		},
+		{
+			name:       "subpackage",
+			outputRule: genutils.OutputSubpackage{Suffix: "logging"},
+		},
+		{
+			name:      "per_type",
+			generator: Generator{Base: generator.Base{PerType: true}},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			gentest.RunFile(t, "testdata/"+test.name+".txtar", gentest.Test{
+				Generator:  test.generator,
+				OutputRule: test.outputRule,
+				Compile:    true,
+			})
+		})
+	}
+}
+
+func TestGenerator_Errors(t *testing.T) {
+	gentest.RunFile(t, "testdata/errors.txtar", gentest.Test{
+		Generator: Generator{},
+	})
+}
@@ -0,0 +1,27 @@
+//go:build !ignore_autogenerated
+
+// Marker documentation shown by the markers command.
+
+// Code generated by helpgen. DO NOT EDIT.
+
+package templategen
+
+import (
+	"sigs.k8s.io/controller-tools/pkg/markers"
+)
+
+func (Marker) Help() *markers.DefinitionHelp {
+	return &markers.DefinitionHelp{
+		Category: "Templates",
+		DetailedHelp: markers.DetailedHelp{
+			Summary: "enables rendering a user-supplied template for a type.",
+			Details: "The template is read from <name>.tmpl in the template directory\nand its output is written to zz_generated.<name>.go.\nEvery type marked with the same template in a package is rendered into the same file\n(unless files are generated per type).",
+		},
+		FieldHelp: map[string]markers.DetailedHelp{
+			"Name": {
+				Summary: "is the name of the template (relative to the template directory, without the .tmpl extension).",
+				Details: "",
+			},
+		},
+	}
+}
@@ -4,14 +4,13 @@ import (
 	"fmt"
 	"go/ast"
 	"go/types"
-	"io"
-	"strings"
 
 	"sigs.k8s.io/controller-tools/pkg/genall"
 	"sigs.k8s.io/controller-tools/pkg/loader"
 	"sigs.k8s.io/controller-tools/pkg/markers"
 
 	"sagikazarmark.dev/mga/internal/generate/testify/mock"
+	"sagikazarmark.dev/mga/pkg/generator"
 	"sagikazarmark.dev/mga/pkg/gentypes"
 	"sagikazarmark.dev/mga/pkg/genutils"
 )
@@ -21,7 +20,9 @@ var (
 	mockMarker = markers.Must(markers.MakeDefinition("testify:mock", markers.DescribesType, Marker{}))
 )
 
-// Marker enables generating a mock for an interface and provides information to the generator.
+// +controllertools:marker:generateHelp:category=Testify
+
+// Marker enables generating a testify mock for an interface.
 type Marker struct {
 	// TestOnly tells the generator to write the generated mock in a test file.
 	TestOnly bool `marker:"testOnly,optional"`
@@ -33,13 +34,9 @@ type Marker struct {
 	External bool `marker:"external,optional"`
 }
 
-// Generator generates a Go kit Endpoint for a service.
+// Generator generates testify mocks for interfaces.
 type Generator struct {
-	// HeaderFile specifies the header text (e.g. license) to prepend to generated files.
-	HeaderFile string `marker:",optional"`
-
-	// Year specifies the year to substitute for " YEAR" in the header file.
-	Year string `marker:",optional"`
+	generator.Base
 }
 
 func (g Generator) RegisterMarkers(into *markers.Registry) error {
@@ -47,14 +44,18 @@ func (g Generator) RegisterMarkers(into *markers.Registry) error {
 		return err
 	}
 
-	into.AddHelp(
-		mockMarker,
-		markers.SimpleHelp("Kit", "enables endpoint generation for a service interface"),
-	)
+	into.AddHelp(mockMarker, Marker{}.Help())
 
 	return nil
 }
 
+// MarkerKinds restricts markers to the kinds of declarations the generator handles.
+func (Generator) MarkerKinds() map[string]string {
+	return map[string]string{
+		mockMarker.Name: genutils.InterfaceKind,
+	}
+}
+
 func (Generator) CheckFilter() loader.NodeFilter {
 	return func(node ast.Node) bool {
 		// ignore non-interfaces
@@ -64,20 +65,17 @@ func (Generator) CheckFilter() loader.NodeFilter {
 	}
 }
 
-func (g Generator) Generate(ctx *genall.GenerationContext) error {
-	var headerText string
-
-	if g.HeaderFile != "" {
-		headerBytes, err := ctx.ReadFile(g.HeaderFile)
-		if err != nil {
-			return err
-		}
+// OutputFiles returns the names of the files written by the generator.
+func (g Generator) OutputFiles() []string {
+	return g.OutputFilePatterns("mock", "mock_test", "mock_external_test")
+}
 
-		headerText = string(headerBytes)
+func (g Generator) Generate(ctx *genall.GenerationContext) error {
+	headerText, err := g.HeaderText(ctx)
+	if err != nil {
+		return err
 	}
 
-	headerText = strings.ReplaceAll(headerText, " YEAR", " "+g.Year)
-
 	for _, root := range ctx.Roots {
 		g.generatePackage(ctx, headerText, root)
 	}
@@ -146,100 +144,88 @@ func (g Generator) generatePackage(ctx *genall.GenerationContext, headerText str
 		return
 	}
 
-	packageName, packagePath := root.Name, root.PkgPath
-	if pkgrefer, ok := ctx.OutputRule.(genutils.PackageRefer); ok {
-		packageName, packagePath = pkgrefer.PackageRef(root)
+	outputPackage := generator.OutputPackage(ctx, root)
+
+	externalPackage := gentypes.PackageRef{
+		Name: outputPackage.Name + "_test",
+		Path: outputPackage.Path + "_test", // See https://github.com/dave/jennifer/issues/73
 	}
 
-	if len(externalInterfaces) > 0 {
-		file := mock.File{
-			File: gentypes.File{
-				Package: gentypes.PackageRef{
-					Name: packageName + "_test",
-					Path: packagePath + "_test", // See https://github.com/dave/jennifer/issues/73
-				},
-				HeaderText: headerText,
-			},
-			Interfaces: externalInterfaces,
-		}
+	files := generator.FileSet{}
 
-		outContents, err := mock.Generate(file)
-		if err != nil {
-			root.AddError(err)
+	if !g.writeMocks(ctx, root, files, headerText, externalPackage, "mock_external_test", externalInterfaces) {
+		return
+	}
 
-			return
-		}
+	if !g.writeMocks(ctx, root, files, headerText, outputPackage, "mock_test", testOnlyInterfaces) {
+		return
+	}
 
-		if outContents != nil {
-			writeOut(ctx, root, outContents, "zz_generated.mock_external_test.go")
-		}
+	g.writeMocks(ctx, root, files, headerText, outputPackage, "mock", interfaces)
+}
+
+// writeMocks writes mocks for interfaces into a file of the given kind (or a file per interface in per-type mode).
+//
+// It reports whether writing mocks succeeded.
+func (g Generator) writeMocks(
+	ctx *genall.GenerationContext,
+	root *loader.Package,
+	files generator.FileSet,
+	headerText string,
+	pkg gentypes.PackageRef,
+	name string,
+	interfaces []mock.Interface,
+) bool {
+	if len(interfaces) == 0 {
+		return true
 	}
 
-	if len(testOnlyInterfaces) > 0 {
-		file := mock.File{
-			File: gentypes.File{
-				Package: gentypes.PackageRef{
-					Name: packageName,
-					Path: packagePath,
-				},
-				HeaderText: headerText,
-			},
-			Interfaces: testOnlyInterfaces,
+	groups := map[string][]mock.Interface{"": interfaces}
+	typeNames := []string{""}
+
+	if g.PerType {
+		groups = make(map[string][]mock.Interface, len(interfaces))
+		typeNames = typeNames[:0]
+
+		for _, iface := range interfaces {
+			groups[iface.Object.Name()] = []mock.Interface{iface}
+			typeNames = append(typeNames, iface.Object.Name())
 		}
+	}
 
-		outContents, err := mock.Generate(file)
+	for _, typeName := range typeNames {
+		fileName, err := g.OutputFileName(name, typeName)
 		if err != nil {
 			root.AddError(err)
 
-			return
+			return false
 		}
 
-		if outContents != nil {
-			writeOut(ctx, root, outContents, "zz_generated.mock_test.go")
+		if err := files.Add(fileName, typeName); err != nil {
+			root.AddError(err)
+
+			return false
 		}
-	}
 
-	if len(interfaces) > 0 {
 		file := mock.File{
 			File: gentypes.File{
-				Package: gentypes.PackageRef{
-					Name: packageName,
-					Path: packagePath,
-				},
+				Package:    pkg,
 				HeaderText: headerText,
 			},
-			Interfaces: interfaces,
+			Interfaces: groups[typeName],
 		}
 
 		outContents, err := mock.Generate(file)
 		if err != nil {
 			root.AddError(err)
 
-			return
+			return false
 		}
 
 		if outContents != nil {
-			writeOut(ctx, root, outContents, "zz_generated.mock.go")
+			generator.WriteFile(ctx, root, fileName, outContents)
 		}
 	}
-}
 
-// writeOut outputs the given code.
-func writeOut(ctx *genall.GenerationContext, root *loader.Package, outBytes []byte, fileName string) {
-	outputFile, err := ctx.Open(root, fileName)
-	if err != nil {
-		root.AddError(err)
-
-		return
-	}
-	defer outputFile.Close()
-	n, err := outputFile.Write(outBytes)
-	if err != nil {
-		root.AddError(err)
-
-		return
-	}
-	if n < len(outBytes) {
-		root.AddError(io.ErrShortWrite)
-	}
+	return true
 }
@@ -0,0 +1,31 @@
+//go:build !ignore_autogenerated
+
+// Marker documentation shown by the markers command.
+
+// Code generated by helpgen. DO NOT EDIT.
+
+package mockgen
+
+import (
+	"sigs.k8s.io/controller-tools/pkg/markers"
+)
+
+func (Marker) Help() *markers.DefinitionHelp {
+	return &markers.DefinitionHelp{
+		Category: "Testify",
+		DetailedHelp: markers.DetailedHelp{
+			Summary: "enables generating a testify mock for an interface.",
+			Details: "",
+		},
+		FieldHelp: map[string]markers.DetailedHelp{
+			"TestOnly": {
+				Summary: "tells the generator to write the generated mock in a test file.",
+				Details: "",
+			},
+			"External": {
+				Summary: "tells the generator to write the generated mock in an \"external\" test package",
+				Details: "(package name suffixed with _test).\nExternal also implies TestOnly, but setting both values will generate the mock twice:\nonce in the same package in a test file, once in an external package.",
+			},
+		},
+	}
+}
@@ -0,0 +1,341 @@
+// Package watch regenerates code when source files change.
+package watch
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"sigs.k8s.io/controller-tools/pkg/loader"
+
+	"sagikazarmark.dev/mga/internal/generate/check"
+)
+
+// DefaultDebounce is the time to wait for further changes before regenerating code.
+const DefaultDebounce = 200 * time.Millisecond
+
+// LoadFunc loads root packages by their import paths.
+type LoadFunc func(paths ...string) ([]*loader.Package, error)
+
+// GenerateFunc generates code for root packages and reports whether any errors occurred.
+//
+// Errors are expected to be printed by the function itself.
+type GenerateFunc func(roots []*loader.Package) bool
+
+// Watcher regenerates code for packages affected by source file changes.
+type Watcher struct {
+	// Load reloads changed packages.
+	Load LoadFunc
+
+	// Generate generates code for the reloaded packages.
+	Generate GenerateFunc
+
+	// Debounce is the time to wait for further changes before regenerating code.
+	// Defaults to DefaultDebounce.
+	Debounce time.Duration
+
+	// Output receives progress messages and load errors. Defaults to os.Stderr.
+	Output io.Writer
+
+	// GeneratedFiles lists the names (or glob patterns) of the files written by generators
+	// (see genutils.OutputFiler).
+	//
+	// Removed files can't be inspected, so they are told apart from source files by their names.
+	GeneratedFiles []string
+
+	packages map[string]*pkg // by import path
+}
+
+// pkg is a watched root package.
+type pkg struct {
+	dir     string
+	imports []string
+}
+
+// Watch watches the root packages and regenerates code (using the given functions) until interrupted.
+//
+// generatedFiles lists the names (or glob patterns) of the files written by generators (see Watcher.GeneratedFiles).
+func Watch(roots []*loader.Package, load LoadFunc, generate GenerateFunc, generatedFiles []string) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	w := &Watcher{
+		Load:           load,
+		Generate:       generate,
+		GeneratedFiles: generatedFiles,
+	}
+
+	return w.Watch(ctx, roots)
+}
+
+// Watch watches the directories of the root packages until the context is canceled.
+func (w *Watcher) Watch(ctx context.Context, roots []*loader.Package) error {
+	if w.Output == nil {
+		w.Output = os.Stderr
+	}
+
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer fsw.Close()
+
+	for _, dir := range w.track(roots) {
+		if err := fsw.Add(dir); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(w.Output, "Watching %d packages for changes (press Ctrl+C to stop)\n", len(w.packages))
+
+	changes := make(chan string)
+
+	go func() {
+		for {
+			select {
+			case event, ok := <-fsw.Events:
+				if !ok {
+					return
+				}
+
+				if !isSourceChange(event, w.GeneratedFiles) {
+					continue
+				}
+
+				select {
+				case changes <- filepath.Dir(event.Name):
+				case <-ctx.Done():
+					return
+				}
+
+			case err, ok := <-fsw.Errors:
+				if !ok {
+					return
+				}
+
+				fmt.Fprintln(w.Output, err)
+
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return w.loop(ctx, changes, func(dirs []string) {
+		for _, dir := range dirs {
+			if err := fsw.Add(dir); err != nil {
+				fmt.Fprintln(w.Output, err)
+			}
+		}
+	})
+}
+
+// loop collects changed directories and regenerates code once no changes arrive for the debounce duration.
+func (w *Watcher) loop(ctx context.Context, changes <-chan string, watch func(dirs []string)) error {
+	debounce := w.Debounce
+	if debounce <= 0 {
+		debounce = DefaultDebounce
+	}
+
+	timer := time.NewTimer(debounce)
+	timer.Stop()
+
+	pending := map[string]bool{}
+
+	for {
+		select {
+		case dir := <-changes:
+			pending[dir] = true
+
+			timer.Reset(debounce)
+
+		case <-timer.C:
+			dirs := make([]string, 0, len(pending))
+			for dir := range pending {
+				dirs = append(dirs, dir)
+			}
+
+			pending = map[string]bool{}
+
+			if newDirs := w.regenerate(dirs); watch != nil && len(newDirs) > 0 {
+				watch(newDirs)
+			}
+
+		case <-ctx.Done():
+			timer.Stop()
+
+			return nil
+		}
+	}
+}
+
+// regenerate reloads the packages affected by changes in the given directories and generates code for them.
+//
+// It returns the directories of packages that were not watched before.
+func (w *Watcher) regenerate(dirs []string) []string {
+	paths := w.affected(dirs)
+	if len(paths) == 0 {
+		return nil
+	}
+
+	fmt.Fprintf(w.Output, "Regenerating %s\n", strings.Join(paths, ", "))
+
+	roots, err := w.Load(paths...)
+	if err != nil {
+		fmt.Fprintln(w.Output, err)
+
+		return nil
+	}
+
+	newDirs := w.track(roots)
+
+	if hadErrs := w.Generate(roots); hadErrs {
+		fmt.Fprintln(w.Output, "Generation failed, waiting for changes")
+
+		return newDirs
+	}
+
+	fmt.Fprintln(w.Output, "Generation finished, waiting for changes")
+
+	return newDirs
+}
+
+// track records root packages and returns the directories that were not tracked before.
+func (w *Watcher) track(roots []*loader.Package) []string {
+	if w.packages == nil {
+		w.packages = make(map[string]*pkg)
+	}
+
+	dirs := map[string]bool{}
+	for _, p := range w.packages {
+		dirs[p.dir] = true
+	}
+
+	var newDirs []string
+
+	for _, root := range roots {
+		files := root.CompiledGoFiles
+		if len(files) == 0 {
+			files = root.GoFiles
+		}
+
+		if len(files) == 0 {
+			continue
+		}
+
+		p := &pkg{
+			dir: filepath.Dir(files[0]),
+		}
+
+		for importPath := range root.Package.Imports {
+			p.imports = append(p.imports, importPath)
+		}
+
+		w.packages[root.PkgPath] = p
+
+		if !dirs[p.dir] {
+			dirs[p.dir] = true
+			newDirs = append(newDirs, p.dir)
+		}
+	}
+
+	sort.Strings(newDirs)
+
+	return newDirs
+}
+
+// affected returns the import paths of watched packages in the given directories
+// and of every watched package importing them (directly or through other watched packages).
+func (w *Watcher) affected(dirs []string) []string {
+	changed := map[string]bool{}
+	for _, dir := range dirs {
+		changed[dir] = true
+	}
+
+	importers := map[string][]string{}
+
+	var queue []string
+
+	for path, p := range w.packages {
+		for _, importPath := range p.imports {
+			importers[importPath] = append(importers[importPath], path)
+		}
+
+		if changed[p.dir] {
+			queue = append(queue, path)
+		}
+	}
+
+	affected := map[string]bool{}
+
+	for len(queue) > 0 {
+		path := queue[0]
+		queue = queue[1:]
+
+		if affected[path] {
+			continue
+		}
+
+		affected[path] = true
+
+		queue = append(queue, importers[path]...)
+	}
+
+	paths := make([]string, 0, len(affected))
+	for path := range affected {
+		paths = append(paths, path)
+	}
+
+	sort.Strings(paths)
+
+	return paths
+}
+
+// isSourceChange reports whether an event is a change of a (non-generated) Go source file.
+//
+// Files are considered generated when they have an mga header line
+// or (if they can't be read, eg. because they were removed) when they match one of the generated file names.
+func isSourceChange(event fsnotify.Event, generatedFiles []string) bool {
+	if !event.Has(fsnotify.Write) && !event.Has(fsnotify.Create) && !event.Has(fsnotify.Remove) && !event.Has(fsnotify.Rename) {
+		return false
+	}
+
+	name := filepath.Base(event.Name)
+
+	if filepath.Ext(name) != ".go" || strings.HasSuffix(name, "_test.go") {
+		return false
+	}
+
+	// editors often create hidden temporary files
+	if strings.HasPrefix(name, ".") {
+		return false
+	}
+
+	content, err := os.ReadFile(event.Name)
+	if err != nil {
+		// removed (or renamed) files can't be inspected: fall back to the names of generated files
+		return !isGeneratedFile(name, generatedFiles)
+	}
+
+	return !check.IsGenerated(content)
+}
+
+// isGeneratedFile reports whether a file name matches one of the generated file names (or glob patterns).
+func isGeneratedFile(name string, generatedFiles []string) bool {
+	for _, pattern := range generatedFiles {
+		// output rules decide the directory of generated files
+		if ok, _ := filepath.Match(filepath.Base(pattern), name); ok {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,178 @@
+package watch
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/tools/go/packages"
+	"sigs.k8s.io/controller-tools/pkg/loader"
+)
+
+func newPackage(pkgPath string, dir string, imports ...string) *loader.Package {
+	pkg := &packages.Package{
+		PkgPath: pkgPath,
+		GoFiles: []string{filepath.Join(dir, "file.go")},
+		Imports: make(map[string]*packages.Package),
+	}
+
+	for _, importPath := range imports {
+		pkg.Imports[importPath] = &packages.Package{PkgPath: importPath}
+	}
+
+	return &loader.Package{Package: pkg}
+}
+
+func TestWatcher_affected(t *testing.T) {
+	w := &Watcher{}
+
+	newDirs := w.track([]*loader.Package{
+		newPackage("example.com/event", "/src/event"),
+		newPackage("example.com/todo", "/src/todo", "example.com/event", "context"),
+		newPackage("example.com/todo/driver", "/src/todo/driver", "example.com/todo"),
+		newPackage("example.com/user", "/src/user"),
+	})
+
+	assert.Equal(t, []string{"/src/event", "/src/todo", "/src/todo/driver", "/src/user"}, newDirs)
+
+	assert.Equal(
+		t,
+		[]string{"example.com/event", "example.com/todo", "example.com/todo/driver"},
+		w.affected([]string{"/src/event"}),
+	)
+	assert.Equal(t, []string{"example.com/todo/driver"}, w.affected([]string{"/src/todo/driver"}))
+	assert.Equal(t, []string{"example.com/user"}, w.affected([]string{"/src/user", "/src/unknown"}))
+	assert.Empty(t, w.affected([]string{"/src/unknown"}))
+
+	assert.Equal(
+		t,
+		[]string{"/src/project"},
+		w.track([]*loader.Package{newPackage("example.com/project", "/src/project"), newPackage("example.com/user", "/src/user")}),
+	)
+}
+
+func TestWatcher_loop(t *testing.T) {
+	var (
+		mu        sync.Mutex
+		loaded    [][]string
+		generated int
+	)
+
+	done := make(chan struct{})
+
+	w := &Watcher{
+		Load: func(paths ...string) ([]*loader.Package, error) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			loaded = append(loaded, paths)
+
+			return []*loader.Package{newPackage("example.com/todo", "/src/todo")}, nil
+		},
+		Generate: func(_ []*loader.Package) bool {
+			mu.Lock()
+			defer mu.Unlock()
+
+			generated++
+			close(done)
+
+			return false
+		},
+		Debounce: 50 * time.Millisecond,
+		Output:   io.Discard,
+	}
+
+	w.track([]*loader.Package{
+		newPackage("example.com/todo", "/src/todo"),
+		newPackage("example.com/user", "/src/user"),
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	changes := make(chan string)
+	result := make(chan error)
+
+	go func() {
+		result <- w.loop(ctx, changes, nil)
+	}()
+
+	// rapid edits are collected into a single regeneration
+	changes <- "/src/todo"
+	changes <- "/src/todo"
+	changes <- "/src/user"
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("code was not regenerated")
+	}
+
+	cancel()
+	require.NoError(t, <-result)
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	assert.Equal(t, [][]string{{"example.com/todo", "example.com/user"}}, loaded)
+	assert.Equal(t, 1, generated)
+}
+
+func TestIsSourceChange(t *testing.T) {
+	dir := t.TempDir()
+
+	files := map[string]string{
+		"service.go":                "package todo\n",
+		"service_test.go":           "package todo\n",
+		"zz_generated.endpoint.go":  "// Code generated by mga tool. DO NOT EDIT.\n\npackage todo\n",
+		"custom_generated_name.go":  "// Code generated by mga tool. DO NOT EDIT.\n\npackage todo\n",
+		"other_tool_generated.go":   "// Code generated by other tool. DO NOT EDIT.\n\npackage todo\n",
+		"README.md":                 "# Todo\n",
+		".service.go.swp":           "",
+		".#service.go":              "",
+		"zz_generated.mock_test.go": "// Code generated by mga tool. DO NOT EDIT.\n\npackage todo\n",
+		"generator.go":              "package todo\n\nconst header = \"Code generated by mga tool. DO NOT EDIT.\"\n",
+	}
+
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+	}
+
+	tests := []struct {
+		name     string
+		op       fsnotify.Op
+		expected bool
+	}{
+		{"service.go", fsnotify.Write, true},
+		{"service.go", fsnotify.Chmod, false},
+		{"service_test.go", fsnotify.Write, false},
+		{"zz_generated.endpoint.go", fsnotify.Write, false},
+		{"custom_generated_name.go", fsnotify.Create, false},
+		{"other_tool_generated.go", fsnotify.Write, true},
+		{"README.md", fsnotify.Write, false},
+		{".#service.go", fsnotify.Create, false},
+		{"generator.go", fsnotify.Write, true},
+		{"removed.go", fsnotify.Remove, true},
+		{"zz_generated.event_handler.go", fsnotify.Remove, false},
+		{"zz_generated.command_bus.go", fsnotify.Remove, true},
+		{"service.endpoint.go", fsnotify.Remove, false},
+		{"zz_generated.mock.service.go", fsnotify.Rename, false},
+	}
+
+	generatedFiles := []string{"zz_generated.event_handler.go", "*.endpoint.go", "zz_generated.mock.*.go"}
+
+	for _, test := range tests {
+		t.Run(test.op.String()+" "+test.name, func(t *testing.T) {
+			event := fsnotify.Event{Name: filepath.Join(dir, test.name), Op: test.op}
+
+			assert.Equal(t, test.expected, isSourceChange(event, generatedFiles))
+		})
+	}
+}
@@ -0,0 +1,101 @@
+// Package generator provides the building blocks of mga generators.
+//
+// Generators built with this package behave the same way as builtin ones:
+// they prepend the same header text, honor output rules and can be run by a command
+// with the same flags and configuration (see NewCommand).
+package generator
+
+import (
+	"io"
+	"strings"
+
+	"sigs.k8s.io/controller-tools/pkg/genall"
+	"sigs.k8s.io/controller-tools/pkg/loader"
+
+	"sagikazarmark.dev/mga/pkg/gentypes"
+	"sagikazarmark.dev/mga/pkg/genutils"
+)
+
+// Base implements the parts shared by generators writing Go code.
+//
+// Embed it in generators:
+//
+//	type Generator struct {
+//		generator.Base
+//	}
+type Base struct {
+	// HeaderFile specifies the header text (e.g. license) to prepend to generated files.
+	HeaderFile string `marker:",optional"`
+
+	// Year specifies the year to substitute for " YEAR" in the header file.
+	Year string `marker:",optional"`
+
+	// FileName specifies the template of generated file names (see OutputFileName).
+	FileName string `marker:",optional"`
+
+	// PerType writes a separate file for every marked type (if the generator supports it).
+	PerType bool `marker:",optional"`
+}
+
+// HeaderText reads the header file (if any) and substitutes " YEAR" in it.
+func (b Base) HeaderText(ctx *genall.GenerationContext) (string, error) {
+	var headerText string
+
+	if b.HeaderFile != "" {
+		headerBytes, err := ctx.ReadFile(b.HeaderFile)
+		if err != nil {
+			return "", err
+		}
+
+		headerText = string(headerBytes)
+	}
+
+	return strings.ReplaceAll(headerText, " YEAR", " "+b.Year), nil
+}
+
+// OutputPackage returns the package code generated for a package belongs to.
+//
+// It is the package itself, unless the output rule of the generation context
+// writes files somewhere else (see genutils.PackageRefer).
+func OutputPackage(ctx *genall.GenerationContext, root *loader.Package) gentypes.PackageRef {
+	packageName, packagePath := root.Name, root.PkgPath
+	if pkgrefer, ok := ctx.OutputRule.(genutils.PackageRefer); ok {
+		packageName, packagePath = pkgrefer.PackageRef(root)
+	}
+
+	return gentypes.PackageRef{
+		Name: packageName,
+		Path: packagePath,
+	}
+}
+
+// WriteFile writes a file generated for a package using the output rule of the generation context.
+//
+// Errors (including the ones returned when closing the file) are added to the package.
+func WriteFile(ctx *genall.GenerationContext, root *loader.Package, fileName string, outBytes []byte) {
+	outputFile, err := ctx.Open(root, fileName)
+	if err != nil {
+		root.AddError(err)
+
+		return
+	}
+	n, err := outputFile.Write(outBytes)
+	if err != nil {
+		root.AddError(err)
+		_ = outputFile.Close()
+
+		return
+	}
+	if n < len(outBytes) {
+		root.AddError(io.ErrShortWrite)
+		_ = outputFile.Close()
+
+		return
+	}
+
+	// Some output rules (eg. the ones keeping track of generated files) only write files when they are closed
+	err = outputFile.Close()
+	if err != nil {
+		root.AddError(err)
+	}
+}
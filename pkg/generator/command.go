@@ -0,0 +1,217 @@
+package generator
+
+import (
+	"errors"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"sigs.k8s.io/controller-tools/pkg/genall"
+	"sigs.k8s.io/controller-tools/pkg/loader"
+
+	"sagikazarmark.dev/mga/internal/config"
+	"sagikazarmark.dev/mga/internal/generate/check"
+	"sagikazarmark.dev/mga/internal/generate/diagnostics"
+	"sagikazarmark.dev/mga/internal/generate/runner"
+	"sagikazarmark.dev/mga/internal/generate/watch"
+	"sagikazarmark.dev/mga/pkg/genutils"
+)
+
+// Command describes a command running a generator.
+type Command struct {
+	// Name of the generator (eg. kit:endpoint).
+	//
+	// It identifies the generator in the configuration (mga.yaml) and in reported errors.
+	Name string
+
+	// Output is the default output rule of the generator (defaults to pkg).
+	Output string
+
+	// Use is the one-line usage message of the command (eg. "mock [flags] [paths]").
+	Use string
+
+	// Aliases of the command.
+	Aliases []string
+
+	// Short description of the command.
+	Short string
+
+	// Long description of the command.
+	Long string
+
+	// NoHeader omits the header and file name flags (for generators not writing Go code).
+	NoHeader bool
+
+	// PerType adds a flag for writing a separate file for every marked type
+	// (for generators supporting it, see Base.PerType).
+	PerType bool
+
+	// Flags registers generator specific flags.
+	Flags func(flags *pflag.FlagSet)
+
+	// New creates the generator once flags are parsed.
+	New func(base Base) genall.Generator
+}
+
+type commandOptions struct {
+	base  Base
+	build runner.BuildOptions
+
+	paths  []string
+	output string
+	check  bool
+	prune  bool
+	watch  bool
+
+	diagnosticsFormat string
+
+	config config.Config
+}
+
+// NewCommand returns a cobra command running a generator.
+//
+// The command works the same way as builtin generate commands:
+// it reads the configuration (mga.yaml) and supports output rules, checking and pruning generated files,
+// watching for changes and reporting errors in different formats.
+func NewCommand(command Command) *cobra.Command {
+	var options commandOptions
+
+	if command.Output == "" {
+		command.Output = "pkg"
+	}
+
+	cmd := &cobra.Command{
+		Use:     command.Use,
+		Aliases: command.Aliases,
+		Short:   command.Short,
+		Long:    command.Long,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceErrors = true
+			cmd.SilenceUsage = true
+
+			cfg, err := config.LoadGenerator(command.Name, command.Output)
+			if err != nil {
+				return err
+			}
+
+			if err := cfg.ApplyFlags(command.Name, cmd.Flags()); err != nil {
+				return err
+			}
+
+			options.paths = args
+			options.config = cfg
+
+			return runCommand(command, options)
+		},
+	}
+
+	flags := cmd.Flags()
+
+	flags.StringVar(&options.output, "output", command.Output, "output rule")
+	flags.BoolVar(&options.check, "check", false, "check that generated files are up-to-date without writing anything")
+	flags.BoolVar(&options.prune, "prune", false, "remove generated files that are not generated anymore")
+	flags.BoolVar(&options.watch, "watch", false, "regenerate code when source files change")
+	flags.StringVar(&options.diagnosticsFormat, "diagnostics-format", "text", "format of reported errors (text, json or sarif)")
+	flags.StringSliceVar(&options.build.Tags, "tags", nil, "additional build tags used when loading packages")
+	flags.StringVar(&options.build.GOOS, "goos", "", "target operating system used when loading packages")
+	flags.StringVar(&options.build.GOARCH, "goarch", "", "target architecture used when loading packages")
+
+	if !command.NoHeader {
+		flags.StringVar(&options.base.HeaderFile, "header-file", "", "header text (e.g. license) to prepend to generated files")
+		flags.StringVar(&options.base.Year, "year", "", "copyright year")
+		flags.StringVar(&options.base.FileName, "file-name", DefaultFileName, "template of generated file names")
+	}
+
+	if command.PerType {
+		flags.BoolVar(&options.base.PerType, "per-type", false, "write a separate file for every marked type")
+	}
+
+	if command.Flags != nil {
+		command.Flags(flags)
+	}
+
+	return cmd
+}
+
+func runCommand(command Command, options commandOptions) error {
+	if err := options.base.ValidateFileName(); err != nil {
+		return err
+	}
+
+	generator := command.New(options.base)
+
+	if options.watch && (options.check || options.prune) {
+		return errors.New("--watch cannot be used with --check or --prune")
+	}
+
+	diagnosticsFormat, err := diagnostics.ParseFormat(options.diagnosticsFormat)
+	if err != nil {
+		return err
+	}
+
+	if len(options.paths) == 0 {
+		options.paths = options.config.Include()
+	}
+
+	roots, err := options.build.Load(options.paths...)
+	if err != nil {
+		return err
+	}
+
+	runtime, err := runner.New([]runner.Generator{{Name: command.Name, Generator: generator}}, roots)
+	if err != nil {
+		return err
+	}
+
+	runtime.Roots = options.config.Filter(runtime.Roots)
+	runtime.DiagnosticsFormat = diagnosticsFormat
+
+	outputRule, err := genutils.LookupOutput(options.output)
+	if err != nil {
+		return err
+	}
+
+	runtime.Generators[0].OutputRule = outputRule
+
+	checkOutput := check.NewOutput(outputRule, generator)
+	if options.prune && !options.check {
+		checkOutput = check.NewWritingOutput(outputRule, generator)
+	}
+
+	if options.check || options.prune {
+		runtime.Generators[0].OutputRule = checkOutput
+	}
+
+	hadErrs := runtime.Run()
+
+	if options.watch {
+		return watch.Watch(runtime.Roots, options.build.Load, func(roots []*loader.Package) bool {
+			runtime.Roots = options.config.Filter(roots)
+
+			return runtime.Run()
+		}, runtime.OutputFiles())
+	}
+
+	if hadErrs {
+		os.Exit(1)
+	}
+
+	if options.check {
+		stale, err := check.Run(os.Stdout, runtime.Roots, checkOutput)
+		if err != nil {
+			return err
+		}
+
+		if stale {
+			os.Exit(1)
+		}
+	}
+
+	if options.prune && !options.check {
+		if err := check.Prune(os.Stdout, runtime.Roots, false, checkOutput); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
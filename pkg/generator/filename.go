@@ -0,0 +1,198 @@
+package generator
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// DefaultFileName is the default template of generated file names.
+const DefaultFileName = "zz_generated.{{ .Name }}{{ with .Type }}.{{ lower . }}{{ end }}"
+
+// testSuffix marks test files.
+const testSuffix = "_test"
+
+// nolint: gochecknoglobals
+var fileNameFuncs = template.FuncMap{
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+}
+
+// OutputFileName returns the name of a generated Go file.
+//
+// The name is the kind of the file (eg. endpoint); a _test suffix (eg. mock_test) marks test files.
+// The type name is the marked type the file is generated for in per-type mode (empty otherwise).
+//
+// The file name template receives the kind (.Name) and the type (.Type).
+// The _test suffix and the .go extension are added to the rendered name.
+// Templates that may give files of different kinds or types the same name are rejected (see ValidateFileName).
+func (b Base) OutputFileName(name string, typeName string) (string, error) {
+	tmpl, err := b.fileNameTemplate()
+	if err != nil {
+		return "", err
+	}
+
+	return renderFileName(tmpl, name, typeName)
+}
+
+// ValidateFileName checks that the file name template gives files of different kinds
+// (and of different types in per-type mode) different names.
+//
+// In per-type mode, file names of a kind (with any type name) must not match the names of files of other kinds either:
+// files that are not generated anymore are looked up with glob patterns (see OutputFilePatterns).
+func (b Base) ValidateFileName() error {
+	_, err := b.fileNameTemplate()
+
+	return err
+}
+
+// fileNameTemplate parses and validates the file name template.
+func (b Base) fileNameTemplate() (*template.Template, error) {
+	text := b.FileName
+	if text == "" {
+		text = DefaultFileName
+	}
+
+	tmpl, err := template.New("file name").Funcs(fileNameFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid file name template: %w", err)
+	}
+
+	// the template is rendered for sample kinds and types
+	render := func(name string, typeName string) string {
+		fileName, _ := renderFileName(tmpl, name, typeName)
+
+		return fileName
+	}
+
+	if _, err := renderFileName(tmpl, "a", ""); err != nil {
+		return nil, err
+	}
+
+	if render("a", "") == render("b", "") || render("a", "T") == render("b", "T") {
+		return nil, fmt.Errorf("invalid file name template %q: files of different kinds get the same name (use {{ .Name }})", text)
+	}
+
+	if !b.PerType {
+		return tmpl, nil
+	}
+
+	if _, err := renderFileName(tmpl, "a", "T"); err != nil {
+		return nil, err
+	}
+
+	if render("a", "T") == render("a", "U") || render("a", "T") == render("a", "") {
+		return nil, fmt.Errorf("invalid file name template %q: files of different types get the same name (use {{ .Type }})", text)
+	}
+
+	pattern := render("a", "*")
+
+	for _, name := range []string{"a_b", "b_a", "ab", "ba"} {
+		for _, typeName := range []string{"", "T"} {
+			if ok, _ := filepath.Match(pattern, render(name, typeName)); ok {
+				return nil, fmt.Errorf(
+					"invalid file name template %q: file names of a kind may match files of other kinds (separate {{ .Type }} with a dot)",
+					text,
+				)
+			}
+		}
+	}
+
+	return tmpl, nil
+}
+
+// renderFileName renders the file name template for a kind and a type.
+func renderFileName(tmpl *template.Template, name string, typeName string) (string, error) {
+	name, test := strings.CutSuffix(name, testSuffix)
+
+	var fileName strings.Builder
+
+	err := tmpl.Execute(&fileName, struct {
+		Name string
+		Type string
+	}{
+		Name: name,
+		Type: typeName,
+	})
+	if err != nil {
+		return "", fmt.Errorf("invalid file name template: %w", err)
+	}
+
+	baseName := strings.TrimSuffix(fileName.String(), ".go")
+
+	switch {
+	case baseName == "" || filepath.Base(baseName) != baseName || strings.ContainsAny(baseName, `/\`):
+		return "", fmt.Errorf("invalid file name %q", fileName.String())
+
+	// the go command ignores these files
+	case strings.HasPrefix(baseName, ".") || strings.HasPrefix(baseName, "_"):
+		return "", fmt.Errorf("invalid file name %q: files starting with . or _ are ignored by the go command", fileName.String())
+
+	case strings.HasSuffix(baseName, testSuffix):
+		return "", fmt.Errorf("invalid file name %q: the _test suffix is added to test files only", fileName.String())
+	}
+
+	if test {
+		baseName += testSuffix
+	}
+
+	return baseName + ".go", nil
+}
+
+// OutputFilePatterns returns the names of generated files of the given kinds (see OutputFileName)
+// for implementing genutils.OutputFiler.
+//
+// In per-type mode, glob patterns matching the files of every type are returned as well.
+// Kinds with invalid file names are omitted.
+func (b Base) OutputFilePatterns(names ...string) []string {
+	var patterns []string
+
+	for _, name := range names {
+		typeNames := []string{""}
+		if b.PerType {
+			typeNames = append(typeNames, "*")
+		}
+
+		for _, typeName := range typeNames {
+			pattern, err := b.OutputFileName(name, typeName)
+			if err != nil {
+				continue
+			}
+
+			patterns = append(patterns, pattern)
+		}
+	}
+
+	return patterns
+}
+
+// FileSet keeps track of the files generated for a package.
+//
+// Different types may still be generated into the same file, eg. when their names differ only in case
+// and the file name template ignores the case of type names (like the default one).
+type FileSet map[string]string
+
+// Add adds a file generated for a type (or for the package if the type name is empty).
+//
+// It returns an error if the file is already generated for another type.
+func (s FileSet) Add(fileName string, typeName string) error {
+	if other, ok := s[fileName]; ok {
+		return fmt.Errorf(
+			"%s is generated for both %s and %s: use a file name template telling them apart",
+			fileName, describeFileType(other), describeFileType(typeName),
+		)
+	}
+
+	s[fileName] = typeName
+
+	return nil
+}
+
+func describeFileType(typeName string) string {
+	if typeName == "" {
+		return "the package"
+	}
+
+	return typeName
+}
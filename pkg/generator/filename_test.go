@@ -0,0 +1,161 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBase_OutputFileName(t *testing.T) {
+	tests := []struct {
+		name     string
+		base     Base
+		kind     string
+		typeName string
+		expected string
+	}{
+		{
+			name:     "default",
+			kind:     "endpoint",
+			expected: "zz_generated.endpoint.go",
+		},
+		{
+			name:     "default_test",
+			kind:     "mock_test",
+			expected: "zz_generated.mock_test.go",
+		},
+		{
+			name:     "default_type",
+			kind:     "mock",
+			typeName: "TodoService",
+			expected: "zz_generated.mock.todoservice.go",
+		},
+		{
+			name:     "default_type_test",
+			kind:     "mock_external_test",
+			typeName: "TodoService",
+			expected: "zz_generated.mock_external.todoservice_test.go",
+		},
+		{
+			name:     "template",
+			base:     Base{FileName: "{{ .Name }}.gen"},
+			kind:     "mock_test",
+			expected: "mock.gen_test.go",
+		},
+		{
+			name:     "template_go_extension",
+			base:     Base{FileName: "{{ .Name }}{{ with .Type }}.{{ . }}{{ end }}.go", PerType: true},
+			kind:     "mock",
+			typeName: "Service",
+			expected: "mock.Service.go",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			fileName, err := test.base.OutputFileName(test.kind, test.typeName)
+			require.NoError(t, err)
+
+			assert.Equal(t, test.expected, fileName)
+		})
+	}
+}
+
+func TestBase_OutputFileName_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		base Base
+	}{
+		{
+			name: "invalid_template",
+			base: Base{FileName: "{{ .Name "},
+		},
+		{
+			name: "unknown_field",
+			base: Base{FileName: "{{ .Package }}"},
+		},
+		{
+			name: "empty",
+			base: Base{FileName: "{{ .Type }}"},
+		},
+		{
+			name: "directory",
+			base: Base{FileName: "gen/{{ .Name }}"},
+		},
+		{
+			name: "ignored",
+			base: Base{FileName: "_{{ .Name }}"},
+		},
+		{
+			name: "test_suffix",
+			base: Base{FileName: "{{ .Name }}_test"},
+		},
+		{
+			name: "no_name",
+			base: Base{FileName: "generated"},
+		},
+		{
+			name: "per_type_no_type",
+			base: Base{FileName: "zz_generated.{{ .Name }}", PerType: true},
+		},
+		{
+			name: "per_type_type_only",
+			base: Base{FileName: "{{ with .Type }}{{ . }}{{ else }}generated{{ end }}", PerType: true},
+		},
+		{
+			name: "per_type_ambiguous",
+			base: Base{FileName: "{{ .Name }}{{ with .Type }}_{{ . }}{{ end }}", PerType: true},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			_, err := test.base.OutputFileName("mock", "")
+			require.Error(t, err)
+
+			assert.Error(t, test.base.ValidateFileName())
+		})
+	}
+}
+
+func TestBase_OutputFilePatterns(t *testing.T) {
+	assert.Equal(
+		t,
+		[]string{"zz_generated.mock.go", "zz_generated.mock_test.go"},
+		Base{}.OutputFilePatterns("mock", "mock_test"),
+	)
+
+	assert.Equal(
+		t,
+		[]string{
+			"zz_generated.mock.go",
+			"zz_generated.mock.*.go",
+			"zz_generated.mock_test.go",
+			"zz_generated.mock.*_test.go",
+		},
+		Base{PerType: true}.OutputFilePatterns("mock", "mock_test"),
+	)
+
+	assert.Empty(t, Base{FileName: "{{ .Name "}.OutputFilePatterns("mock"))
+}
+
+func TestFileSet_Add(t *testing.T) {
+	files := FileSet{}
+
+	require.NoError(t, files.Add("zz_generated.mock.go", ""))
+	require.NoError(t, files.Add("zz_generated.mock.service.go", "Service"))
+
+	err := files.Add("zz_generated.mock.service.go", "service")
+	require.Error(t, err)
+
+	assert.Equal(
+		t,
+		"zz_generated.mock.service.go is generated for both Service and service: use a file name template telling them apart",
+		err.Error(),
+	)
+}
@@ -0,0 +1,124 @@
+package generator
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"emperror.dev/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/controller-tools/pkg/genall"
+	"sigs.k8s.io/controller-tools/pkg/loader"
+
+	"sagikazarmark.dev/mga/pkg/gentypes"
+	"sagikazarmark.dev/mga/pkg/genutils"
+)
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
+
+type bufferOutput struct {
+	files map[string]*bytes.Buffer
+}
+
+func (o *bufferOutput) Open(_ *loader.Package, itemPath string) (io.WriteCloser, error) {
+	buf := &bytes.Buffer{}
+	o.files[itemPath] = buf
+
+	return nopCloser{buf}, nil
+}
+
+type failingCloser struct {
+	io.Writer
+
+	err error
+}
+
+func (c failingCloser) Close() error {
+	return c.err
+}
+
+type failingCloseOutput struct {
+	err error
+}
+
+func (o failingCloseOutput) Open(_ *loader.Package, _ string) (io.WriteCloser, error) {
+	return failingCloser{Writer: io.Discard, err: o.err}, nil
+}
+
+func TestBase_HeaderText(t *testing.T) {
+	ctx := &genall.GenerationContext{InputRule: genall.InputFromFileSystem}
+
+	headerText, err := Base{HeaderFile: "testdata/header/header.txt", Year: "2020"}.HeaderText(ctx)
+	require.NoError(t, err)
+
+	assert.Equal(t, "// Copyright 2020 Acme Inc.\n", headerText)
+
+	headerText, err = Base{}.HeaderText(ctx)
+	require.NoError(t, err)
+
+	assert.Equal(t, "", headerText)
+
+	_, err = Base{HeaderFile: "testdata/header/missing.txt"}.HeaderText(ctx)
+	require.Error(t, err)
+}
+
+func TestOutputPackage(t *testing.T) {
+	root := loadPackage(t)
+
+	ctx := &genall.GenerationContext{OutputRule: genutils.OutputPackage{}}
+
+	assert.Equal(
+		t,
+		gentypes.PackageRef{Name: "types", Path: "sagikazarmark.dev/mga/pkg/generator/testdata/types"},
+		OutputPackage(ctx, root),
+	)
+
+	ctx = &genall.GenerationContext{OutputRule: genutils.OutputSubpackage{Suffix: "gen"}}
+
+	assert.Equal(
+		t,
+		gentypes.PackageRef{Name: "typesgen", Path: "sagikazarmark.dev/mga/pkg/generator/testdata/types/typesgen"},
+		OutputPackage(ctx, root),
+	)
+
+	ctx = &genall.GenerationContext{OutputRule: genall.OutputToStdout}
+
+	assert.Equal(
+		t,
+		gentypes.PackageRef{Name: "types", Path: "sagikazarmark.dev/mga/pkg/generator/testdata/types"},
+		OutputPackage(ctx, root),
+		"output rules not implementing PackageRefer should fall back to the package itself",
+	)
+}
+
+func TestWriteFile(t *testing.T) {
+	root := loadPackage(t)
+
+	output := &bufferOutput{files: make(map[string]*bytes.Buffer)}
+	ctx := &genall.GenerationContext{OutputRule: output}
+
+	WriteFile(ctx, root, "zz_generated.test.go", []byte("package types\n"))
+
+	require.Contains(t, output.files, "zz_generated.test.go")
+	assert.Equal(t, "package types\n", output.files["zz_generated.test.go"].String())
+	assert.Empty(t, root.Errors)
+}
+
+func TestWriteFile_CloseError(t *testing.T) {
+	root := loadPackage(t)
+
+	failure := errors.NewPlain("close error")
+	ctx := &genall.GenerationContext{OutputRule: failingCloseOutput{err: failure}}
+
+	WriteFile(ctx, root, "zz_generated.test.go", []byte("package types\n"))
+
+	require.Len(t, root.Errors, 1)
+	assert.Contains(t, root.Errors[0].Error(), failure.Error())
+}
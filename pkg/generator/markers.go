@@ -0,0 +1,68 @@
+package generator
+
+import (
+	"fmt"
+	"go/types"
+
+	"sigs.k8s.io/controller-tools/pkg/loader"
+	"sigs.k8s.io/controller-tools/pkg/markers"
+)
+
+// RegisterMarker registers a marker definition and its help (if any) in a registry.
+func RegisterMarker(into *markers.Registry, def *markers.Definition, help *markers.DefinitionHelp) error {
+	if err := into.Register(def); err != nil {
+		return err
+	}
+
+	if help != nil {
+		into.AddHelp(def, help)
+	}
+
+	return nil
+}
+
+// LookupType returns the type object of a marked type.
+//
+// Errors point to the type declaration, so they can be added to the package as they are.
+func LookupType(root *loader.Package, info *markers.TypeInfo) (*types.TypeName, error) {
+	obj, ok := root.TypesInfo.ObjectOf(info.RawSpec.Name).(*types.TypeName)
+	if !ok {
+		return nil, loader.ErrFromNode(fmt.Errorf("unknown type %s", info.Name), info.RawSpec)
+	}
+
+	return obj, nil
+}
+
+// LookupInterface returns the type object of a marked interface type.
+//
+// Errors point to the type declaration, so they can be added to the package as they are.
+func LookupInterface(root *loader.Package, info *markers.TypeInfo) (*types.TypeName, *types.Interface, error) {
+	obj, err := LookupType(root, info)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	iface, ok := obj.Type().Underlying().(*types.Interface)
+	if !ok {
+		return nil, nil, loader.ErrFromNode(fmt.Errorf("%s is not an interface", info.Name), info.RawSpec)
+	}
+
+	return obj, iface, nil
+}
+
+// LookupStruct returns the type object of a marked struct type.
+//
+// Errors point to the type declaration, so they can be added to the package as they are.
+func LookupStruct(root *loader.Package, info *markers.TypeInfo) (*types.TypeName, *types.Struct, error) {
+	obj, err := LookupType(root, info)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	strct, ok := obj.Type().Underlying().(*types.Struct)
+	if !ok {
+		return nil, nil, loader.ErrFromNode(fmt.Errorf("%s is not a struct", info.Name), info.RawSpec)
+	}
+
+	return obj, strct, nil
+}
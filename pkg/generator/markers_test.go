@@ -0,0 +1,89 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/controller-tools/pkg/loader"
+	"sigs.k8s.io/controller-tools/pkg/markers"
+)
+
+func loadPackage(t *testing.T) *loader.Package {
+	t.Helper()
+
+	roots, err := loader.LoadRoots("./testdata/types")
+	require.NoError(t, err)
+	require.Len(t, roots, 1)
+
+	return roots[0]
+}
+
+func typeInfos(t *testing.T, root *loader.Package) map[string]*markers.TypeInfo {
+	t.Helper()
+
+	root.NeedTypesInfo()
+
+	infos := make(map[string]*markers.TypeInfo)
+
+	err := markers.EachType(&markers.Collector{Registry: &markers.Registry{}}, root, func(info *markers.TypeInfo) {
+		infos[info.Name] = info
+	})
+	require.NoError(t, err)
+
+	return infos
+}
+
+type testMarker struct{}
+
+func TestRegisterMarker(t *testing.T) {
+	registry := &markers.Registry{}
+	def := markers.Must(markers.MakeDefinition("test:marker", markers.DescribesType, testMarker{}))
+
+	require.NoError(t, RegisterMarker(registry, def, &markers.DefinitionHelp{Category: "Test"}))
+
+	assert.Equal(t, def, registry.Lookup("+test:marker", markers.DescribesType))
+	assert.Equal(t, "Test", registry.HelpFor(def).Category)
+}
+
+func TestLookupType(t *testing.T) {
+	root := loadPackage(t)
+	infos := typeInfos(t, root)
+
+	obj, err := LookupType(root, infos["ID"])
+	require.NoError(t, err)
+
+	assert.Equal(t, "ID", obj.Name())
+}
+
+func TestLookupInterface(t *testing.T) {
+	root := loadPackage(t)
+	infos := typeInfos(t, root)
+
+	obj, iface, err := LookupInterface(root, infos["Service"])
+	require.NoError(t, err)
+
+	assert.Equal(t, "Service", obj.Name())
+	assert.Equal(t, 1, iface.NumMethods())
+
+	_, _, err = LookupInterface(root, infos["Request"])
+	require.Error(t, err)
+
+	assert.Contains(t, err.Error(), "Request is not an interface")
+}
+
+func TestLookupStruct(t *testing.T) {
+	root := loadPackage(t)
+	infos := typeInfos(t, root)
+
+	obj, strct, err := LookupStruct(root, infos["Request"])
+	require.NoError(t, err)
+
+	assert.Equal(t, "Request", obj.Name())
+	assert.Equal(t, 1, strct.NumFields())
+
+	_, _, err = LookupStruct(root, infos["ID"])
+	require.Error(t, err)
+
+	assert.Contains(t, err.Error(), "ID is not a struct")
+}
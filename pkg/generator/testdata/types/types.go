@@ -0,0 +1,11 @@
+package types
+
+type Service interface {
+	Call() error
+}
+
+type Request struct {
+	ID string
+}
+
+type ID string
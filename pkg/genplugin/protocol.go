@@ -0,0 +1,138 @@
+// Package genplugin implements the protocol between mga and external generator plugins.
+//
+// Plugins are executables named mga-gen-<name> found in PATH.
+// mga runs them with a single argument:
+//
+//   - describe: the plugin writes its Description (as JSON) to the standard output
+//   - generate: the plugin reads a Request (as JSON) from the standard input
+//     and writes a Response (as JSON) to the standard output
+//
+// Plugins written in Go can use Serve to implement the protocol.
+package genplugin
+
+import (
+	"sagikazarmark.dev/mga/pkg/gentypes"
+)
+
+// ProtocolVersion is the version of the plugin protocol.
+//
+// It changes only when the protocol changes in an incompatible way.
+const ProtocolVersion = 1
+
+// Marker targets.
+const (
+	PackageTarget = "package"
+	TypeTarget    = "type"
+	FieldTarget   = "field"
+)
+
+// Kinds of type declarations type markers can be restricted to.
+const (
+	InterfaceKind = "interface"
+	StructKind    = "struct"
+)
+
+// Argument types.
+const (
+	StringArgument     = "string"
+	IntArgument        = "int"
+	BoolArgument       = "bool"
+	StringListArgument = "[]string"
+	IntListArgument    = "[]int"
+)
+
+// Description describes a plugin.
+type Description struct {
+	// ProtocolVersion is the version of the protocol implemented by the plugin.
+	ProtocolVersion int `json:"protocolVersion"`
+
+	// Markers recognized by the plugin.
+	Markers []Marker `json:"markers"`
+
+	// OutputFiles are the names of the files the plugin might generate
+	// (used for detecting stale and orphaned files).
+	OutputFiles []string `json:"outputFiles,omitempty"`
+}
+
+// Marker describes a marker recognized by a plugin.
+type Marker struct {
+	// Name of the marker (without the leading +, eg. authz:table).
+	Name string `json:"name"`
+
+	// Target of the marker (package, type or field).
+	Target string `json:"target"`
+
+	// Kind restricts type markers to interfaces or structs (optional).
+	Kind string `json:"kind,omitempty"`
+
+	// Help describes the marker.
+	Help string `json:"help,omitempty"`
+
+	// Arguments of the marker.
+	Arguments []Argument `json:"arguments,omitempty"`
+}
+
+// Argument describes a marker argument.
+type Argument struct {
+	// Name of the argument.
+	Name string `json:"name"`
+
+	// Type of the argument (string, int, bool, []string or []int).
+	Type string `json:"type"`
+
+	// Optional is true if the argument can be omitted.
+	Optional bool `json:"optional,omitempty"`
+
+	// Help describes the argument.
+	Help string `json:"help,omitempty"`
+}
+
+// Request asks a plugin to generate code for a package.
+type Request struct {
+	// ProtocolVersion is the version of the protocol used by mga.
+	ProtocolVersion int `json:"protocolVersion"`
+
+	// Package is the package code is generated for.
+	Package gentypes.PackageRef `json:"package"`
+
+	// Output is the package generated code is written to (depending on the output rule).
+	Output gentypes.PackageRef `json:"output"`
+
+	// HeaderText should be added to the top of generated files (eg. license information).
+	HeaderText string `json:"headerText,omitempty"`
+
+	// Markers are the values of package markers recognized by the plugin (by marker name).
+	Markers map[string][]interface{} `json:"markers,omitempty"`
+
+	// Types are the types marked with (or having fields marked with) markers recognized by the plugin.
+	//
+	// Only markers recognized by the plugin are included.
+	Types []gentypes.TypeDecl `json:"types,omitempty"`
+}
+
+// Response contains the files generated by a plugin.
+type Response struct {
+	// Files to write using the output rule of the plugin.
+	Files []File `json:"files,omitempty"`
+
+	// Errors found in the source code (eg. invalid use of markers).
+	Errors []Error `json:"errors,omitempty"`
+}
+
+// File is a generated file.
+type File struct {
+	// Name of the file (eg. zz_generated.authz.go).
+	Name string `json:"name"`
+
+	// Content of the file.
+	Content string `json:"content"`
+}
+
+// Error is a problem found in the source code.
+type Error struct {
+	// Message describes the problem.
+	Message string `json:"message"`
+
+	// Type is the name of the type the problem was found in (if any).
+	Type string `json:"type,omitempty"`
+}
@@ -0,0 +1,77 @@
+package genplugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Plugin generates code for marked types.
+type Plugin interface {
+	// Describe returns the markers recognized by the plugin.
+	Describe() Description
+
+	// Generate generates code for a package.
+	Generate(req Request) (Response, error)
+}
+
+// Serve implements the plugin protocol for a plugin and exits.
+func Serve(plugin Plugin) {
+	os.Exit(serve(plugin, os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func serve(plugin Plugin, args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	if len(args) != 1 {
+		fmt.Fprintln(stderr, "usage: mga-gen-<name> describe|generate")
+
+		return 2
+	}
+
+	switch args[0] {
+	case "describe":
+		description := plugin.Describe()
+		description.ProtocolVersion = ProtocolVersion
+
+		return encode(stdout, stderr, description)
+
+	case "generate":
+		var req Request
+
+		if err := json.NewDecoder(stdin).Decode(&req); err != nil {
+			fmt.Fprintf(stderr, "invalid request: %v\n", err)
+
+			return 1
+		}
+
+		if req.ProtocolVersion != ProtocolVersion {
+			fmt.Fprintf(stderr, "unsupported protocol version %d (expected %d)\n", req.ProtocolVersion, ProtocolVersion)
+
+			return 1
+		}
+
+		resp, err := plugin.Generate(req)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+
+			return 1
+		}
+
+		return encode(stdout, stderr, resp)
+
+	default:
+		fmt.Fprintf(stderr, "unknown command %q (expected describe or generate)\n", args[0])
+
+		return 2
+	}
+}
+
+func encode(stdout io.Writer, stderr io.Writer, v interface{}) int {
+	if err := json.NewEncoder(stdout).Encode(v); err != nil {
+		fmt.Fprintln(stderr, err)
+
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,133 @@
+package gentypes
+
+// TypeKind is the kind of type expression.
+type TypeKind string
+
+// Supported type kinds.
+const (
+	BasicKind     TypeKind = "basic"
+	NamedKind     TypeKind = "named"
+	TypeParamKind TypeKind = "typeParam"
+	PointerKind   TypeKind = "pointer"
+	SliceKind     TypeKind = "slice"
+	ArrayKind     TypeKind = "array"
+	MapKind       TypeKind = "map"
+	ChanKind      TypeKind = "chan"
+	FuncKind      TypeKind = "func"
+	InterfaceKind TypeKind = "interface"
+	StructKind    TypeKind = "struct"
+)
+
+// Type describes a type expression (eg. the type of a field or a parameter).
+type Type struct {
+	// Kind of the type expression.
+	Kind TypeKind `json:"kind"`
+
+	// Name of basic, named types and type parameters.
+	Name string `json:"name,omitempty"`
+
+	// Package of named types (nil for builtin types, like error).
+	Package *PackageRef `json:"package,omitempty"`
+
+	// TypeArgs of instantiated generic types.
+	TypeArgs []Type `json:"typeArgs,omitempty"`
+
+	// Elem is the element type of pointers, slices, arrays, channels and maps.
+	Elem *Type `json:"elem,omitempty"`
+
+	// Key is the key type of maps.
+	Key *Type `json:"key,omitempty"`
+
+	// Len is the length of arrays.
+	Len int64 `json:"len,omitempty"`
+
+	// Dir is the direction of channels (send, recv or empty for bidirectional channels).
+	Dir string `json:"dir,omitempty"`
+
+	// Params of function types.
+	Params []Param `json:"params,omitempty"`
+
+	// Results of function types.
+	Results []Param `json:"results,omitempty"`
+
+	// Variadic is true if the last parameter of a function type is variadic.
+	Variadic bool `json:"variadic,omitempty"`
+
+	// Expr is the type expression as Go source (with types qualified by their package name).
+	//
+	// Anonymous struct and interface types are only described by their expression.
+	Expr string `json:"expr"`
+}
+
+// Param is a parameter or result of a method.
+type Param struct {
+	// Name of the parameter (might be empty).
+	Name string `json:"name,omitempty"`
+
+	// Type of the parameter.
+	//
+	// The type of variadic parameters is a slice.
+	Type Type `json:"type"`
+}
+
+// Method is a method of an interface or a named type.
+type Method struct {
+	// Name of the method.
+	Name string `json:"name"`
+
+	// Doc is the doc comment of the method (without comment markers).
+	Doc string `json:"doc,omitempty"`
+
+	// Params of the method.
+	Params []Param `json:"params,omitempty"`
+
+	// Results of the method.
+	Results []Param `json:"results,omitempty"`
+
+	// Variadic is true if the last parameter is variadic.
+	Variadic bool `json:"variadic,omitempty"`
+}
+
+// Field is a field of a struct.
+type Field struct {
+	// Name of the field (the type name for embedded fields).
+	Name string `json:"name"`
+
+	// Type of the field.
+	Type Type `json:"type"`
+
+	// Tag is the raw struct tag of the field.
+	Tag string `json:"tag,omitempty"`
+
+	// Embedded is true for embedded fields.
+	Embedded bool `json:"embedded,omitempty"`
+
+	// Doc is the doc comment of the field (without comment markers).
+	Doc string `json:"doc,omitempty"`
+
+	// Markers are the values of markers attached to the field (by marker name).
+	Markers map[string][]interface{} `json:"markers,omitempty"`
+}
+
+// TypeDecl describes a declared (named) type.
+type TypeDecl struct {
+	TypeRef
+
+	// Kind of the underlying type (struct, interface or the kind of any other type).
+	Kind TypeKind `json:"kind"`
+
+	// Doc is the doc comment of the type (without comment markers).
+	Doc string `json:"doc,omitempty"`
+
+	// Underlying type (for types other than structs and interfaces).
+	Underlying *Type `json:"underlying,omitempty"`
+
+	// Fields of struct types.
+	Fields []Field `json:"fields,omitempty"`
+
+	// Methods of interface types (including embedded ones) or methods declared on other types.
+	Methods []Method `json:"methods,omitempty"`
+
+	// Markers are the values of markers attached to the type (by marker name).
+	Markers map[string][]interface{} `json:"markers,omitempty"`
+}
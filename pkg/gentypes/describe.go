@@ -0,0 +1,260 @@
+package gentypes
+
+import (
+	"go/ast"
+	"go/types"
+	"strings"
+)
+
+// NewType describes a type expression.
+func NewType(typ types.Type) Type {
+	typ = types.Unalias(typ)
+
+	t := Type{
+		Expr: types.TypeString(typ, func(pkg *types.Package) string { return pkg.Name() }),
+	}
+
+	switch typ := typ.(type) {
+	case *types.Basic:
+		t.Kind = BasicKind
+		t.Name = typ.Name()
+
+	case *types.Named:
+		t.Kind = NamedKind
+		t.Name = typ.Obj().Name()
+
+		if pkg := typ.Obj().Pkg(); pkg != nil {
+			t.Package = &PackageRef{Name: pkg.Name(), Path: pkg.Path()}
+		}
+
+		for i := 0; i < typ.TypeArgs().Len(); i++ {
+			t.TypeArgs = append(t.TypeArgs, NewType(typ.TypeArgs().At(i)))
+		}
+
+	case *types.TypeParam:
+		t.Kind = TypeParamKind
+		t.Name = typ.Obj().Name()
+
+	case *types.Pointer:
+		t.Kind = PointerKind
+		t.Elem = newTypePtr(typ.Elem())
+
+	case *types.Slice:
+		t.Kind = SliceKind
+		t.Elem = newTypePtr(typ.Elem())
+
+	case *types.Array:
+		t.Kind = ArrayKind
+		t.Elem = newTypePtr(typ.Elem())
+		t.Len = typ.Len()
+
+	case *types.Map:
+		t.Kind = MapKind
+		t.Key = newTypePtr(typ.Key())
+		t.Elem = newTypePtr(typ.Elem())
+
+	case *types.Chan:
+		t.Kind = ChanKind
+		t.Elem = newTypePtr(typ.Elem())
+
+		if typ.Dir() == types.SendOnly {
+			t.Dir = "send"
+		} else if typ.Dir() == types.RecvOnly {
+			t.Dir = "recv"
+		}
+
+	case *types.Signature:
+		t.Kind = FuncKind
+		t.Params = newParams(typ.Params())
+		t.Results = newParams(typ.Results())
+		t.Variadic = typ.Variadic()
+
+	case *types.Interface:
+		t.Kind = InterfaceKind
+
+	case *types.Struct:
+		t.Kind = StructKind
+	}
+
+	return t
+}
+
+func newTypePtr(typ types.Type) *Type {
+	t := NewType(typ)
+
+	return &t
+}
+
+func newParams(tuple *types.Tuple) []Param {
+	if tuple.Len() == 0 {
+		return nil
+	}
+
+	params := make([]Param, 0, tuple.Len())
+
+	for i := 0; i < tuple.Len(); i++ {
+		param := tuple.At(i)
+
+		params = append(params, Param{
+			Name: param.Name(),
+			Type: NewType(param.Type()),
+		})
+	}
+
+	return params
+}
+
+// NewMethod describes a method.
+func NewMethod(fn *types.Func, doc *ast.CommentGroup) Method {
+	sig := fn.Type().(*types.Signature)
+
+	return Method{
+		Name:     fn.Name(),
+		Doc:      DocText(doc),
+		Params:   newParams(sig.Params()),
+		Results:  newParams(sig.Results()),
+		Variadic: sig.Variadic(),
+	}
+}
+
+// DescribeType describes a declared type.
+//
+// The declaration and the type spec (if available) provide doc comments for the type,
+// its fields and interface methods.
+func DescribeType(obj *types.TypeName, decl *ast.GenDecl, spec *ast.TypeSpec) TypeDecl {
+	typeDecl := TypeDecl{
+		TypeRef: TypeRef{
+			Name: obj.Name(),
+			Package: PackageRef{
+				Name: obj.Pkg().Name(),
+				Path: obj.Pkg().Path(),
+			},
+		},
+	}
+
+	var fieldDocs, methodDocs map[string]*ast.CommentGroup
+
+	if spec != nil {
+		doc := spec.Doc
+		if doc == nil && decl != nil && !decl.Lparen.IsValid() {
+			doc = decl.Doc
+		}
+
+		typeDecl.Doc = DocText(doc)
+
+		fieldDocs, methodDocs = memberDocs(spec.Type)
+	}
+
+	switch underlying := obj.Type().Underlying().(type) {
+	case *types.Struct:
+		typeDecl.Kind = StructKind
+
+		for i := 0; i < underlying.NumFields(); i++ {
+			field := underlying.Field(i)
+
+			typeDecl.Fields = append(typeDecl.Fields, Field{
+				Name:     field.Name(),
+				Type:     NewType(field.Type()),
+				Tag:      underlying.Tag(i),
+				Embedded: field.Embedded(),
+				Doc:      DocText(fieldDocs[field.Name()]),
+			})
+		}
+
+	case *types.Interface:
+		typeDecl.Kind = InterfaceKind
+
+		for i := 0; i < underlying.NumMethods(); i++ {
+			method := underlying.Method(i)
+
+			typeDecl.Methods = append(typeDecl.Methods, NewMethod(method, methodDocs[method.Name()]))
+		}
+
+		return typeDecl
+
+	default:
+		underlyingType := NewType(underlying)
+
+		typeDecl.Kind = underlyingType.Kind
+		typeDecl.Underlying = &underlyingType
+	}
+
+	if named, ok := types.Unalias(obj.Type()).(*types.Named); ok {
+		for i := 0; i < named.NumMethods(); i++ {
+			typeDecl.Methods = append(typeDecl.Methods, NewMethod(named.Method(i), nil))
+		}
+	}
+
+	return typeDecl
+}
+
+// memberDocs collects doc comments of struct fields and interface methods by name.
+func memberDocs(expr ast.Expr) (map[string]*ast.CommentGroup, map[string]*ast.CommentGroup) {
+	fieldDocs := make(map[string]*ast.CommentGroup)
+	methodDocs := make(map[string]*ast.CommentGroup)
+
+	switch expr := expr.(type) {
+	case *ast.StructType:
+		for _, field := range expr.Fields.List {
+			if len(field.Names) == 0 {
+				fieldDocs[embeddedName(field.Type)] = field.Doc
+			}
+
+			for _, name := range field.Names {
+				fieldDocs[name.Name] = field.Doc
+			}
+		}
+
+	case *ast.InterfaceType:
+		for _, method := range expr.Methods.List {
+			for _, name := range method.Names {
+				methodDocs[name.Name] = method.Doc
+			}
+		}
+	}
+
+	return fieldDocs, methodDocs
+}
+
+// embeddedName returns the field name of an embedded type.
+func embeddedName(expr ast.Expr) string {
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		return expr.Name
+
+	case *ast.StarExpr:
+		return embeddedName(expr.X)
+
+	case *ast.SelectorExpr:
+		return expr.Sel.Name
+
+	case *ast.IndexExpr:
+		return embeddedName(expr.X)
+
+	case *ast.IndexListExpr:
+		return embeddedName(expr.X)
+
+	default:
+		return ""
+	}
+}
+
+// DocText returns the text of a doc comment without markers (lines starting with +).
+func DocText(doc *ast.CommentGroup) string {
+	if doc == nil {
+		return ""
+	}
+
+	lines := strings.Split(doc.Text(), "\n")
+	text := lines[:0]
+
+	for _, line := range lines {
+		if strings.HasPrefix(line, "+") {
+			continue
+		}
+
+		text = append(text, line)
+	}
+
+	return strings.TrimSpace(strings.Join(text, "\n"))
+}
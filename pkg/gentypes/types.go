@@ -4,20 +4,20 @@ package gentypes
 // It can be used generate code referring to a type, possibly in another package.
 type TypeRef struct {
 	// Name of the type.
-	Name string
+	Name string `json:"name"`
 
 	// Package is a reference to the package where the type can be found.
-	Package PackageRef
+	Package PackageRef `json:"package"`
 }
 
 // PackageRef is a reference to a package.
 // It can be used generate code referring to a package.
 type PackageRef struct {
 	// Name of the package.
-	Name string
+	Name string `json:"name"`
 
 	// Path to the package.
-	Path string
+	Path string `json:"path"`
 }
 
 // Argument is an argument or return argument.
@@ -0,0 +1,367 @@
+// Package gentest runs generators over txtar archives of Go sources and compares their output with golden files.
+//
+// Archives are processed in memory: their files are passed to the go command as an overlay
+// (see packages.Config.Overlay) and generated files are kept in memory, nothing is written to disk.
+// The go command still loads packages (so tests need a Go toolchain) from an empty temporary working directory.
+// Unless the archive contains a go.mod file, the module is called example.com/gentest
+// and it requires the same modules as the module of the test (including the module itself),
+// so generated code may import any of them.
+// Required modules must be in the module cache already: the go command runs with GOPROXY=off.
+//
+// Golden files are txtar archives as well, listing every generated file (relative to the module root).
+// Errors reported by the generator are listed in an "errors" file.
+// Run tests with -update to write the actual output to the golden files.
+package gentest
+
+import (
+	"bytes"
+	"flag"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/tools/txtar"
+	"sigs.k8s.io/controller-tools/pkg/genall"
+	"sigs.k8s.io/controller-tools/pkg/loader"
+
+	"sagikazarmark.dev/mga/internal/generate/runner"
+	"sagikazarmark.dev/mga/pkg/genutils"
+)
+
+// nolint: gochecknoglobals
+var update = flag.Bool("update", false, "update golden files")
+
+// ModulePath is the path of the module archives are loaded into (unless they contain a go.mod file).
+const ModulePath = "example.com/gentest"
+
+// errorsFile lists errors reported by the generator in golden files.
+const errorsFile = "errors"
+
+// Test is a generator test case.
+type Test struct {
+	// Generator under test.
+	Generator genall.Generator
+
+	// OutputRule of the generator. Defaults to writing files into the package (pkg).
+	//
+	// Files are kept in memory by their paths, so the rule has to write files (see genutils.OutputPath).
+	OutputRule genall.OutputRule
+
+	// Patterns of packages to generate code for. Defaults to every package in the archive.
+	//
+	// Directories of the archive are not created on disk, so packages have to be selected by import path
+	// (eg. ModulePath + "/todo") instead of relative paths.
+	Patterns []string
+
+	// Golden is the path of the golden file.
+	Golden string
+
+	// Compile type checks packages (including test files) in the archive after generating code.
+	Compile bool
+}
+
+// RunFile runs a generator over a txtar archive read from a file.
+//
+// The golden file defaults to the path of the archive with a .golden extension.
+func RunFile(t *testing.T, path string, test Test) {
+	t.Helper()
+
+	archive, err := txtar.ParseFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if test.Golden == "" {
+		test.Golden = strings.TrimSuffix(path, filepath.Ext(path)) + ".golden"
+	}
+
+	Run(t, archive, test)
+}
+
+// Run runs a generator over a txtar archive and compares its output with the golden file.
+func Run(t *testing.T, archive *txtar.Archive, test Test) {
+	t.Helper()
+
+	if test.Golden == "" {
+		t.Fatal("gentest: no golden file")
+	}
+
+	if test.OutputRule == nil {
+		test.OutputRule = genutils.OutputPackage{}
+	}
+
+	if len(test.Patterns) == 0 {
+		test.Patterns = []string{"./..."}
+	}
+
+	dir, files, env := overlay(t, archive)
+
+	fset := token.NewFileSet()
+
+	roots, err := loader.LoadRootsWithConfig(
+		&packages.Config{
+			Mode:    packages.NeedDeps | packages.NeedTypes | packages.NeedModule,
+			Dir:     dir,
+			Env:     env,
+			Fset:    fset,
+			Overlay: files,
+		},
+		test.Patterns...,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parse(t, fset, files, roots)
+
+	output := &memoryOutput{rule: test.OutputRule, files: make(map[string][]byte)}
+
+	runtime, err := runner.New([]runner.Generator{{Name: "gentest", Generator: test.Generator, OutputRule: output}}, roots)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var errs bytes.Buffer
+
+	runtime.ErrorWriter = &errs
+	runtime.InputRule = inputFromFiles{dir: dir, files: files}
+
+	runtime.Run()
+
+	actual := outputs(t, dir, files, output.files)
+
+	if errs.Len() > 0 {
+		actual.Files = append(actual.Files, txtar.File{
+			Name: errorsFile,
+			Data: []byte(strings.ReplaceAll(errs.String(), dir+string(filepath.Separator), "")),
+		})
+	}
+
+	if *update {
+		if err := os.WriteFile(test.Golden, txtar.Format(actual), 0o644); err != nil { // nolint: gosec
+			t.Fatal(err)
+		}
+	} else {
+		compare(t, test.Golden, actual)
+	}
+
+	if test.Compile {
+		for path, content := range output.files {
+			files[path] = content
+		}
+
+		compile(t, dir, files, env)
+	}
+}
+
+// parse parses the files of packages in the archive.
+//
+// The loader reads source files from disk (see loader.Package.NeedSyntax),
+// so packages in the archive (roots and their imports) are parsed from the overlay instead.
+func parse(t *testing.T, fset *token.FileSet, files map[string][]byte, roots []*loader.Package) {
+	t.Helper()
+
+	seen := make(map[*loader.Package]bool)
+	queue := append([]*loader.Package(nil), roots...)
+
+	for len(queue) > 0 {
+		pkg := queue[0]
+		queue = queue[1:]
+
+		if seen[pkg] {
+			continue
+		}
+
+		seen[pkg] = true
+
+		// packages outside of the archive (which can't import packages in the archive) are read from disk
+		if pkg.Syntax != nil || len(pkg.CompiledGoFiles) == 0 {
+			continue
+		}
+
+		if _, ok := files[pkg.CompiledGoFiles[0]]; !ok {
+			continue
+		}
+
+		syntax := make([]*ast.File, 0, len(pkg.CompiledGoFiles))
+
+		for _, path := range pkg.CompiledGoFiles {
+			file, err := parser.ParseFile(fset, path, files[path], parser.AllErrors|parser.ParseComments)
+			if err != nil {
+				pkg.AddError(err)
+
+				break
+			}
+
+			syntax = append(syntax, file)
+		}
+
+		if len(syntax) == len(pkg.CompiledGoFiles) {
+			pkg.Syntax = syntax
+		}
+
+		for _, imported := range pkg.Imports() {
+			queue = append(queue, imported)
+		}
+	}
+}
+
+// outputs returns files written or modified by the generator.
+func outputs(t *testing.T, dir string, inputs map[string][]byte, written map[string][]byte) *txtar.Archive {
+	t.Helper()
+
+	actual := &txtar.Archive{}
+
+	for path, content := range written {
+		name, err := filepath.Rel(dir, path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if input, ok := inputs[path]; ok && bytes.Equal(input, content) {
+			continue
+		}
+
+		actual.Files = append(actual.Files, txtar.File{Name: filepath.ToSlash(name), Data: content})
+	}
+
+	sort.Slice(actual.Files, func(i, j int) bool { return actual.Files[i].Name < actual.Files[j].Name })
+
+	return actual
+}
+
+// compare compares generated files with the golden file.
+func compare(t *testing.T, golden string, actual *txtar.Archive) {
+	t.Helper()
+
+	expected, err := txtar.ParseFile(golden)
+	if os.IsNotExist(err) {
+		t.Fatalf("gentest: golden file %s does not exist (run tests with -update to create it)", golden)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedFiles := make(map[string][]byte, len(expected.Files))
+	for _, file := range expected.Files {
+		expectedFiles[file.Name] = file.Data
+	}
+
+	for _, file := range actual.Files {
+		want, ok := expectedFiles[file.Name]
+		if !ok {
+			t.Errorf("unexpected file %s:\n%s", file.Name, file.Data)
+
+			continue
+		}
+
+		delete(expectedFiles, file.Name)
+
+		assert.Equal(t, string(want), string(file.Data), "%s does not match the golden file (run tests with -update to update it)", file.Name)
+	}
+
+	for _, file := range expected.Files {
+		if _, ok := expectedFiles[file.Name]; ok {
+			t.Errorf("missing file %s", file.Name)
+		}
+	}
+}
+
+// compile type checks every package in the archive (along with the generated files).
+func compile(t *testing.T, dir string, files map[string][]byte, env []string) {
+	t.Helper()
+
+	pkgs, err := packages.Load(
+		&packages.Config{
+			Mode:    packages.NeedName | packages.NeedFiles | packages.NeedSyntax | packages.NeedTypes | packages.NeedImports | packages.NeedDeps,
+			Dir:     dir,
+			Env:     env,
+			Overlay: files,
+			Tests:   true,
+		},
+		"./...",
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, pkg := range pkgs {
+		for _, err := range pkg.Errors {
+			t.Errorf("compiling generated code: %s", strings.ReplaceAll(err.Error(), dir+string(filepath.Separator), ""))
+		}
+	}
+}
+
+// memoryOutput is an output rule keeping generated files in memory.
+//
+// Files are kept by the paths the wrapped output rule would write them to.
+type memoryOutput struct {
+	rule genall.OutputRule
+
+	mu    sync.Mutex
+	files map[string][]byte
+}
+
+func (o *memoryOutput) Open(pkg *loader.Package, itemPath string) (io.WriteCloser, error) {
+	path, err := genutils.OutputPath(o.rule, pkg, itemPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return &memoryFile{output: o, path: path}, nil
+}
+
+// PackageRef returns package reference (name and path) based on the wrapped output rule.
+func (o *memoryOutput) PackageRef(pkg *loader.Package) (string, string) {
+	if pkgrefer, ok := o.rule.(genutils.PackageRefer); ok {
+		return pkgrefer.PackageRef(pkg)
+	}
+
+	return pkg.Name, pkg.PkgPath
+}
+
+type memoryFile struct {
+	bytes.Buffer
+
+	output *memoryOutput
+	path   string
+}
+
+func (f *memoryFile) Close() error {
+	f.output.mu.Lock()
+	defer f.output.mu.Unlock()
+
+	f.output.files[f.path] = f.Bytes()
+
+	return nil
+}
+
+// inputFromFiles reads files of the archive (relative to the module root).
+type inputFromFiles struct {
+	dir   string
+	files map[string][]byte
+}
+
+func (i inputFromFiles) OpenForRead(path string) (io.ReadCloser, error) {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(i.dir, path)
+	}
+
+	content, ok := i.files[filepath.Clean(path)]
+	if !ok {
+		// the same error as reading a missing file from disk
+		return nil, &os.PathError{Op: "open", Path: path, Err: syscall.ENOENT}
+	}
+
+	return io.NopCloser(bytes.NewReader(content)), nil
+}
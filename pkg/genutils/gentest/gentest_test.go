@@ -0,0 +1,129 @@
+package gentest_test
+
+import (
+	"flag"
+	"fmt"
+	"go/ast"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/tools/txtar"
+	"sigs.k8s.io/controller-tools/pkg/genall"
+	"sigs.k8s.io/controller-tools/pkg/loader"
+	"sigs.k8s.io/controller-tools/pkg/markers"
+
+	"sagikazarmark.dev/mga/pkg/generator"
+	"sagikazarmark.dev/mga/pkg/genutils"
+	"sagikazarmark.dev/mga/pkg/genutils/gentest"
+)
+
+// typeNamesGenerator lists the types declared in a package.
+type typeNamesGenerator struct{}
+
+func (typeNamesGenerator) RegisterMarkers(_ *markers.Registry) error {
+	return nil
+}
+
+func (typeNamesGenerator) Generate(ctx *genall.GenerationContext) error {
+	for _, root := range ctx.Roots {
+		var names []string
+
+		root.NeedSyntax()
+
+		for _, file := range root.Syntax {
+			ast.Inspect(file, func(node ast.Node) bool {
+				spec, ok := node.(*ast.TypeSpec)
+				if !ok {
+					return true
+				}
+
+				if strings.HasPrefix(spec.Name.Name, "Invalid") {
+					root.AddError(loader.ErrFromNode(fmt.Errorf("invalid type %s", spec.Name.Name), spec))
+
+					return false
+				}
+
+				names = append(names, fmt.Sprintf("%q", spec.Name.Name))
+
+				return false
+			})
+		}
+
+		if len(names) == 0 {
+			continue
+		}
+
+		sort.Strings(names)
+
+		outputPackage := generator.OutputPackage(ctx, root)
+
+		generator.WriteFile(ctx, root, "zz_generated.types.go", []byte(fmt.Sprintf(
+			"package %s\n\nvar TypeNames = []string{%s}\n",
+			outputPackage.Name,
+			strings.Join(names, ", "),
+		)))
+	}
+
+	return nil
+}
+
+func TestRunFile(t *testing.T) {
+	tests := []struct {
+		name       string
+		outputRule genall.OutputRule
+		compile    bool
+	}{
+		{
+			name:    "package",
+			compile: true,
+		},
+		{
+			name:       "subpackage",
+			outputRule: genutils.OutputSubpackage{Suffix: "types"},
+			compile:    true,
+		},
+		{
+			name: "errors",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			gentest.RunFile(t, "testdata/"+test.name+".txtar", gentest.Test{
+				Generator:  typeNamesGenerator{},
+				OutputRule: test.outputRule,
+				Compile:    test.compile,
+			})
+		})
+	}
+}
+
+func TestRun_Update(t *testing.T) {
+	require.NoError(t, flag.Set("update", "true"))
+	t.Cleanup(func() { _ = flag.Set("update", "false") })
+
+	archive, err := txtar.ParseFile("testdata/package.txtar")
+	require.NoError(t, err)
+
+	golden := filepath.Join(t.TempDir(), "package.golden")
+
+	gentest.Run(t, archive, gentest.Test{
+		Generator: typeNamesGenerator{},
+		Golden:    golden,
+	})
+
+	actual, err := os.ReadFile(golden)
+	require.NoError(t, err)
+
+	expected, err := os.ReadFile("testdata/package.golden")
+	require.NoError(t, err)
+
+	assert.Equal(t, string(expected), string(actual))
+}
@@ -0,0 +1,138 @@
+package gentest
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/tools/txtar"
+)
+
+// overlay returns the files of an archive (along with a go.mod file if the archive does not contain one)
+// by their paths in a virtual module root (see packages.Config.Overlay),
+// the module root directory and the environment of the go command.
+//
+// The module root is an empty directory: the go command needs an existing working directory,
+// but nothing is written to it.
+func overlay(t *testing.T, archive *txtar.Archive) (string, map[string][]byte, []string) {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	files := make(map[string][]byte, len(archive.Files)+2)
+
+	for _, file := range archive.Files {
+		name := filepath.Clean(filepath.FromSlash(file.Name))
+		if !filepath.IsLocal(name) {
+			t.Fatalf("gentest: invalid file name in archive: %s", file.Name)
+		}
+
+		files[filepath.Join(dir, name)] = file.Data
+	}
+
+	if _, ok := files[filepath.Join(dir, "go.mod")]; !ok {
+		addModule(t, dir, files)
+	}
+
+	env := append(
+		os.Environ(),
+		"GOFLAGS=-mod=mod",
+		"GOPROXY=off",
+		"GOWORK=off",
+		"GOTOOLCHAIN=local",
+	)
+
+	return dir, files, env
+}
+
+// addModule adds a go.mod (and go.sum) file requiring the same modules as the module of the test to the files.
+func addModule(t *testing.T, dir string, files map[string][]byte) {
+	t.Helper()
+
+	out, err := exec.Command("go", "env", "GOMOD").Output()
+	if err != nil {
+		t.Fatalf("gentest: finding the current module: %v", err)
+	}
+
+	parentPath := strings.TrimSpace(string(out))
+
+	module := &modfile.File{}
+
+	if err := module.AddModuleStmt(ModulePath); err != nil {
+		t.Fatal(err)
+	}
+
+	// outside of a module (GOMOD is empty or os.DevNull) archives can only import the standard library
+	if parentPath == "" || parentPath == os.DevNull {
+		files[filepath.Join(dir, "go.mod")] = format(t, module)
+
+		return
+	}
+
+	parentContent, err := os.ReadFile(parentPath) // nolint: gosec
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parent, err := modfile.Parse(parentPath, parentContent, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parentDir := filepath.Dir(parentPath)
+
+	if parent.Go != nil {
+		if err := module.AddGoStmt(parent.Go.Version); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, req := range parent.Require {
+		module.AddNewRequire(req.Mod.Path, req.Mod.Version, req.Indirect)
+	}
+
+	module.AddNewRequire(parent.Module.Mod.Path, "v0.0.0", false)
+
+	if err := module.AddReplace(parent.Module.Mod.Path, "", parentDir, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, rep := range parent.Replace {
+		newPath := rep.New.Path
+
+		// local replacements are relative to the parent module
+		if rep.New.Version == "" && !filepath.IsAbs(newPath) {
+			newPath = filepath.Join(parentDir, newPath)
+		}
+
+		if err := module.AddReplace(rep.Old.Path, rep.Old.Version, newPath, rep.New.Version); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files[filepath.Join(dir, "go.mod")] = format(t, module)
+
+	sum, err := os.ReadFile(filepath.Join(parentDir, "go.sum"))
+	if err != nil && !os.IsNotExist(err) {
+		t.Fatal(err)
+	}
+
+	files[filepath.Join(dir, "go.sum")] = sum
+}
+
+func format(t *testing.T, module *modfile.File) []byte {
+	t.Helper()
+
+	module.Cleanup()
+
+	content, err := module.Format()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return bytes.TrimSpace(content)
+}
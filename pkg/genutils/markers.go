@@ -0,0 +1,15 @@
+package genutils
+
+// Kinds of declarations markers can be restricted to (see MarkerKinder).
+const (
+	InterfaceKind = "interface"
+	StructKind    = "struct"
+	FieldKind     = "field"
+)
+
+// MarkerKinder is implemented by generators restricting their markers to specific kinds of declarations
+// (beyond the target of the marker definitions).
+type MarkerKinder interface {
+	// MarkerKinds maps marker names to the kind of declaration (eg. interface) they can be used on.
+	MarkerKinds() map[string]string
+}
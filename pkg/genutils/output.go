@@ -3,8 +3,12 @@ package genutils
 import (
 	"errors"
 	"fmt"
+	"go/token"
 	"io"
+	"path"
 	"path/filepath"
+	"strings"
+	"text/template"
 
 	"sigs.k8s.io/controller-tools/pkg/genall"
 	"sigs.k8s.io/controller-tools/pkg/loader"
@@ -13,17 +17,31 @@ import (
 
 // nolint: gochecknoinits
 func init() {
+	if err := RegisterOutputRules(optionsRegistry); err != nil {
+		panic(err)
+	}
+}
+
+// RegisterOutputRules registers output rules (as +output:<rule> markers) in a registry.
+func RegisterOutputRules(into *markers.Registry) error {
 	for ruleName, rule := range allOutputRules {
-		ruleMarker := markers.Must(markers.MakeDefinition("output:"+ruleName, markers.DescribesPackage, rule))
-		if err := optionsRegistry.Register(ruleMarker); err != nil {
-			panic(err)
+		ruleMarker, err := markers.MakeDefinition("output:"+ruleName, markers.DescribesPackage, rule)
+		if err != nil {
+			return err
+		}
+
+		if err := into.Register(ruleMarker); err != nil {
+			return err
 		}
+
 		if helpGiver, hasHelp := rule.(genall.HasHelp); hasHelp {
 			if help := helpGiver.Help(); help != nil {
-				optionsRegistry.AddHelp(ruleMarker, help)
+				into.AddHelp(ruleMarker, help)
 			}
 		}
 	}
+
+	return nil
 }
 
 // nolint: gochecknoglobals
@@ -34,6 +52,7 @@ var (
 		"stdout": genall.OutputToStdout,
 		"pkg":    OutputPackage{},
 		"subpkg": OutputSubpackage{},
+		"mirror": OutputMirror{},
 	}
 
 	optionsRegistry = &markers.Registry{}
@@ -64,19 +83,51 @@ type PackageRefer interface {
 	PackageRef(pkg *loader.Package) (string, string)
 }
 
+// OutputPather returns the path where an output rule writes an artifact.
+type OutputPather interface {
+	OutputPath(pkg *loader.Package, itemPath string) (string, error)
+}
+
+// OutputFiler is implemented by generators knowing the names of the files they write.
+//
+// Names may be glob patterns (see filepath.Match), eg. for files generated per type.
+type OutputFiler interface {
+	OutputFiles() []string
+}
+
+// OutputPath returns the path where an output rule writes an artifact.
+func OutputPath(rule genall.OutputRule, pkg *loader.Package, itemPath string) (string, error) {
+	switch r := rule.(type) {
+	case OutputPather:
+		return r.OutputPath(pkg, itemPath)
+
+	case genall.OutputToDirectory:
+		return filepath.Join(string(r), itemPath), nil
+	}
+
+	return "", fmt.Errorf("output rule %T does not write files", rule)
+}
+
 // +controllertools:marker:generateHelp:category=""
 
 // OutputPackage outputs artifacts to the original package location.
 type OutputPackage struct{}
 
 func (o OutputPackage) Open(pkg *loader.Package, itemPath string) (io.WriteCloser, error) {
-	if len(pkg.CompiledGoFiles) == 0 {
-		return nil, fmt.Errorf("cannot output to a package with no path on disk")
+	path, err := o.OutputPath(pkg, itemPath)
+	if err != nil {
+		return nil, err
 	}
 
-	outDir := filepath.Join(filepath.Dir(pkg.CompiledGoFiles[0]), filepath.Dir(itemPath))
+	return genall.OutputToDirectory(filepath.Dir(path)).Open(pkg, filepath.Base(path))
+}
+
+func (o OutputPackage) OutputPath(pkg *loader.Package, itemPath string) (string, error) {
+	if len(pkg.CompiledGoFiles) == 0 {
+		return "", fmt.Errorf("cannot output to a package with no path on disk")
+	}
 
-	return genall.OutputToDirectory(outDir).Open(pkg, filepath.Base(itemPath))
+	return filepath.Join(filepath.Dir(pkg.CompiledGoFiles[0]), itemPath), nil
 }
 
 func (o OutputPackage) PackageRef(pkg *loader.Package) (string, string) {
@@ -98,13 +149,20 @@ type OutputSubpackage struct {
 }
 
 func (o OutputSubpackage) Open(pkg *loader.Package, itemPath string) (io.WriteCloser, error) {
-	if len(pkg.CompiledGoFiles) == 0 {
-		return nil, fmt.Errorf("cannot output to a package with no path on disk")
+	path, err := o.OutputPath(pkg, itemPath)
+	if err != nil {
+		return nil, err
 	}
 
-	outDir := filepath.Join(filepath.Dir(pkg.CompiledGoFiles[0]), o.packageName(pkg))
+	return genall.OutputToDirectory(filepath.Dir(path)).Open(pkg, filepath.Base(path))
+}
+
+func (o OutputSubpackage) OutputPath(pkg *loader.Package, itemPath string) (string, error) {
+	if len(pkg.CompiledGoFiles) == 0 {
+		return "", fmt.Errorf("cannot output to a package with no path on disk")
+	}
 
-	return genall.OutputToDirectory(outDir).Open(pkg, filepath.Base(itemPath))
+	return filepath.Join(filepath.Dir(pkg.CompiledGoFiles[0]), o.packageName(pkg), filepath.Base(itemPath)), nil
 }
 
 func (o OutputSubpackage) PackageRef(pkg *loader.Package) (string, string) {
@@ -121,3 +179,113 @@ func (o OutputSubpackage) packageName(pkg *loader.Package) string {
 
 	return o.Prefix + pkgName + o.Suffix
 }
+
+// +controllertools:marker:generateHelp:category=""
+
+// OutputMirror outputs artifacts to the same relative path under a central directory of the module.
+//
+// For example, with internal/mocks as root, artifacts of the example.com/app/pkg/todo package
+// are written to internal/mocks/pkg/todo (package example.com/app/internal/mocks/pkg/todo).
+type OutputMirror struct {
+	// Root is the directory (relative to the module root) the source tree is mirrored under.
+	Root string `marker:"root"`
+
+	// Package is a template for the package name (eg. {{ .Name }}mocks).
+	// The template receives the name (.Name) and the import path (.Path) of the original package.
+	// When empty, the original package name is used.
+	Package string `marker:",optional"`
+}
+
+func (o OutputMirror) Open(pkg *loader.Package, itemPath string) (io.WriteCloser, error) {
+	path, err := o.OutputPath(pkg, itemPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return genall.OutputToDirectory(filepath.Dir(path)).Open(pkg, filepath.Base(path))
+}
+
+func (o OutputMirror) OutputPath(pkg *loader.Package, itemPath string) (string, error) {
+	// the package name is not part of the path, but invalid templates should fail writing files
+	if _, err := o.packageName(pkg); err != nil {
+		return "", err
+	}
+
+	dir, _, err := o.location(pkg)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, filepath.Base(itemPath)), nil
+}
+
+// PackageRef returns the mirrored package.
+//
+// It falls back to the original package when the location cannot be determined
+// (the error is reported when writing files).
+func (o OutputMirror) PackageRef(pkg *loader.Package) (string, string) {
+	pkgName, err := o.packageName(pkg)
+	if err != nil {
+		return pkg.Name, pkg.PkgPath
+	}
+
+	_, pkgPath, err := o.location(pkg)
+	if err != nil {
+		return pkg.Name, pkg.PkgPath
+	}
+
+	return pkgName, pkgPath
+}
+
+// location returns the directory and the import path of the mirrored package.
+func (o OutputMirror) location(pkg *loader.Package) (string, string, error) {
+	root := filepath.Clean(filepath.FromSlash(o.Root))
+	if o.Root == "" || !filepath.IsLocal(root) {
+		return "", "", fmt.Errorf("mirror root must be a directory within the module: %q", o.Root)
+	}
+
+	module := pkg.Module
+	if module == nil || module.Dir == "" {
+		return "", "", fmt.Errorf("cannot determine the module of package %s", pkg.PkgPath)
+	}
+
+	if pkg.PkgPath != module.Path && !strings.HasPrefix(pkg.PkgPath, module.Path+"/") {
+		return "", "", fmt.Errorf("package %s is not in module %s", pkg.PkgPath, module.Path)
+	}
+
+	relPath := strings.TrimPrefix(strings.TrimPrefix(pkg.PkgPath, module.Path), "/")
+
+	return filepath.Join(module.Dir, root, filepath.FromSlash(relPath)),
+		path.Join(module.Path, filepath.ToSlash(root), relPath),
+		nil
+}
+
+func (o OutputMirror) packageName(pkg *loader.Package) (string, error) {
+	if o.Package == "" {
+		return pkg.Name, nil
+	}
+
+	tmpl, err := template.New("package").Option("missingkey=error").Parse(o.Package)
+	if err != nil {
+		return "", fmt.Errorf("invalid package name template: %w", err)
+	}
+
+	var pkgName strings.Builder
+
+	err = tmpl.Execute(&pkgName, struct {
+		Name string
+		Path string
+	}{
+		Name: pkg.Name,
+		Path: pkg.PkgPath,
+	})
+	if err != nil {
+		return "", fmt.Errorf("invalid package name template: %w", err)
+	}
+
+	if !token.IsIdentifier(pkgName.String()) {
+		return "", fmt.Errorf("invalid package name: %q", pkgName.String())
+	}
+
+	return pkgName.String(), nil
+}
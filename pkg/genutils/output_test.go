@@ -0,0 +1,155 @@
+package genutils
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/tools/go/packages"
+	"sigs.k8s.io/controller-tools/pkg/loader"
+)
+
+func mirrorTestPackage(pkgPath string) *loader.Package {
+	return &loader.Package{
+		Package: &packages.Package{
+			Name:    "todo",
+			PkgPath: pkgPath,
+			Module: &packages.Module{
+				Path: "example.com/app",
+				Dir:  filepath.FromSlash("/src/app"),
+			},
+		},
+	}
+}
+
+func TestLookupOutput_Mirror(t *testing.T) {
+	tests := []struct {
+		output   string
+		expected OutputMirror
+	}{
+		{
+			output:   "mirror:root=internal/mocks",
+			expected: OutputMirror{Root: "internal/mocks"},
+		},
+		{
+			output:   `mirror:root=internal/mocks,package="{{ .Name }}mocks"`,
+			expected: OutputMirror{Root: "internal/mocks", Package: "{{ .Name }}mocks"},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.output, func(t *testing.T) {
+			outputRule, err := LookupOutput(test.output)
+			require.NoError(t, err)
+
+			assert.Equal(t, test.expected, outputRule)
+		})
+	}
+}
+
+func TestOutputMirror(t *testing.T) {
+	tests := []struct {
+		name        string
+		rule        OutputMirror
+		pkgPath     string
+		path        string
+		packageName string
+		packagePath string
+	}{
+		{
+			name:        "default",
+			rule:        OutputMirror{Root: "internal/mocks"},
+			pkgPath:     "example.com/app/pkg/todo",
+			path:        "/src/app/internal/mocks/pkg/todo/zz_generated.mock.go",
+			packageName: "todo",
+			packagePath: "example.com/app/internal/mocks/pkg/todo",
+		},
+		{
+			name:        "package_template",
+			rule:        OutputMirror{Root: "./internal/mocks/", Package: "{{ .Name }}mocks"},
+			pkgPath:     "example.com/app/pkg/todo",
+			path:        "/src/app/internal/mocks/pkg/todo/zz_generated.mock.go",
+			packageName: "todomocks",
+			packagePath: "example.com/app/internal/mocks/pkg/todo",
+		},
+		{
+			name:        "module_root",
+			rule:        OutputMirror{Root: "mocks"},
+			pkgPath:     "example.com/app",
+			path:        "/src/app/mocks/zz_generated.mock.go",
+			packageName: "todo",
+			packagePath: "example.com/app/mocks",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			pkg := mirrorTestPackage(test.pkgPath)
+
+			path, err := test.rule.OutputPath(pkg, "zz_generated.mock.go")
+			require.NoError(t, err)
+
+			assert.Equal(t, filepath.FromSlash(test.path), path)
+
+			packageName, packagePath := test.rule.PackageRef(pkg)
+
+			assert.Equal(t, test.packageName, packageName)
+			assert.Equal(t, test.packagePath, packagePath)
+		})
+	}
+}
+
+func TestOutputMirror_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    OutputMirror
+		pkgPath string
+	}{
+		{
+			name:    "root_outside_module",
+			rule:    OutputMirror{Root: "../mocks"},
+			pkgPath: "example.com/app/pkg/todo",
+		},
+		{
+			name:    "absolute_root",
+			rule:    OutputMirror{Root: "/mocks"},
+			pkgPath: "example.com/app/pkg/todo",
+		},
+		{
+			name:    "package_outside_module",
+			rule:    OutputMirror{Root: "mocks"},
+			pkgPath: "example.com/other/todo",
+		},
+		{
+			name:    "invalid_template",
+			rule:    OutputMirror{Root: "mocks", Package: "{{ .Name "},
+			pkgPath: "example.com/app/pkg/todo",
+		},
+		{
+			name:    "invalid_package_name",
+			rule:    OutputMirror{Root: "mocks", Package: "{{ .Name }}-mocks"},
+			pkgPath: "example.com/app/pkg/todo",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			pkg := mirrorTestPackage(test.pkgPath)
+
+			_, err := test.rule.OutputPath(pkg, "zz_generated.mock.go")
+			require.Error(t, err)
+
+			packageName, packagePath := test.rule.PackageRef(pkg)
+
+			assert.Equal(t, "todo", packageName)
+			assert.Equal(t, test.pkgPath, packagePath)
+		})
+	}
+}
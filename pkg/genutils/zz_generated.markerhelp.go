@@ -0,0 +1,66 @@
+//go:build !ignore_autogenerated
+
+// Marker documentation shown by the markers command.
+
+// Code generated by helpgen. DO NOT EDIT.
+
+package genutils
+
+import (
+	"sigs.k8s.io/controller-tools/pkg/markers"
+)
+
+func (OutputMirror) Help() *markers.DefinitionHelp {
+	return &markers.DefinitionHelp{
+		Category: "",
+		DetailedHelp: markers.DetailedHelp{
+			Summary: "outputs artifacts to the same relative path under a central directory of the module.",
+			Details: "For example, with internal/mocks as root, artifacts of the example.com/app/pkg/todo package\nare written to internal/mocks/pkg/todo (package example.com/app/internal/mocks/pkg/todo).",
+		},
+		FieldHelp: map[string]markers.DetailedHelp{
+			"Root": {
+				Summary: "is the directory (relative to the module root) the source tree is mirrored under.",
+				Details: "",
+			},
+			"Package": {
+				Summary: "is a template for the package name (eg. {{ .Name }}mocks).",
+				Details: "The template receives the name (.Name) and the import path (.Path) of the original package.\nWhen empty, the original package name is used.",
+			},
+		},
+	}
+}
+
+func (OutputPackage) Help() *markers.DefinitionHelp {
+	return &markers.DefinitionHelp{
+		Category: "",
+		DetailedHelp: markers.DetailedHelp{
+			Summary: "outputs artifacts to the original package location.",
+			Details: "",
+		},
+		FieldHelp: map[string]markers.DetailedHelp{},
+	}
+}
+
+func (OutputSubpackage) Help() *markers.DefinitionHelp {
+	return &markers.DefinitionHelp{
+		Category: "",
+		DetailedHelp: markers.DetailedHelp{
+			Summary: "outputs artifacts to a subpackage of the original package.",
+			Details: "",
+		},
+		FieldHelp: map[string]markers.DetailedHelp{
+			"Prefix": {
+				Summary: "added to the package name.",
+				Details: "",
+			},
+			"Package": {
+				Summary: "is the main package name. When empty, the original package name is used.",
+				Details: "",
+			},
+			"Suffix": {
+				Summary: "added to the package name.",
+				Details: "",
+			},
+		},
+	}
+}